@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/analyze"
+)
+
+// dotFlags are the Graphviz styling flags shared by summarize and analyze
+type dotFlags struct {
+	heatmap  bool
+	cluster  string
+	issues   bool
+	minCount int
+	legend   bool
+}
+
+func addDOTFlags(fs *flag.FlagSet) *dotFlags {
+	d := &dotFlags{}
+	fs.BoolVar(&d.heatmap, "dot-heatmap", false, "Color DOT nodes by update count")
+	fs.StringVar(&d.cluster, "dot-cluster", "", "Cluster DOT nodes by correlated source: file|module (needs -source)")
+	fs.BoolVar(&d.issues, "dot-issues", false, "Highlight detected issue paths in the DOT output")
+	fs.IntVar(&d.minCount, "dot-min-count", 0, "Drop DOT nodes updated fewer than N times")
+	fs.BoolVar(&d.legend, "dot-legend", false, "Add a legend to the DOT output")
+	return d
+}
+
+// validate checks flag combinations before any work is done
+func (d *dotFlags) validate(sourceRoot string) error {
+	switch d.cluster {
+	case "", "file", "module":
+	default:
+		return fmt.Errorf("unknown -dot-cluster %q (want file|module)", d.cluster)
+	}
+	if d.cluster != "" && sourceRoot == "" {
+		return fmt.Errorf("-dot-cluster needs -source")
+	}
+	return nil
+}
+
+func (d *dotFlags) options() analyze.DOTOptions {
+	return analyze.DOTOptions{Heatmap: d.heatmap, MinCount: d.minCount, Legend: d.legend}
+}
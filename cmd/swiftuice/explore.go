@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/analyze"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/explore"
+)
+
+func cmdExplore(args []string) int {
+	fs := flag.NewFlagSet("explore", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	var input string
+	var sourceRoot string
+	fs.StringVar(&input, "in", "", "Analysis report (analysis.json), export directory, .trace or saved graph")
+	fs.StringVar(&sourceRoot, "source", "", "Swift source root for code correlation and snippets (optional)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if input == "" {
+		fmt.Fprintln(os.Stderr, "-in is required")
+		fs.Usage()
+		return 2
+	}
+
+	report, err := loadReport(input, sourceRoot)
+	if err != nil {
+		if errors.Is(err, analyze.ErrNoData) {
+			fmt.Fprintln(os.Stderr, "no parseable Cause & Effect data found; see trace/export limitations")
+			return 3
+		}
+		fmt.Fprintln(os.Stderr, "load failed:", err)
+		return 1
+	}
+
+	if err := explore.Run(explore.New(report), os.Stdin, os.Stdout); err != nil {
+		if errors.Is(err, explore.ErrNotTerminal) {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, "explore failed:", err)
+		return 1
+	}
+	return 0
+}
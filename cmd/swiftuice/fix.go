@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/analyze"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/compare"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/patch"
+)
+
+func cmdFix(args []string) int {
+	fs := flag.NewFlagSet("fix", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	var input string
+	var sourceRoot string
+	var issueID string
+	var fixID string
+	var dryRun bool
+	var apply bool
+	var backupSuffix string
+	fs.StringVar(&input, "in", "", "Export directory, .trace, saved graph or analysis report")
+	fs.StringVar(&sourceRoot, "source", "", "Swift source root (default: the report's source root)")
+	fs.StringVar(&issueID, "issue", "", "Issue ID to fix, e.g. issue-1")
+	fs.StringVar(&fixID, "fix", "", "Fix ID to apply (default: the first mechanical fix the deployment target can use that changes the source)")
+	fs.BoolVar(&dryRun, "dry-run", false, "Print the unified diff without changing files (default)")
+	fs.BoolVar(&apply, "apply", false, "Write the patched files, keeping backups")
+	fs.StringVar(&backupSuffix, "backup-suffix", ".orig", "Suffix of the backup written next to each patched file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if input == "" || issueID == "" {
+		fmt.Fprintln(os.Stderr, "-in and -issue are required")
+		return 2
+	}
+	if dryRun && apply {
+		fmt.Fprintln(os.Stderr, "-dry-run and -apply are mutually exclusive")
+		return 2
+	}
+	if fixID != "" && !patch.Mechanical(fixID) {
+		fmt.Fprintf(os.Stderr, "fix %q has no source rewrite; see its steps in the report\n", fixID)
+		return 2
+	}
+	var saved *aioutput.Report
+	if strings.EqualFold(filepath.Ext(input), ".json") && !isSavedGraph(input) {
+		r, err := aioutput.ReadReport(input)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "load failed:", err)
+			return 1
+		}
+		saved = r
+		if sourceRoot == "" {
+			sourceRoot = r.Input.SourceRoot
+		}
+	}
+	if sourceRoot == "" {
+		fmt.Fprintln(os.Stderr, "-source is required when the report has no source root")
+		return 2
+	}
+
+	report, err := loadReport(input, sourceRoot)
+	if err != nil {
+		if errors.Is(err, analyze.ErrNoData) {
+			fmt.Fprintln(os.Stderr, "no parseable Cause & Effect data found; see trace/export limitations")
+			return 3
+		}
+		fmt.Fprintln(os.Stderr, "load failed:", err)
+		return 1
+	}
+	// A saved report is regenerated against the source, and one fitted to
+	// -max-tokens has a trimmed graph that can number its issues
+	// differently: the issue is named by the saved report and matched by
+	// type and affected nodes
+	var issue *aioutput.IssueWithFixes
+	if saved != nil {
+		want := findIssue(saved, issueID)
+		if want == nil {
+			fmt.Fprintf(os.Stderr, "issue %s not found (%d issues in the report)\n", issueID, len(saved.Issues))
+			return 1
+		}
+		key := compare.IssueKey(saved, want.Issue)
+		for i := range report.Issues {
+			if compare.IssueKey(report, report.Issues[i].Issue) == key {
+				issue = &report.Issues[i]
+				break
+			}
+		}
+		if issue == nil {
+			fmt.Fprintf(os.Stderr, "issue %s (%s) is not found again in the report's graph; analyze the trace without -max-tokens and fix from that report\n", issueID, want.Type)
+			return 1
+		}
+	} else if issue = findIssue(report, issueID); issue == nil {
+		fmt.Fprintf(os.Stderr, "issue %s not found (%d issues in the report)\n", issueID, len(report.Issues))
+		return 1
+	}
+
+	// Fixes the deployment target cannot use are never written, as the
+	// report's own patches skip them
+	fixIDs := []string{fixID}
+	if fixID == "" {
+		fixIDs = fixIDs[:0]
+	}
+	for _, f := range issue.SuggestedFixes {
+		usable := f.Availability == nil || f.Availability.Compatible
+		if fixID == "" && usable {
+			fixIDs = append(fixIDs, f.ID)
+		} else if f.ID == fixID && !usable {
+			fmt.Fprintf(os.Stderr, "fix %q is not available to this project: %s\n", fixID, f.Availability.Reason)
+			return 2
+		}
+	}
+	if len(fixIDs) == 0 {
+		fmt.Fprintf(os.Stderr, "%s: no fix the deployment target can use\n", issueID)
+		return 1
+	}
+	p, err := patch.Generate(sourceRoot, fixIDs, issueFiles(report, issue.Issue))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s (%s): %v\n", issueID, issue.Type, err)
+		return 1
+	}
+	for _, n := range p.Notes {
+		fmt.Fprintln(os.Stderr, "note:", n)
+	}
+	if len(p.Files) == 0 {
+		fmt.Fprintf(os.Stderr, "%s: %s changed nothing\n", issueID, p.FixID)
+		return 1
+	}
+
+	if !apply {
+		fmt.Print(p.Diff())
+		fmt.Fprintf(os.Stderr, "%s: %s changes %d file(s); rerun with -apply to write them\n", issueID, p.FixID, len(p.Files))
+		return 0
+	}
+	backups, err := p.Apply(sourceRoot, backupSuffix)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "apply failed:", err)
+		return 1
+	}
+	for _, f := range p.Files {
+		fmt.Println(filepath.Join(sourceRoot, f.Path))
+	}
+	fmt.Fprintf(os.Stderr, "%s: applied %s to %d file(s); originals saved with suffix %s (%d backups)\n",
+		issueID, p.FixID, len(p.Files), backupSuffix, len(backups))
+	return 0
+}
+
+// findIssue returns the report's issue with the given ID, or nil
+func findIssue(r *aioutput.Report, id string) *aioutput.IssueWithFixes {
+	for i := range r.Issues {
+		if r.Issues[i].ID == id {
+			return &r.Issues[i]
+		}
+	}
+	return nil
+}
+
+// issueFiles returns the source files the issue's affected nodes correlate
+// with, relative to the source root
+func issueFiles(report *aioutput.Report, issue issues.Issue) []string {
+	g := report.Graph.ToGraph()
+	ids := map[string]bool{}
+	for _, id := range issues.AffectedNodeIDs(g, []issues.Issue{issue}) {
+		ids[id] = true
+	}
+	var files []string
+	for _, n := range report.Graph.Nodes {
+		if ids[n.ID] && n.SourceFile != "" {
+			files = append(files, n.SourceFile)
+		}
+	}
+	return files
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/suggestions"
+)
+
+// userCatalog returns the fix catalog extended with the user's catalog
+// directories, loaded on first use. A broken user catalog is reported and
+// ignored so analysis still runs with the built-in fixes.
+var userCatalog = sync.OnceValue(func() *suggestions.Catalog {
+	dirs := suggestions.UserDirs()
+	if len(dirs) == 0 {
+		return suggestions.Builtin()
+	}
+	c, err := suggestions.LoadCatalog(dirs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v; using the built-in fixes (see 'swiftuice fixes lint')\n", err)
+		return suggestions.Builtin()
+	}
+	return c
+})
+
+func cmdFixes(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: swiftuice fixes list|show|lint [flags]")
+		return 2
+	}
+	switch args[0] {
+	case "list":
+		return cmdFixesList(args[1:])
+	case "show":
+		return cmdFixesShow(args[1:])
+	case "lint":
+		return cmdFixesLint(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown fixes command: %s (list, show or lint)\n", args[0])
+		return 2
+	}
+}
+
+func cmdFixesList(args []string) int {
+	fs := flag.NewFlagSet("fixes list", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	var issueType string
+	var asJSON bool
+	fs.StringVar(&issueType, "type", "", "Only fixes applicable to this issue type")
+	fs.BoolVar(&asJSON, "json", false, "Print the entries as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if issueType != "" && !contains(issueTypeNames(), issueType) {
+		fmt.Fprintf(os.Stderr, "unknown issue type %q (%s)\n", issueType, strings.Join(issueTypeNames(), ", "))
+		return 2
+	}
+
+	var entries []suggestions.Entry
+	for _, e := range userCatalog().Entries() {
+		if issueType == "" || contains(e.ApplicableTo, issueType) {
+			entries = append(entries, e)
+		}
+	}
+	if asJSON {
+		return printJSON(entries)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEFFORT\tIMPACT\tREQUIRES\tAPPLIES TO\tSOURCE")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.ID, e.Effort, e.Impact,
+			requirements(e.Fix), strings.Join(e.ApplicableTo, ","), e.Source)
+	}
+	w.Flush()
+	return 0
+}
+
+func cmdFixesShow(args []string) int {
+	fs := flag.NewFlagSet("fixes show", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	var asJSON bool
+	fs.BoolVar(&asJSON, "json", false, "Print the entry as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: swiftuice fixes show [-json] <fix-id>")
+		return 2
+	}
+	e, ok := userCatalog().Lookup(fs.Arg(0))
+	if !ok {
+		fmt.Fprintf(os.Stderr, "no fix %q in the catalog (see 'swiftuice fixes list')\n", fs.Arg(0))
+		return 1
+	}
+	if asJSON {
+		return printJSON(e)
+	}
+
+	fmt.Printf("%s — %s\n\n%s\n", e.ID, e.Approach, e.Description)
+	if e.Rationale != "" {
+		fmt.Printf("Why: %s\n", e.Rationale)
+	}
+	fmt.Printf("\nApplies to: %s\n", strings.Join(e.ApplicableTo, ", "))
+	if w := e.When; w != nil {
+		var conds []string
+		if w.MinSeverity != "" {
+			conds = append(conds, "severity ≥ "+string(w.MinSeverity))
+		}
+		if w.MinUpdateCount > 0 {
+			conds = append(conds, fmt.Sprintf("update count ≥ %d", w.MinUpdateCount))
+		}
+		if w.MinCascadeDepth > 0 {
+			conds = append(conds, fmt.Sprintf("cascade depth ≥ %d", w.MinCascadeDepth))
+		}
+		if len(w.Imports) > 0 {
+			conds = append(conds, "imports "+strings.Join(w.Imports, " or "))
+		}
+		if w.ModelType != "" {
+			conds = append(conds, "model type ~ "+w.ModelType)
+		}
+		fmt.Printf("When: %s\n", strings.Join(conds, ", "))
+	}
+	if req := requirements(e.Fix); req != "" {
+		fmt.Printf("Requires: %s\n", req)
+	}
+	fmt.Printf("Effort: %s, impact: %s\nSource: %s\n", e.Effort, e.Impact, e.Source)
+	fmt.Println("\nSteps:")
+	for i, s := range e.Steps {
+		fmt.Printf("  %d. %s\n", i+1, s)
+	}
+	if e.CodeBefore != "" {
+		fmt.Printf("\nBefore:\n%s\n", indent(e.CodeBefore))
+	}
+	if e.CodeAfter != "" {
+		fmt.Printf("\nAfter:\n%s\n", indent(e.CodeAfter))
+	}
+	for _, r := range e.References {
+		fmt.Printf("\nSee %s\n", r)
+	}
+	return 0
+}
+
+func cmdFixesLint(args []string) int {
+	fs := flag.NewFlagSet("fixes lint", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: swiftuice fixes lint [dir...]\n\nChecks the built-in catalog and the given directories (default: the user catalog directories).")
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	dirs := fs.Args()
+	if len(dirs) == 0 {
+		dirs = suggestions.UserDirs()
+	}
+
+	errs := 0
+	for _, p := range suggestions.Lint(dirs) {
+		fmt.Println(p)
+		if !p.Warning {
+			errs++
+		}
+	}
+	if errs > 0 {
+		fmt.Fprintf(os.Stderr, "%d error(s)\n", errs)
+		return 1
+	}
+	c, _ := suggestions.LoadCatalog(dirs)
+	fmt.Fprintf(os.Stderr, "catalog OK: %d fixes, %d user director(ies)\n", len(c.Entries()), len(dirs))
+	return 0
+}
+
+// requirements describes a fix's version requirements
+func requirements(f suggestions.Fix) string {
+	var req []string
+	if f.MinIOS != "" {
+		req = append(req, "iOS "+f.MinIOS)
+	}
+	if f.SwiftVersion != "" {
+		req = append(req, "Swift "+f.SwiftVersion)
+	}
+	return strings.Join(req, ", ")
+}
+
+func issueTypeNames() []string {
+	names := make([]string, len(issues.DetectorTypes))
+	for i, t := range issues.DetectorTypes {
+		names[i] = string(t)
+	}
+	return names
+}
+
+func indent(code string) string {
+	return "    " + strings.ReplaceAll(code, "\n", "\n    ")
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func printJSON(v any) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/analyze"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/project"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/xctrace"
+)
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// loadGraphInput builds a graph from any input the CLI accepts: an export
+// directory, a .trace, a saved graph (.json/.graphml) or an analysis report.
+func loadGraphInput(path string) (*graph.Graph, error) {
+	if graph.IsGraphMLPath(path) {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return graph.Load(path)
+		}
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if isSavedGraph(path) {
+			return graph.Load(path)
+		}
+		report, err := aioutput.ReadReport(path)
+		if err != nil {
+			return nil, err
+		}
+		return report.Graph.ToGraph(), nil
+	}
+	result, err := analyze.ParseTrace(analyze.Options{Input: path, XcTrace: xctrace.New()})
+	if err != nil {
+		return nil, err
+	}
+	return result.Graph, nil
+}
+
+// isSavedGraph reports whether a JSON file is in the canonical graph format
+func isSavedGraph(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var header struct {
+		Format string `json:"format"`
+	}
+	return json.Unmarshal(data, &header) == nil && header.Format == graph.FormatName
+}
+
+// loadReport returns an analysis report for any CLI input. A saved report is
+// used as is unless a source root is given, in which case it is regenerated
+// from its graph so source correlation is filled in; other inputs are
+// analyzed first. Fixes are ranked for the report's deployment target, or
+// the one detected under the source root, and drawn from the user's catalog.
+func loadReport(path, sourceRoot string) (*aioutput.Report, error) {
+	opts := aioutput.GenerateOptions{TracePath: path, SourceRoot: sourceRoot}
+	var g *graph.Graph
+	if strings.EqualFold(filepath.Ext(path), ".json") && !isSavedGraph(path) {
+		report, err := aioutput.ReadReport(path)
+		if err != nil {
+			return nil, err
+		}
+		if sourceRoot == "" {
+			return report, nil
+		}
+		g = report.Graph.ToGraph()
+		opts.TracePath = report.Input.TracePath
+		opts.ExportDir = report.Input.ExportDir
+		opts.FilesParsed = report.Input.FilesParsed
+		if t := report.Input.DeploymentTarget; t != nil {
+			opts.Target = *t
+		}
+		for _, s := range report.Input.Sources {
+			opts.Sources = append(opts.Sources, s.Name)
+		}
+	} else {
+		var err error
+		if g, err = loadGraphInput(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if sourceRoot != "" && !opts.Target.Known() {
+		t, err := deploymentTarget(sourceRoot, "", "")
+		if err != nil {
+			return nil, err
+		}
+		opts.Target = t
+	}
+	generator, err := aioutput.NewGenerator(sourceRoot)
+	if err != nil {
+		return nil, err
+	}
+	opts.Catalog = userCatalog()
+	return generator.Generate(g, opts), nil
+}
+
+var versionFlag = regexp.MustCompile(`^\d+(?:\.\d+)*$`)
+
+// deploymentTarget returns the target given by -ios and -swift, filling in
+// what they leave out from the project files under the source root
+func deploymentTarget(sourceRoot, ios, swift string) (project.Target, error) {
+	for _, v := range []string{ios, swift} {
+		if v != "" && !versionFlag.MatchString(v) {
+			return project.Target{}, fmt.Errorf("invalid version %q, want e.g. 15.0", v)
+		}
+	}
+	var t project.Target
+	if sourceRoot != "" && (ios == "" || swift == "") {
+		detected, err := project.Detect(sourceRoot)
+		if err != nil {
+			return project.Target{}, err
+		}
+		t = detected
+	}
+	if ios != "" || swift != "" {
+		t.Sources = append(t.Sources, "flag")
+	}
+	if ios != "" {
+		t.IOS = ios
+	}
+	if swift != "" {
+		t.Swift = swift
+	}
+	return t, nil
+}
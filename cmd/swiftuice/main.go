@@ -5,10 +5,16 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
 	"github.com/greenstevester/swiftui-cause-effect-cli/internal/analyze"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/cireport"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/correlation"
 	"github.com/greenstevester/swiftui-cause-effect-cli/internal/export"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/merge"
 	"github.com/greenstevester/swiftui-cause-effect-cli/internal/xctrace"
 )
 
@@ -34,6 +40,28 @@ func run() int {
 		return cmdSummarize(os.Args[2:])
 	case "analyze":
 		return cmdAnalyze(os.Args[2:])
+	case "query":
+		return cmdQuery(os.Args[2:])
+	case "report":
+		return cmdReport(os.Args[2:])
+	case "explore":
+		return cmdExplore(os.Args[2:])
+	case "mcp":
+		return cmdMCP(os.Args[2:])
+	case "fix":
+		return cmdFix(os.Args[2:])
+	case "fixes":
+		return cmdFixes(os.Args[2:])
+	case "plan":
+		return cmdPlan(os.Args[2:])
+	case "whatif":
+		return cmdWhatIf(os.Args[2:])
+	case "verify":
+		return cmdVerify(os.Args[2:])
+	case "schema":
+		return cmdSchema(os.Args[2:])
+	case "validate":
+		return cmdValidate(os.Args[2:])
 	case "version":
 		fmt.Printf("swiftuice v%s\n", version)
 		return 0
@@ -55,6 +83,17 @@ Usage:
   swiftuice export    [flags]   Export a .trace to parseable formats
   swiftuice summarize [flags]   Generate human-readable summary + Graphviz
   swiftuice analyze   [flags]   Generate AI-friendly JSON report (recommended for agents)
+  swiftuice query     [flags]   Trace causes, states and views through the graph
+  swiftuice report    [flags]   Write a self-contained interactive HTML report
+  swiftuice explore   [flags]   Browse issues, nodes and fixes in the terminal
+  swiftuice mcp       [flags]   Serve analysis tools to agents over MCP (stdio)
+  swiftuice fix       [flags]   Preview or apply the source patch of a mechanical fix
+  swiftuice fixes     <cmd>     List, show or lint the fix catalog
+  swiftuice plan      [flags]   Print the ordered fix plan for an agent
+  swiftuice whatif    [flags]   Simulate graph edits and compare issues and score
+  swiftuice verify    [flags]   Check fixes against a new recording (exit 4 if they fall short)
+  swiftuice schema    [flags]   Print the JSON Schema of the analysis report
+  swiftuice validate  <report>  Check reports against the schema, migrating older versions
 
 AI Integration:
   The 'analyze' command produces structured JSON output designed for AI agents.
@@ -130,21 +169,49 @@ func cmdSummarize(args []string) int {
 	fs := flag.NewFlagSet("summarize", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 	var input string
+	var graphIn string
+	var graphOut string
 	var out string
 	var dot string
+	var mermaid string
+	var mermaidTop int
+	var rawLabels bool
+	var sourceRoot string
+	var svg string
 	fs.StringVar(&input, "in", "", "Input directory (from export) OR a .trace path")
+	fs.StringVar(&graphIn, "graph-in", "", "Saved graph (.json or .graphml) to use instead of parsing a trace")
+	fs.StringVar(&graphOut, "graph-out", "", "Save the parsed graph (.json or .graphml) for later reuse")
 	fs.StringVar(&out, "out", "summary.md", "Summary markdown output")
 	fs.StringVar(&dot, "dot", "graph.dot", "Graphviz .dot output")
+	fs.StringVar(&mermaid, "mermaid", "", "Also write a Mermaid flowchart (.mmd, or .md for a fenced block)")
+	fs.IntVar(&mermaidTop, "mermaid-top", 12, "Heaviest paths kept in the Mermaid flowchart (0 = all)")
+	fs.BoolVar(&rawLabels, "raw-labels", false, "Keep raw trace labels (no address stripping, generic simplification or instance collapsing)")
+	fs.StringVar(&sourceRoot, "source", "", "Swift source root for code correlation: source locations in the summary and -dot-cluster (optional)")
+	fs.StringVar(&svg, "svg", "", "Also draw the graph as SVG (no Graphviz needed)")
+	dotOpts := addDOTFlags(fs)
 	if err := fs.Parse(args); err != nil {
 		return 2
 	}
-	if input == "" {
-		fmt.Fprintln(os.Stderr, "-in is required")
+	if input == "" && graphIn == "" {
+		fmt.Fprintln(os.Stderr, "-in or -graph-in is required")
+		return 2
+	}
+	if input != "" && graphIn != "" {
+		fmt.Fprintln(os.Stderr, "-in and -graph-in cannot be combined")
+		return 2
+	}
+	if err := dotOpts.validate(sourceRoot); err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		return 2
 	}
 
 	cli := xctrace.New()
-	res, err := analyze.Summarize(analyze.Options{Input: input, OutSummary: out, OutDOT: dot, XcTrace: cli})
+	res, err := analyze.Summarize(analyze.Options{
+		Input: input, GraphIn: graphIn, OutSummary: out, OutDOT: dot, OutGraph: graphOut,
+		OutMermaid: mermaid, MermaidTop: mermaidTop, OutSVG: svg, RawLabels: rawLabels, XcTrace: cli,
+		SourceRoot: sourceRoot, DOT: dotOpts.options(), DOTCluster: dotOpts.cluster, DOTIssues: dotOpts.issues,
+		Catalog: userCatalog(),
+	})
 	if err != nil {
 		if errors.Is(err, analyze.ErrNoData) {
 			fmt.Fprintln(os.Stderr, "no parseable Cause & Effect data found; see trace/export limitations")
@@ -154,34 +221,181 @@ func cmdSummarize(args []string) int {
 		return 1
 	}
 	fmt.Printf("%s\n%s\n", res.SummaryPath, res.DotPath)
+	if res.MermaidPath != "" {
+		fmt.Println(res.MermaidPath)
+	}
+	if res.SVGPath != "" {
+		fmt.Println(res.SVGPath)
+	}
 	return 0
 }
 
 func cmdAnalyze(args []string) int {
 	fs := flag.NewFlagSet("analyze", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
-	var input string
+	var inputs stringList
+	var inputDir string
+	var aggregate string
+	var graphIn string
+	var graphOut string
 	var sourceRoot string
 	var out string
 	var compact bool
 	var stdout bool
-	fs.StringVar(&input, "in", "", "Input directory (from export) OR a .trace path")
+	var ndjson bool
+	var rawLabels bool
+	var mermaid string
+	var mermaidTop int
+	var dot string
+	var svg string
+	var junit string
+	var github bool
+	var failOn string
+	var minScore int
+	var latest string
+	var xcode bool
+	var targetDirs stringList
+	var targetFiles stringList
+	var maxTokens int
+	var iosTarget string
+	var swiftVersion string
+	var dropIncompatible bool
+	fs.Var(&inputs, "in", "Input directory (from export) OR a .trace path; repeat to merge several recordings")
+	fs.StringVar(&inputDir, "in-dir", "", "Directory of recordings to merge (each .trace and subdirectory except exported/ is one input)")
+	fs.StringVar(&latest, "latest", "", "Analyze the most recent recording in this directory")
+	fs.StringVar(&aggregate, "aggregate", "sum", "Merged node count used for detection: sum|median|max")
+	fs.StringVar(&graphIn, "graph-in", "", "Saved graph (.json or .graphml) to use instead of parsing a trace")
+	fs.StringVar(&graphOut, "graph-out", "", "Save the parsed graph (.json or .graphml) for later reuse")
 	fs.StringVar(&sourceRoot, "source", "", "Swift source root for code correlation (optional)")
+	fs.StringVar(&iosTarget, "ios", "", "Minimum iOS version the project supports (default: detected from Package.swift or the Xcode project under -source)")
+	fs.StringVar(&swiftVersion, "swift", "", "Swift version the project builds with (default: the Package.swift tools version under -source)")
+	fs.BoolVar(&dropIncompatible, "drop-incompatible", false, "Leave out fixes the deployment target cannot use instead of ranking them last")
 	fs.StringVar(&out, "out", "analysis.json", "Output JSON file path")
 	fs.BoolVar(&compact, "compact", false, "Output compact JSON (for piping)")
 	fs.BoolVar(&stdout, "stdout", false, "Output to stdout instead of file")
+	fs.BoolVar(&ndjson, "ndjson", false, "Stream progress, nodes, edges, issues, correlations and the summary to stdout as NDJSON while analyzing (the report file is only written with an explicit -out)")
+	fs.IntVar(&maxTokens, "max-tokens", 0, "Fit the JSON report into this many (estimated) LLM tokens, recording what was left out (0 = no limit)")
+	fs.StringVar(&mermaid, "mermaid", "", "Also write a Mermaid flowchart with issue paths highlighted (.mmd, or .md for a fenced block)")
+	fs.IntVar(&mermaidTop, "mermaid-top", 12, "Heaviest paths kept in the Mermaid flowchart (0 = all)")
+	fs.BoolVar(&rawLabels, "raw-labels", false, "Keep raw trace labels (no address stripping, generic simplification or instance collapsing)")
+	fs.StringVar(&dot, "dot", "", "Also write a Graphviz .dot graph (styled with the -dot-* flags)")
+	fs.StringVar(&svg, "svg", "", "Also draw the graph as SVG (no Graphviz needed; styled with the -dot-* flags)")
+	fs.StringVar(&junit, "junit", "", "Also write JUnit XML (one test case per detector plus the score budget)")
+	fs.BoolVar(&github, "github", false, "Print GitHub Actions annotations (::error/::warning) for each issue")
+	fs.BoolVar(&xcode, "xcode", false, "Print Xcode diagnostics (path:line:col: warning: ...) for a Run Script build phase")
+	fs.Var(&targetDirs, "target-dir", "Only report -xcode findings in files under this directory (repeatable; $(SRCROOT) is expanded)")
+	fs.Var(&targetFiles, "target-files", "Only report -xcode findings in files listed in this .xcfilelist (repeatable)")
+	fs.StringVar(&failOn, "fail-on", "high", "Lowest severity reported as a failure in -junit, -github and -xcode output")
+	fs.IntVar(&minScore, "min-score", 0, "Performance score budget for -junit (0 = none)")
+	dotOpts := addDOTFlags(fs)
 	if err := fs.Parse(args); err != nil {
 		return 2
 	}
-	if input == "" {
-		fmt.Fprintln(os.Stderr, "-in is required")
+	failSeverity, err := issues.ParseSeverity(failOn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if maxTokens < 0 {
+		fmt.Fprintln(os.Stderr, "-max-tokens must not be negative")
+		return 2
+	}
+	if stdout && (github || xcode) {
+		fmt.Fprintln(os.Stderr, "-github and -xcode print to stdout; they cannot be combined with -stdout")
+		return 2
+	}
+	if ndjson && (stdout || github || xcode) {
+		fmt.Fprintln(os.Stderr, "-ndjson owns stdout; it cannot be combined with -stdout, -github or -xcode")
+		return 2
+	}
+	if ndjson && maxTokens > 0 {
+		fmt.Fprintln(os.Stderr, "-max-tokens fits a single report; it cannot be combined with -ndjson")
+		return 2
+	}
+	writeReport := !ndjson
+	fs.Visit(func(f *flag.Flag) {
+		writeReport = writeReport || f.Name == "out"
+	})
+	printPaths := !stdout && !ndjson
+	if err := dotOpts.validate(sourceRoot); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	var target cireport.Target
+	for _, dir := range targetDirs {
+		if err := target.AddDir(dir); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	for _, list := range targetFiles {
+		if err := target.ReadFileList(list); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to read target file list:", err)
+			return 2
+		}
+	}
+	if !target.Empty() && !xcode {
+		fmt.Fprintln(os.Stderr, "-target-dir and -target-files need -xcode")
+		return 2
+	}
+	if latest != "" {
+		in, err := analyze.LatestInput(latest)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		inputs = append(inputs, in)
+	}
+	if inputDir != "" {
+		expanded, err := analyze.ExpandInputs(inputDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		inputs = append(inputs, expanded...)
+	}
+	if len(inputs) == 0 && graphIn == "" {
+		fmt.Fprintln(os.Stderr, "-in, -in-dir, -latest or -graph-in is required")
 		fs.Usage()
 		return 2
 	}
+	if len(inputs) > 0 && graphIn != "" {
+		fmt.Fprintln(os.Stderr, "-graph-in cannot be combined with -in, -in-dir or -latest")
+		return 2
+	}
+	agg, err := merge.ParseAggregate(aggregate)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	deployTarget, err := deploymentTarget(sourceRoot, iosTarget, swiftVersion)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	input := strings.Join(inputs, ",")
+	if input == "" {
+		input = graphIn
+	}
+	var stream *aioutput.Stream
+	if ndjson {
+		stream = aioutput.NewStream(os.Stdout)
+	}
 
-	// Parse the trace/export
+	// Parse the trace/export(s) (or load a saved graph)
+	stream.Progress("parse", "reading "+input, 0, 0)
 	cli := xctrace.New()
-	result, err := analyze.ParseTrace(analyze.Options{Input: input, XcTrace: cli})
+	var result *analyze.AnalysisResult
+	if len(inputs) > 1 {
+		result, err = analyze.ParseMulti(inputs, agg, analyze.Options{RawLabels: rawLabels, XcTrace: cli, Stream: stream})
+	} else {
+		input := ""
+		if len(inputs) == 1 {
+			input = inputs[0]
+		}
+		result, err = analyze.ParseTrace(analyze.Options{Input: input, GraphIn: graphIn, RawLabels: rawLabels, XcTrace: cli, Stream: stream})
+	}
 	if err != nil {
 		if errors.Is(err, analyze.ErrNoData) {
 			fmt.Fprintln(os.Stderr, "no parseable Cause & Effect data found; see trace/export limitations")
@@ -190,7 +404,19 @@ func cmdAnalyze(args []string) int {
 		fmt.Fprintln(os.Stderr, "analyze failed:", err)
 		return 1
 	}
-
+	if stream != nil {
+		parsed := fmt.Sprintf("%d nodes, %d edges", len(result.Graph.Nodes), len(result.Graph.Edges))
+		if result.FilesParsed > 0 {
+			parsed += fmt.Sprintf(" from %d files", result.FilesParsed)
+		}
+		stream.Progress("parse", parsed, 0, 0)
+	}
+	if graphOut != "" {
+		if err := graph.Save(result.Graph, graphOut); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to save graph:", err)
+			return 1
+		}
+	}
 	// Generate AI report
 	generator, err := aioutput.NewGenerator(sourceRoot)
 	if err != nil {
@@ -203,10 +429,99 @@ func cmdAnalyze(args []string) int {
 		ExportDir:   result.InputDir,
 		SourceRoot:  sourceRoot,
 		FilesParsed: result.FilesParsed,
+		Hints:       result.Hints,
+		Sources:     result.Sources,
+
+		Target:           deployTarget,
+		DropIncompatible: dropIncompatible,
+		Catalog:          userCatalog(),
+		Stream:           stream,
 	})
+	if err := stream.Err(); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to stream records:", err)
+		return 1
+	}
 
-	// Output the report
-	if stdout {
+	detected := make([]issues.Issue, len(report.Issues))
+	for i, issue := range report.Issues {
+		detected[i] = issue.Issue
+	}
+	highlight := issues.AffectedNodeIDs(result.Graph, detected)
+	opts := dotOpts.options()
+	if dotOpts.issues {
+		opts.Highlight = highlight
+	}
+	if dotOpts.cluster != "" {
+		opts.Groups, _ = correlation.GroupBy(report.SourceCorrelations, dotOpts.cluster)
+	}
+	if dot != "" {
+		if err := os.WriteFile(dot, []byte(analyze.RenderDOTWithOptions(result.Graph, opts)), 0o644); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to write DOT graph:", err)
+			return 1
+		}
+		if printPaths {
+			fmt.Println(dot)
+		}
+	}
+	if svg != "" {
+		if err := analyze.WriteSVG(svg, result.Graph, opts); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to write SVG:", err)
+			return 1
+		}
+		if printPaths {
+			fmt.Println(svg)
+		}
+	}
+	ciOpts := cireport.Options{FailOn: failSeverity, MinScore: minScore}
+	if junit != "" {
+		if err := cireport.WriteJUnitFile(junit, report, ciOpts); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to write JUnit XML:", err)
+			return 1
+		}
+		if printPaths {
+			fmt.Println(junit)
+		}
+	}
+	if github {
+		if err := cireport.WriteAnnotations(os.Stdout, report, ciOpts); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to write annotations:", err)
+			return 1
+		}
+	}
+	xcodeErrors := 0
+	if xcode {
+		if xcodeErrors, err = cireport.WriteXcode(os.Stdout, report, ciOpts, target); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to write Xcode diagnostics:", err)
+			return 1
+		}
+	}
+	if mermaid != "" {
+		if err := analyze.WriteMermaid(mermaid, result.Graph, analyze.MermaidOptions{TopN: mermaidTop, Highlight: highlight}); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to write Mermaid flowchart:", err)
+			return 1
+		}
+		if printPaths {
+			fmt.Println(mermaid)
+		}
+	}
+
+	// Output the report, fitted to the token budget if there is one
+	if maxTokens > 0 {
+		report, err = report.Fit(maxTokens, stdout && compact)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to fit the report:", err)
+			return 1
+		}
+	}
+	if ndjson {
+		if writeReport {
+			if err := report.WriteJSON(out); err != nil {
+				fmt.Fprintln(os.Stderr, "failed to write report:", err)
+				return 1
+			}
+			fmt.Fprintln(os.Stderr, "Report:", out)
+		}
+	} else if stdout {
 		var jsonStr string
 		if compact {
 			jsonStr, err = report.ToCompactJSON()
@@ -230,10 +545,22 @@ func cmdAnalyze(args []string) int {
 		fmt.Fprintf(os.Stderr, "  Performance Score: %d/100 (%s)\n", report.Summary.PerformanceScore, report.Summary.HealthStatus)
 		fmt.Fprintf(os.Stderr, "  Issues Found: %d (%d critical, %d high)\n", report.Summary.IssuesFound, report.Summary.CriticalIssues, report.Summary.HighIssues)
 		fmt.Fprintf(os.Stderr, "  Graph: %d causes → %d states → %d views\n", report.Summary.TotalCauses, report.Summary.TotalStateChanges, report.Summary.TotalViewUpdates)
+		if len(result.Sources) > 0 {
+			fmt.Fprintf(os.Stderr, "  Merged inputs: %d (counts aggregated by %s)\n", len(result.Sources), agg)
+		}
 		if sourceRoot != "" {
 			fmt.Fprintf(os.Stderr, "  Source correlations: %d matches\n", len(report.SourceCorrelations))
 		}
+		if deployTarget.Known() {
+			fmt.Fprintf(os.Stderr, "  Deployment target: %s (from %s)\n", deployTarget, strings.Join(deployTarget.Sources, ", "))
+		}
+		if report.Budget != nil {
+			fmt.Fprintf(os.Stderr, "  Token budget: ~%d of %d tokens, %d section(s) elided\n", report.Budget.EstimatedTokens, report.Budget.MaxTokens, len(report.Elisions))
+		}
 	}
 
+	if xcodeErrors > 0 {
+		return 1 // fail the build phase, as a compiler error would
+	}
 	return 0
 }
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/mcp"
+)
+
+func cmdMCP(args []string) int {
+	fs := flag.NewFlagSet("mcp", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	var sourceRoot string
+	fs.StringVar(&sourceRoot, "source", "", "Default Swift source root for code correlation when a tool call gives none")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	session := mcp.NewSession(func(input, source string) (*aioutput.Report, error) {
+		if source == "" {
+			source = sourceRoot
+		}
+		return loadReport(input, source)
+	})
+	server := mcp.NewServer("swiftuice", version, session.Tools())
+	if err := server.Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "mcp server failed:", err)
+		return 1
+	}
+	return 0
+}
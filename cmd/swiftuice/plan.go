@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/analyze"
+)
+
+func cmdPlan(args []string) int {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	var input string
+	var sourceRoot string
+	var jsonOut bool
+	var out string
+	fs.StringVar(&input, "in", "", "Export directory, .trace, saved graph or analysis report")
+	fs.StringVar(&sourceRoot, "source", "", "Swift source root, for target files and patches (optional)")
+	fs.BoolVar(&jsonOut, "json", false, "Print the plan as JSON")
+	fs.StringVar(&out, "out", "", "Write the plan to a file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if input == "" {
+		fmt.Fprintln(os.Stderr, "-in is required")
+		return 2
+	}
+
+	report, err := loadReport(input, sourceRoot)
+	if err != nil {
+		if errors.Is(err, analyze.ErrNoData) {
+			fmt.Fprintln(os.Stderr, "no parseable Cause & Effect data found; see trace/export limitations")
+			return 3
+		}
+		fmt.Fprintln(os.Stderr, "load failed:", err)
+		return 1
+	}
+	// A report fitted to a token budget may have had its plan trimmed
+	plan := report.Plan
+	if plan == nil || report.Budget != nil {
+		plan = aioutput.BuildPlan(report)
+	}
+
+	text := plan.Text()
+	if jsonOut {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to generate JSON:", err)
+			return 1
+		}
+		text = string(data) + "\n"
+	}
+	if out == "" {
+		fmt.Print(text)
+		return 0
+	}
+	if err := os.WriteFile(out, []byte(text), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to write output:", err)
+		return 1
+	}
+	fmt.Println(out)
+	return 0
+}
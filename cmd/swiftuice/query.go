@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/analyze"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/query"
+)
+
+func cmdQuery(args []string) int {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	var input string
+	var node string
+	var direction string
+	var from string
+	var to string
+	var all bool
+	var types string
+	var minCount int
+	var depth int
+	var format string
+	var out string
+	fs.StringVar(&input, "in", "", "Export directory, .trace, saved graph (.json/.graphml) or analysis report")
+	fs.StringVar(&node, "node", "", "Start node: exact ID or case-insensitive label regex")
+	fs.StringVar(&direction, "dir", "down", "Traversal direction from -node: up (towards causes) or down (towards views)")
+	fs.StringVar(&from, "from", "", "Path search start node (ID or label regex)")
+	fs.StringVar(&to, "to", "", "Path search end node (ID or label regex)")
+	fs.BoolVar(&all, "all", false, "Return all simple paths instead of only the shortest")
+	fs.StringVar(&types, "type", "", "Only report these node types, comma-separated (cause,state,view,other)")
+	fs.IntVar(&minCount, "min-count", 0, "Only report nodes with at least this update count")
+	fs.IntVar(&depth, "depth", 0, "Maximum traversal or path length in edges (0 = unlimited)")
+	fs.StringVar(&format, "format", "text", "Output format: text|json|dot|mermaid")
+	fs.StringVar(&out, "out", "", "Write output to a file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if input == "" {
+		fmt.Fprintln(os.Stderr, "-in is required")
+		return 2
+	}
+	if node == "" && (from == "" || to == "") {
+		fmt.Fprintln(os.Stderr, "-node, or both -from and -to, are required")
+		return 2
+	}
+	nodeTypes, err := query.ParseTypes(types)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	g, err := loadGraphInput(input)
+	if err != nil {
+		if errors.Is(err, analyze.ErrNoData) {
+			fmt.Fprintln(os.Stderr, "no parseable Cause & Effect data found; see trace/export limitations")
+			return 3
+		}
+		fmt.Fprintln(os.Stderr, "load failed:", err)
+		return 1
+	}
+
+	res, err := query.Run(g, query.Query{
+		Node:      node,
+		Direction: query.Direction(direction),
+		From:      from,
+		To:        to,
+		AllPaths:  all,
+		Types:     nodeTypes,
+		MinCount:  minCount,
+		MaxDepth:  depth,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "query failed:", err)
+		return 1
+	}
+
+	var text string
+	switch format {
+	case "text":
+		text = res.Text()
+	case "json":
+		text, err = res.JSON()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to generate JSON:", err)
+			return 1
+		}
+		text += "\n"
+	case "dot":
+		text = res.DOT()
+	case "mermaid":
+		text = res.Mermaid()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format: %s\n", format)
+		return 2
+	}
+
+	if out == "" {
+		fmt.Print(text)
+		return 0
+	}
+	if err := os.WriteFile(out, []byte(text), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to write output:", err)
+		return 1
+	}
+	fmt.Println(out)
+	return 0
+}
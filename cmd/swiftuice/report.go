@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/analyze"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/htmlreport"
+)
+
+func cmdReport(args []string) int {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	var input string
+	var sourceRoot string
+	var format string
+	var out string
+	fs.StringVar(&input, "in", "", "Analysis report (analysis.json), export directory, .trace or saved graph")
+	fs.StringVar(&sourceRoot, "source", "", "Swift source root for code correlation and source links (optional)")
+	fs.StringVar(&format, "format", "html", "Output format: html")
+	fs.StringVar(&out, "out", "report.html", "Output file path")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if input == "" {
+		fmt.Fprintln(os.Stderr, "-in is required")
+		fs.Usage()
+		return 2
+	}
+	if format != "html" {
+		fmt.Fprintf(os.Stderr, "unknown format: %s\n", format)
+		return 2
+	}
+
+	report, err := loadReport(input, sourceRoot)
+	if err != nil {
+		if errors.Is(err, analyze.ErrNoData) {
+			fmt.Fprintln(os.Stderr, "no parseable Cause & Effect data found; see trace/export limitations")
+			return 3
+		}
+		fmt.Fprintln(os.Stderr, "load failed:", err)
+		return 1
+	}
+
+	if err := htmlreport.Write(report, out); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to write report:", err)
+		return 1
+	}
+	fmt.Println(out)
+	return 0
+}
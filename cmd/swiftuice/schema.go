@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+)
+
+func cmdSchema(args []string) int {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	var out string
+	fs.StringVar(&out, "out", "", "Write the schema to a file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if out == "" {
+		os.Stdout.Write(aioutput.Schema())
+		return 0
+	}
+	if err := os.WriteFile(out, aioutput.Schema(), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to write schema:", err)
+		return 1
+	}
+	fmt.Println(out)
+	return 0
+}
+
+func cmdValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	var jsonOut bool
+	var out string
+	fs.BoolVar(&jsonOut, "json", false, "Print the results as JSON")
+	fs.StringVar(&out, "out", "", "Write the report, migrated to the current version, to this file (one report only)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: swiftuice validate [flags] <report.json>...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		fs.Usage()
+		return 2
+	}
+	if out != "" && len(paths) > 1 {
+		fmt.Fprintln(os.Stderr, "-out takes a single report")
+		return 2
+	}
+
+	type result struct {
+		Path string `json:"path"`
+		*aioutput.Validation
+		Error string `json:"error,omitempty"`
+	}
+	var results []result
+	code := 0
+	for _, path := range paths {
+		res := result{Path: path, Validation: &aioutput.Validation{}}
+		data, err := os.ReadFile(path)
+		var report *aioutput.Report
+		if err == nil {
+			var v *aioutput.Validation
+			if v, report, err = aioutput.ValidateReport(data); v != nil {
+				res.Validation = v
+			}
+		}
+		if err != nil {
+			res.Error = err.Error()
+		}
+		if err != nil || !res.Valid() {
+			code = 1
+		} else if out != "" {
+			if err := report.WriteJSON(out); err != nil {
+				fmt.Fprintln(os.Stderr, "failed to write report:", err)
+				return 1
+			}
+			fmt.Fprintln(os.Stderr, "Migrated report:", out)
+		}
+		results = append(results, res)
+	}
+
+	if jsonOut {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to generate JSON:", err)
+			return 1
+		}
+		fmt.Println(string(data))
+		return code
+	}
+	for _, res := range results {
+		switch {
+		case res.Error != "":
+			fmt.Printf("%s: %s\n", res.Path, res.Error)
+		case res.Valid():
+			note := ""
+			if res.MigratedFrom != "" {
+				note = fmt.Sprintf(" (migrated from %s)", res.MigratedFrom)
+			}
+			fmt.Printf("%s: valid report version %s%s\n", res.Path, res.Version, note)
+		default:
+			fmt.Printf("%s: %d schema error(s)\n", res.Path, len(res.Errors))
+			for _, e := range res.Errors {
+				fmt.Printf("  %s\n", e)
+			}
+		}
+	}
+	return code
+}
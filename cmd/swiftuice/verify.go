@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/analyze"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/verify"
+)
+
+// exitVerifyFailed is returned when the fixes did not hold up, so a fix
+// loop can tell a failed verification from a failed run
+const exitVerifyFailed = 4
+
+func cmdVerify(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	var reportPath string
+	var input string
+	var sourceRoot string
+	var issueIDs stringList
+	var taskIDs stringList
+	var tolerance float64
+	var failOn string
+	var jsonOut bool
+	var out string
+	var save string
+	fs.StringVar(&reportPath, "report", "", "Analysis report the fixes were planned from")
+	fs.StringVar(&input, "in", "", "Export directory, .trace, saved graph or report recorded after the fixes")
+	fs.StringVar(&sourceRoot, "source", "", "Swift source root for the new analysis (optional)")
+	fs.Var(&issueIDs, "issue", "Issue ID from the report to check (repeatable; default: every planned issue)")
+	fs.Var(&taskIDs, "task", "Plan task whose issues to check (repeatable)")
+	fs.Float64Var(&tolerance, "tolerance", verify.DefaultTolerance, "Share of the expected savings that may be missed, and growth allowed in other views")
+	fs.StringVar(&failOn, "fail-on", string(verify.DefaultFailOn), "Lowest severity of a new or worsened issue that fails verification")
+	fs.BoolVar(&jsonOut, "json", false, "Print the result as JSON")
+	fs.StringVar(&out, "out", "", "Write the result to a file instead of stdout")
+	fs.StringVar(&save, "save", "", "Also write the new analysis report (JSON), e.g. as the next baseline")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if reportPath == "" || input == "" {
+		fmt.Fprintln(os.Stderr, "-report and -in are required")
+		return 2
+	}
+	severity, err := issues.ParseSeverity(failOn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	base, err := aioutput.ReadReport(reportPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "load failed:", err)
+		return 1
+	}
+	head, err := loadReport(input, sourceRoot)
+	if err != nil {
+		if errors.Is(err, analyze.ErrNoData) {
+			fmt.Fprintln(os.Stderr, "no parseable Cause & Effect data found; see trace/export limitations")
+			return 3
+		}
+		fmt.Fprintln(os.Stderr, "load failed:", err)
+		return 1
+	}
+	if save != "" {
+		if err := head.WriteJSON(save); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to write report:", err)
+			return 1
+		}
+		fmt.Fprintln(os.Stderr, "New report:", save)
+	}
+
+	res, err := verify.Check(base, head, verify.Options{Issues: issueIDs, Tasks: taskIDs, Tolerance: &tolerance, FailOn: severity})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	text := res.Text()
+	if jsonOut {
+		data, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to generate JSON:", err)
+			return 1
+		}
+		text = string(data) + "\n"
+	}
+	if out == "" {
+		fmt.Print(text)
+	} else if err := os.WriteFile(out, []byte(text), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to write output:", err)
+		return 1
+	} else {
+		fmt.Println(out)
+	}
+	if !res.Pass {
+		return exitVerifyFailed
+	}
+	return 0
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/analyze"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/query"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/simulate"
+)
+
+// editSpec is an edit as given on the command line; nodes are resolved once
+// the graph is loaded
+type editSpec struct {
+	kind string
+	arg  string
+}
+
+// editFlag collects one kind of edit into a list shared by all kinds, so
+// edits apply in command-line order
+type editFlag struct {
+	kind  string
+	specs *[]editSpec
+}
+
+func (f editFlag) String() string { return "" }
+
+func (f editFlag) Set(v string) error {
+	*f.specs = append(*f.specs, editSpec{kind: f.kind, arg: v})
+	return nil
+}
+
+func cmdWhatIf(args []string) int {
+	fs := flag.NewFlagSet("whatif", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	var input string
+	var duration float64
+	var jsonOut bool
+	var out string
+	var graphOut string
+	var specs []editSpec
+	fs.StringVar(&input, "in", "", "Export directory, .trace, saved graph (.json/.graphml) or analysis report")
+	fs.Var(editFlag{"drop-edge", &specs}, "drop-edge", "Remove the edges `FROM->TO` (repeatable)")
+	fs.Var(editFlag{"split", &specs}, "split", "Split a state, one part per observing view, or into named parts: `STATE[=part:View,View;part:View]` (repeatable)")
+	fs.Var(editFlag{"debounce", &specs}, "debounce", "Limit a cause to N events per second: `CAUSE=N` (repeatable, needs -duration)")
+	fs.Var(editFlag{"equatable", &specs}, "equatable", "Make a view equatable, keeping a share of its updates: `VIEW[=share]` (repeatable)")
+	fs.Float64Var(&duration, "duration", 0, "Length of the traced session in seconds, for -debounce")
+	fs.BoolVar(&jsonOut, "json", false, "Print the comparison as JSON")
+	fs.StringVar(&out, "out", "", "Write the comparison to a file instead of stdout")
+	fs.StringVar(&graphOut, "graph-out", "", "Save the edited graph (.json or .graphml)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if input == "" {
+		fmt.Fprintln(os.Stderr, "-in is required")
+		return 2
+	}
+	if len(specs) == 0 {
+		fmt.Fprintln(os.Stderr, "at least one edit is required (-drop-edge, -split, -debounce or -equatable)")
+		return 2
+	}
+
+	g, err := loadGraphInput(input)
+	if err != nil {
+		if errors.Is(err, analyze.ErrNoData) {
+			fmt.Fprintln(os.Stderr, "no parseable Cause & Effect data found; see trace/export limitations")
+			return 3
+		}
+		fmt.Fprintln(os.Stderr, "load failed:", err)
+		return 1
+	}
+	edits := make([]simulate.Edit, 0, len(specs))
+	for _, s := range specs {
+		e, err := resolveEdit(g, s, duration)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "-%s %s: %v\n", s.kind, s.arg, err)
+			return 2
+		}
+		edits = append(edits, e)
+	}
+
+	d := issues.NewDetector()
+	outcome, err := simulate.Compare(g, d, d.Detect(g), edits)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "simulation failed:", err)
+		return 1
+	}
+	if graphOut != "" {
+		if err := graph.Save(outcome.Graph, graphOut); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to save graph:", err)
+			return 1
+		}
+		fmt.Fprintln(os.Stderr, "Edited graph:", graphOut)
+	}
+
+	text := outcome.Text()
+	if jsonOut {
+		data, err := json.MarshalIndent(outcome, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to generate JSON:", err)
+			return 1
+		}
+		text = string(data) + "\n"
+	}
+	if out == "" {
+		fmt.Print(text)
+		return 0
+	}
+	if err := os.WriteFile(out, []byte(text), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to write output:", err)
+		return 1
+	}
+	fmt.Println(out)
+	return 0
+}
+
+// resolveEdit turns a command-line edit into a graph edit on node IDs
+func resolveEdit(g *graph.Graph, s editSpec, duration float64) (simulate.Edit, error) {
+	switch s.kind {
+	case "drop-edge":
+		from, to, ok := strings.Cut(s.arg, "->")
+		if !ok {
+			return simulate.Edit{}, fmt.Errorf("want FROM->TO")
+		}
+		fromID, err := resolveNode(g, strings.TrimSpace(from), "")
+		if err != nil {
+			return simulate.Edit{}, err
+		}
+		toID, err := resolveNode(g, strings.TrimSpace(to), "")
+		if err != nil {
+			return simulate.Edit{}, err
+		}
+		return simulate.Edit{Op: simulate.OpDropEdge, From: fromID, To: toID}, nil
+
+	case "split":
+		ref, partSpec, _ := strings.Cut(s.arg, "=")
+		id, err := resolveNode(g, strings.TrimSpace(ref), graph.NodeState)
+		if err != nil {
+			return simulate.Edit{}, err
+		}
+		e := simulate.Edit{Op: simulate.OpSplitState, Node: id}
+		if strings.TrimSpace(partSpec) == "" {
+			return e, nil
+		}
+		for _, p := range strings.Split(partSpec, ";") {
+			name, views, ok := strings.Cut(p, ":")
+			if !ok || strings.TrimSpace(name) == "" {
+				return simulate.Edit{}, fmt.Errorf("want part:View,View, got %q", p)
+			}
+			part := simulate.Part{Name: strings.TrimSpace(name)}
+			for _, v := range strings.Split(views, ",") {
+				if strings.TrimSpace(v) == "" {
+					continue
+				}
+				vid, err := resolveNode(g, strings.TrimSpace(v), graph.NodeView)
+				if err != nil {
+					return simulate.Edit{}, err
+				}
+				part.Views = append(part.Views, vid)
+			}
+			e.Parts = append(e.Parts, part)
+		}
+		return e, nil
+
+	case "debounce":
+		i := strings.LastIndex(s.arg, "=")
+		if i < 0 {
+			return simulate.Edit{}, fmt.Errorf("want CAUSE=N (events per second)")
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(s.arg[i+1:]), 64)
+		if err != nil || rate <= 0 {
+			return simulate.Edit{}, fmt.Errorf("events per second must be a positive number, got %q", s.arg[i+1:])
+		}
+		if duration <= 0 {
+			return simulate.Edit{}, fmt.Errorf("-duration (the trace length in seconds) is needed to turn a rate into a count")
+		}
+		id, err := resolveNode(g, strings.TrimSpace(s.arg[:i]), graph.NodeCause)
+		if err != nil {
+			return simulate.Edit{}, err
+		}
+		count := g.Nodes[id].Count
+		if count == 0 {
+			return simulate.Edit{}, fmt.Errorf("%s has no count to debounce", id)
+		}
+		factor := math.Min(1, rate*duration/float64(count))
+		return simulate.Edit{Op: simulate.OpScale, Node: id, Factor: math.Round(factor*1000) / 1000}, nil
+
+	case "equatable":
+		ref, share, hasShare := strings.Cut(s.arg, "=")
+		factor := equatableShare()
+		if hasShare {
+			f, err := strconv.ParseFloat(strings.TrimSpace(share), 64)
+			if err != nil || f < 0 || f > 1 {
+				return simulate.Edit{}, fmt.Errorf("share of updates kept must be between 0 and 1, got %q", share)
+			}
+			factor = f
+		}
+		id, err := resolveNode(g, strings.TrimSpace(ref), graph.NodeView)
+		if err != nil {
+			return simulate.Edit{}, err
+		}
+		return simulate.Edit{Op: simulate.OpScale, Node: id, Factor: factor}, nil
+	}
+	return simulate.Edit{}, fmt.Errorf("unknown edit")
+}
+
+// equatableShare is the share of a view's updates the equatable-view fix
+// assumes are kept
+func equatableShare() float64 {
+	if e, ok := userCatalog().Lookup("equatable-view"); ok && e.Effect != nil && e.Effect.Kind == simulate.KindScaleViews {
+		return e.Effect.Factor
+	}
+	return 0.5
+}
+
+// resolveNode resolves an ID or label pattern to a single node, of type t
+// when given. A label matching exactly wins over partial matches.
+func resolveNode(g *graph.Graph, ref string, t graph.NodeType) (string, error) {
+	matches, err := query.Match(g, ref)
+	if err != nil {
+		return "", err
+	}
+	if t != "" {
+		var typed []*graph.Node
+		for _, n := range matches {
+			if n.Type == t {
+				typed = append(typed, n)
+			}
+		}
+		if len(typed) == 0 {
+			return "", fmt.Errorf("%q matches no %s node", ref, t)
+		}
+		matches = typed
+	}
+	if len(matches) == 1 {
+		return matches[0].ID, nil
+	}
+	var exact []*graph.Node
+	for _, n := range matches {
+		if strings.EqualFold(n.Label, ref) {
+			exact = append(exact, n)
+		}
+	}
+	if len(exact) == 1 {
+		return exact[0].ID, nil
+	}
+	ids := make([]string, 0, len(matches))
+	for _, n := range matches {
+		ids = append(ids, n.ID)
+	}
+	return "", fmt.Errorf("%q matches %d nodes (%s); use an ID", ref, len(matches), strings.Join(ids, ", "))
+}
@@ -3,13 +3,20 @@ package aioutput
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"time"
 
 	"github.com/greenstevester/swiftui-cause-effect-cli/internal/correlation"
 	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/hierarchy"
 	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/merge"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/patch"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/project"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/simulate"
 	"github.com/greenstevester/swiftui-cause-effect-cli/internal/suggestions"
 )
 
@@ -35,20 +42,43 @@ type Report struct {
 	// Source code correlations
 	SourceCorrelations []correlation.SourceMatch `json:"source_correlations,omitempty"`
 
+	// Update counts rolled up the view containment tree, screens first
+	Hierarchy []*hierarchy.Rollup `json:"hierarchy,omitempty"`
+
 	// High-level recommendations
 	Recommendations []suggestions.Recommendation `json:"recommendations"`
 
 	// AI agent instructions
 	AgentInstructions AgentInstructions `json:"agent_instructions"`
+
+	// Ordered fix tasks with their targets, preconditions and checks
+	Plan *Plan `json:"plan,omitempty"`
+
+	// Set when the report was fitted to a token budget, with what was left out
+	Budget   *Budget   `json:"budget,omitempty"`
+	Elisions []Elision `json:"elisions,omitempty"`
 }
 
 // InputInfo describes what was analyzed
 type InputInfo struct {
-	TracePath    string `json:"trace_path,omitempty"`
-	ExportDir    string `json:"export_dir,omitempty"`
-	SourceRoot   string `json:"source_root,omitempty"`
-	FilesParsed  int    `json:"files_parsed"`
-	SwiftFiles   int    `json:"swift_files,omitempty"`
+	TracePath        string          `json:"trace_path,omitempty"`
+	ExportDir        string          `json:"export_dir,omitempty"`
+	SourceRoot       string          `json:"source_root,omitempty"`
+	FilesParsed      int             `json:"files_parsed"`
+	SwiftFiles       int             `json:"swift_files,omitempty"`
+	DeploymentTarget *project.Target `json:"deployment_target,omitempty"`
+	ParseHints       []string        `json:"parse_hints,omitempty"` // inputs the parser skipped or misread
+
+	// Per-input breakdown when several traces were merged
+	Sources []SourceSummary `json:"sources,omitempty"`
+}
+
+// SourceSummary describes one merged input
+type SourceSummary struct {
+	Name             string `json:"name"`
+	NodesPresent     int    `json:"nodes_present"`
+	TotalViewUpdates int    `json:"total_view_updates"`
+	TotalCauseEvents int    `json:"total_cause_events"`
 }
 
 // Summary provides high-level metrics
@@ -68,12 +98,21 @@ type Summary struct {
 type IssueWithFixes struct {
 	issues.Issue
 	SuggestedFixes []suggestions.Fix `json:"suggested_fixes"`
+
+	// Source change of the first mechanical fix, when the issue correlates
+	// with project files the fix can rewrite
+	Patch *patch.Patch `json:"patch,omitempty"`
+
+	// Per-source counts of the primary affected node for merged graphs
+	SourceCounts map[string]int    `json:"source_counts,omitempty"`
+	CountStats   *merge.CountStats `json:"count_stats,omitempty"`
 }
 
 // GraphData is a simplified graph representation for AI consumption
 type GraphData struct {
-	Nodes []NodeData `json:"nodes"`
-	Edges []EdgeData `json:"edges"`
+	Nodes       []NodeData `json:"nodes"`
+	Edges       []EdgeData `json:"edges"`
+	Containment []EdgeData `json:"containment,omitempty"` // parent → child views
 }
 
 // NodeData is a node in AI-friendly format
@@ -85,6 +124,16 @@ type NodeData struct {
 	SourceFile  string  `json:"source_file,omitempty"`
 	LineNumber  int     `json:"line_number,omitempty"`
 	Confidence  float64 `json:"source_confidence,omitempty"`
+
+	// Per-source counts and their spread for merged graphs
+	SourceCounts map[string]int    `json:"source_counts,omitempty"`
+	CountStats   *merge.CountStats `json:"count_stats,omitempty"`
+
+	// Raw nodes collapsed into this one by label normalization, with the
+	// spread of their counts when there is more than one
+	InstanceCount int               `json:"instance_count,omitempty"`
+	InstanceStats *merge.CountStats `json:"instance_stats,omitempty"`
+	Instances     []graph.Instance  `json:"instances,omitempty"`
 }
 
 // EdgeData is an edge in AI-friendly format
@@ -103,6 +152,10 @@ type AgentInstructions struct {
 	Context         string   `json:"context"`
 }
 
+// maxEstimatedIssues caps the issues, most severe first, whose fixes get a
+// simulated payoff; the rest keep the catalog order
+const maxEstimatedIssues = 50
+
 // Generator creates AI reports
 type Generator struct {
 	detector   *issues.Detector
@@ -134,66 +187,231 @@ type GenerateOptions struct {
 	ExportDir   string
 	SourceRoot  string
 	FilesParsed int
+	Hints       []string // parse hints to carry into the report
+	Sources     []string // merged input names, in order
+
+	// Deployment target the fixes are checked against; fixes it cannot use
+	// are ranked last, or left out with DropIncompatible
+	Target           project.Target
+	DropIncompatible bool
+
+	// Catalog the fixes are drawn from (nil = the built-in one)
+	Catalog *suggestions.Catalog
+
+	// Stream receives records as the report is produced (nil = none)
+	Stream *Stream
 }
 
 // Generate creates a complete AI report from a graph
 func (g *Generator) Generate(gr *graph.Graph, opts GenerateOptions) *Report {
 	// Detect issues
 	detectedIssues := g.detector.Detect(gr)
+	catalog := opts.Catalog
+	if catalog == nil {
+		catalog = suggestions.Builtin()
+	}
+	opts.Stream.Progress("detect", fmt.Sprintf("%d issues found", len(detectedIssues)), 0, 0)
+	if opts.Stream != nil {
+		opts.Stream.graph(g.buildGraphData(gr, nil, opts.Sources))
+	}
 
 	// Generate fixes for each issue
 	issuesWithFixes := make([]IssueWithFixes, len(detectedIssues))
 	for i, issue := range detectedIssues {
 		issuesWithFixes[i] = IssueWithFixes{
 			Issue:          issue,
-			SuggestedFixes: suggestions.GenerateFixes(issue),
+			SuggestedFixes: catalog.Fixes(issue, suggestions.Context{}),
+		}
+		if len(opts.Sources) > 0 && len(issue.AffectedNodes) > 0 {
+			if n, ok := gr.Nodes[issue.AffectedNodes[0]]; ok && n.SourceCounts != nil {
+				stats := merge.Stats(n.SourceCounts, opts.Sources)
+				issuesWithFixes[i].SourceCounts = n.SourceCounts
+				issuesWithFixes[i].CountStats = &stats
+			}
+		}
+	}
+
+	// Rank fixes by their simulated payoff and by what the deployment target
+	// allows; with source, issues are then patched with the first usable
+	// mechanical fix. Payoff is simulated for the most severe issues only,
+	// and once: correlation tailors a fix's samples, not its effect.
+	expected := make([]map[string]*simulate.Impact, len(issuesWithFixes))
+	est := simulate.NewEstimator(gr, g.detector, detectedIssues)
+	for i := 0; i < len(issuesWithFixes) && i < maxEstimatedIssues; i++ {
+		expected[i] = catalog.Estimate(issuesWithFixes[i].SuggestedFixes, est, issuesWithFixes[i].Issue)
+	}
+	rank := func(i int) {
+		fixes := suggestions.RankByPayoff(issuesWithFixes[i].SuggestedFixes, expected[i])
+		issuesWithFixes[i].SuggestedFixes = suggestions.ApplyTarget(fixes, opts.Target, opts.DropIncompatible)
+	}
+	// A stream gets the issues before correlation, which can take a while
+	// on a large source tree, and again once it has refined them
+	if g.correlator == nil || opts.Stream != nil {
+		for i := range issuesWithFixes {
+			rank(i)
+			opts.Stream.issue(issuesWithFixes[i], false)
 		}
 	}
 
 	// Correlate with source if available
 	var sourceMatches []correlation.SourceMatch
 	if g.correlator != nil {
-		sourceMatches = g.correlator.Correlate(gr)
+		sourceMatches = g.correlate(gr, opts.Stream)
+		refs := issues.NewResolver(gr)
+		for i := range issuesWithFixes {
+			g.locateIssue(refs, &issuesWithFixes[i].Issue)
+			ctx := g.issueContext(gr, refs, issuesWithFixes[i].Issue)
+			issuesWithFixes[i].SuggestedFixes = catalog.Fixes(issuesWithFixes[i].Issue, ctx)
+			rank(i)
+			issuesWithFixes[i].Patch = g.patchIssue(refs, issuesWithFixes[i])
+			opts.Stream.issue(issuesWithFixes[i], true)
+		}
 	}
 
 	// Build graph data with source info
-	graphData := g.buildGraphData(gr, sourceMatches)
+	graphData := g.buildGraphData(gr, sourceMatches, opts.Sources)
+
+	// Roll update counts up the view hierarchy
+	rollups := g.buildHierarchy(gr, detectedIssues)
 
 	// Calculate summary
 	summary := g.calculateSummary(gr, detectedIssues)
 
 	// Generate recommendations
-	recs := suggestions.GenerateRecommendations(detectedIssues)
+	recs := suggestions.ApplyTargetRecommendations(suggestions.GenerateRecommendations(detectedIssues), opts.Target, opts.DropIncompatible)
 
 	// Build agent instructions
-	agentInstructions := g.buildAgentInstructions(summary, detectedIssues)
+	agentInstructions := g.buildAgentInstructions(summary, issuesWithFixes, opts.Target)
 
 	swiftFiles := 0
 	if g.correlator != nil {
 		swiftFiles = g.correlator.SwiftFileCount()
 	}
+	var target *project.Target
+	if opts.Target.Known() {
+		target = &opts.Target
+	}
 
-	return &Report{
-		Version:   "1.0",
+	report := &Report{
+		Version:   ReportVersion,
 		Generated: time.Now().UTC(),
 		Tool:      "swiftuice",
 		Input: InputInfo{
-			TracePath:   opts.TracePath,
-			ExportDir:   opts.ExportDir,
-			SourceRoot:  opts.SourceRoot,
-			FilesParsed: opts.FilesParsed,
-			SwiftFiles:  swiftFiles,
+			TracePath:        opts.TracePath,
+			ExportDir:        opts.ExportDir,
+			SourceRoot:       opts.SourceRoot,
+			FilesParsed:      opts.FilesParsed,
+			SwiftFiles:       swiftFiles,
+			DeploymentTarget: target,
+			ParseHints:       opts.Hints,
+			Sources:          summarizeSources(gr, opts.Sources),
 		},
 		Summary:            summary,
 		Issues:             issuesWithFixes,
 		Graph:              graphData,
 		SourceCorrelations: sourceMatches,
+		Hierarchy:          rollups,
 		Recommendations:    recs,
 		AgentInstructions:  agentInstructions,
 	}
+	report.Plan = BuildPlan(report)
+	opts.Stream.summary(summary)
+	return report
+}
+
+// correlate finds the source matches of every node, in ID order, and
+// streams them as they are found
+func (g *Generator) correlate(gr *graph.Graph, stream *Stream) []correlation.SourceMatch {
+	nodes := gr.SortedNodes()
+	stream.Progress("correlate", fmt.Sprintf("matching %d nodes against %d Swift files", len(nodes), g.correlator.SwiftFileCount()), 0, len(nodes))
+	step := max(len(nodes)/10, 1)
+	var matches []correlation.SourceMatch
+	for i, n := range nodes {
+		found := g.correlator.CorrelateNode(n)
+		for _, m := range found {
+			stream.correlation(m)
+		}
+		matches = append(matches, found...)
+		if done := i + 1; done%step == 0 || done == len(nodes) {
+			stream.Progress("correlate", fmt.Sprintf("%d matches", len(matches)), done, len(nodes))
+		}
+	}
+	return matches
 }
 
-func (g *Generator) buildGraphData(gr *graph.Graph, matches []correlation.SourceMatch) GraphData {
+// locateIssue points an issue at the source of its first affected node
+// that correlates; affected nodes may be given by ID or label.
+func (g *Generator) locateIssue(refs *issues.Resolver, issue *issues.Issue) {
+	for _, ref := range issue.AffectedNodes {
+		id, ok := refs.NodeID(ref)
+		if !ok {
+			continue
+		}
+		if m := g.correlator.BestMatch(id); m != nil {
+			issue.SourceFile = m.RelativePath
+			issue.LineNumber = m.LineNumber
+			return
+		}
+	}
+}
+
+// issueContext collects the project names for an issue's fix samples: the
+// first affected view that correlates with its declaration, its file's
+// imports, and the model and state properties that view declares
+func (g *Generator) issueContext(gr *graph.Graph, refs *issues.Resolver, issue issues.Issue) suggestions.Context {
+	var ctx suggestions.Context
+	for _, id := range refs.AffectedNodeIDs([]issues.Issue{issue}) {
+		n, ok := gr.Nodes[id]
+		if !ok || n.Type != graph.NodeView {
+			continue
+		}
+		m := g.correlator.BestMatch(id)
+		if m == nil || m.MatchType != "exact" {
+			continue
+		}
+		ctx.ViewType = m.MatchedSymbol
+		ctx.Snippet = m.CodeSnippet
+		ctx.SourceFile = m.RelativePath
+		ctx.LineNumber = m.LineNumber
+		ctx.Imports = correlation.Imports(m.FilePath)
+		for _, p := range correlation.ViewProperties(m.FilePath, m.MatchedSymbol) {
+			if p.Model() && ctx.Property == "" {
+				ctx.Property, ctx.ModelType = p.Name, p.Type
+			} else if p.Wrapper == "State" && ctx.StateName == "" {
+				ctx.StateName = p.Name
+			}
+		}
+		break
+	}
+	return ctx
+}
+
+// patchIssue generates the source patch of an issue's first mechanical fix
+// against the files its affected nodes correlate with
+func (g *Generator) patchIssue(refs *issues.Resolver, issue IssueWithFixes) *patch.Patch {
+	var files []string
+	for _, id := range refs.AffectedNodeIDs([]issues.Issue{issue.Issue}) {
+		if m := g.correlator.BestMatch(id); m != nil {
+			files = append(files, m.RelativePath)
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	var fixIDs []string
+	for _, fix := range issue.SuggestedFixes {
+		if fix.Availability == nil || fix.Availability.Compatible {
+			fixIDs = append(fixIDs, fix.ID)
+		}
+	}
+	p, err := patch.Generate(g.correlator.GetSourceRoot(), fixIDs, files)
+	if err != nil || len(p.Files) == 0 {
+		return nil
+	}
+	return p
+}
+
+func (g *Generator) buildGraphData(gr *graph.Graph, matches []correlation.SourceMatch, sources []string) GraphData {
 	// Build lookup for source matches
 	matchLookup := make(map[string]*correlation.SourceMatch)
 	for i := range matches {
@@ -215,6 +433,25 @@ func (g *Generator) buildGraphData(gr *graph.Graph, matches []correlation.Source
 			nd.LineNumber = match.LineNumber
 			nd.Confidence = match.Confidence
 		}
+		if len(sources) > 0 && node.SourceCounts != nil {
+			stats := merge.Stats(node.SourceCounts, sources)
+			nd.SourceCounts = node.SourceCounts
+			nd.CountStats = &stats
+		}
+		if len(node.Instances) > 0 {
+			nd.InstanceCount = len(node.Instances)
+			nd.Instances = node.Instances
+		}
+		if len(node.Instances) > 1 {
+			counts := make(map[string]int, len(node.Instances))
+			ids := make([]string, len(node.Instances))
+			for i, inst := range node.Instances {
+				ids[i] = fmt.Sprintf("%d:%s", i, inst.ID)
+				counts[ids[i]] = inst.Count
+			}
+			stats := merge.Stats(counts, ids)
+			nd.InstanceStats = &stats
+		}
 		nodes = append(nodes, nd)
 	}
 
@@ -227,7 +464,64 @@ func (g *Generator) buildGraphData(gr *graph.Graph, matches []correlation.Source
 		})
 	}
 
-	return GraphData{Nodes: nodes, Edges: edges}
+	var containment []EdgeData
+	for _, edge := range gr.Containment {
+		containment = append(containment, EdgeData{From: edge.From, To: edge.To})
+	}
+
+	return GraphData{Nodes: nodes, Edges: edges, Containment: containment}
+}
+
+// buildHierarchy combines containment from the trace with the view nesting
+// found in source and aggregates update counts up to the screens.
+func (g *Generator) buildHierarchy(gr *graph.Graph, detected []issues.Issue) []*hierarchy.Rollup {
+	var links []hierarchy.Link
+	names := map[string]string{}
+	if g.correlator != nil {
+		for _, l := range g.correlator.ViewHierarchy() {
+			links = append(links, hierarchy.Link{Parent: l.Parent, Child: l.Child})
+		}
+		for id, n := range gr.Nodes {
+			if m := g.correlator.BestMatch(id); n.Type == graph.NodeView && m != nil && m.MatchType == "exact" && m.MatchedSymbol != "" {
+				names[id] = m.MatchedSymbol
+			}
+		}
+	}
+
+	flagged := map[string]bool{}
+	for _, id := range issues.AffectedNodeIDs(gr, detected) {
+		flagged[id] = true
+	}
+	return hierarchy.Build(gr, links, flagged, names)
+}
+
+// summarizeSources totals each merged input's contribution
+func summarizeSources(gr *graph.Graph, sources []string) []SourceSummary {
+	if len(sources) == 0 {
+		return nil
+	}
+	out := make([]SourceSummary, len(sources))
+	index := make(map[string]int, len(sources))
+	for i, name := range sources {
+		out[i].Name = name
+		index[name] = i
+	}
+	for _, node := range gr.Nodes {
+		for name, count := range node.SourceCounts {
+			i, ok := index[name]
+			if !ok {
+				continue
+			}
+			out[i].NodesPresent++
+			switch node.Type {
+			case graph.NodeView:
+				out[i].TotalViewUpdates += count
+			case graph.NodeCause:
+				out[i].TotalCauseEvents += count
+			}
+		}
+	}
+	return out
 }
 
 func (g *Generator) calculateSummary(gr *graph.Graph, detected []issues.Issue) Summary {
@@ -254,13 +548,7 @@ func (g *Generator) calculateSummary(gr *graph.Graph, detected []issues.Issue) S
 	}
 
 	// Calculate performance score (100 = no issues, 0 = critical problems)
-	score := 100
-	score -= critical * 25
-	score -= high * 10
-	score -= (len(detected) - critical - high) * 3
-	if score < 0 {
-		score = 0
-	}
+	score := issues.Score(detected)
 
 	status := "good"
 	if score < 50 {
@@ -282,15 +570,48 @@ func (g *Generator) calculateSummary(gr *graph.Graph, detected []issues.Issue) S
 	}
 }
 
-func (g *Generator) buildAgentInstructions(summary Summary, detected []issues.Issue) AgentInstructions {
+// Deduction is one line of the performance score breakdown
+type Deduction struct {
+	Reason string
+	Issues int
+	Points int
+}
+
+// Deductions explains PerformanceScore: the points taken off the starting
+// 100 per severity band. The total may exceed 100; the score stops at 0.
+func (s Summary) Deductions() []Deduction {
+	var out []Deduction
+	add := func(reason string, n, per int) {
+		if n > 0 {
+			out = append(out, Deduction{Reason: reason, Issues: n, Points: n * per})
+		}
+	}
+	add(fmt.Sprintf("critical issues (%d each)", issues.CriticalPenalty), s.CriticalIssues, issues.CriticalPenalty)
+	add(fmt.Sprintf("high issues (%d each)", issues.HighPenalty), s.HighIssues, issues.HighPenalty)
+	add(fmt.Sprintf("other issues (%d each)", issues.OtherPenalty), s.IssuesFound-s.CriticalIssues-s.HighIssues, issues.OtherPenalty)
+	return out
+}
+
+func (g *Generator) buildAgentInstructions(summary Summary, detected []IssueWithFixes, target project.Target) AgentInstructions {
 	var priority []string
 
-	// Prioritize by issue severity
+	// Prioritize severe issues by the payoff of their best fix
+	var severe []IssueWithFixes
 	for _, issue := range detected {
 		if issue.Severity == issues.SeverityCritical || issue.Severity == issues.SeverityHigh {
-			priority = append(priority, fmt.Sprintf("[%s] %s", issue.Severity, issue.Title))
+			severe = append(severe, issue)
 		}
 	}
+	sort.SliceStable(severe, func(i, j int) bool {
+		return simulate.Payoff(bestImpact(severe[i]), bestImpact(severe[j])) > 0
+	})
+	for _, issue := range severe {
+		line := fmt.Sprintf("[%s] %s", issue.Severity, issue.Title)
+		if im := bestImpact(issue); im != nil && len(issue.SuggestedFixes) > 0 {
+			line += fmt.Sprintf(" (%s: %s)", issue.SuggestedFixes[0].ID, im)
+		}
+		priority = append(priority, line)
+	}
 	if len(priority) == 0 {
 		priority = append(priority, "Review medium-priority issues if any")
 	}
@@ -301,6 +622,10 @@ func (g *Generator) buildAgentInstructions(summary Summary, detected []issues.Is
 		"Test changes thoroughly before committing",
 		"Consider iOS version compatibility of suggested fixes",
 		"Preserve existing code style and patterns",
+		"Work through plan.tasks in order; finish a task's depends_on first",
+	}
+	if target.Known() {
+		constraints[3] = fmt.Sprintf("The project targets %s: skip fixes whose availability is not compatible", target)
 	}
 
 	successCriteria := []string{
@@ -334,6 +659,15 @@ func (g *Generator) buildAgentInstructions(summary Summary, detected []issues.Is
 	}
 }
 
+// bestImpact is the expected impact of an issue's first fix, the best
+// usable one once fixes are ranked
+func bestImpact(issue IssueWithFixes) *simulate.Impact {
+	if len(issue.SuggestedFixes) == 0 {
+		return nil
+	}
+	return issue.SuggestedFixes[0].Expected
+}
+
 // WriteJSON writes the report as formatted JSON to a file
 func (r *Report) WriteJSON(path string) error {
 	data, err := json.MarshalIndent(r, "", "  ")
@@ -343,6 +677,35 @@ func (r *Report) WriteJSON(path string) error {
 	return os.WriteFile(path, data, 0o644)
 }
 
+// ReadReport loads a report previously written by WriteJSON, migrating
+// older report versions to ReportVersion
+func ReadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := decodeReport(data)
+	if errors.Is(err, errNotReport) {
+		return nil, fmt.Errorf("%s is not a swiftuice report", path)
+	}
+	return r, err
+}
+
+// ToGraph rebuilds a graph from the report's node and edge lists
+func (d GraphData) ToGraph() *graph.Graph {
+	g := graph.New()
+	for _, n := range d.Nodes {
+		g.UpsertNode(&graph.Node{ID: n.ID, Label: n.Label, Type: graph.NodeType(n.Type), Count: n.UpdateCount, SourceCounts: n.SourceCounts, Instances: n.Instances})
+	}
+	for _, e := range d.Edges {
+		g.AddEdge(graph.Edge{From: e.From, To: e.To, Label: e.Label})
+	}
+	for _, e := range d.Containment {
+		g.AddContainment(e.From, e.To)
+	}
+	return g
+}
+
 // ToJSON returns the report as a JSON string
 func (r *Report) ToJSON() (string, error) {
 	data, err := json.MarshalIndent(r, "", "  ")
@@ -2,12 +2,15 @@ package aioutput
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
 	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/project"
 )
 
 func TestNewGenerator(t *testing.T) {
@@ -54,8 +57,8 @@ func TestGenerate(t *testing.T) {
 		FilesParsed: 1,
 	})
 
-	if report.Version != "1.0" {
-		t.Errorf("Expected version 1.0, got %s", report.Version)
+	if report.Version != ReportVersion {
+		t.Errorf("Expected version %s, got %s", ReportVersion, report.Version)
 	}
 
 	if report.Tool != "swiftuice" {
@@ -115,6 +118,198 @@ func TestGenerateWithIssues(t *testing.T) {
 	}
 }
 
+func TestGenerateLocatesIssues(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ItemRow.swift"), []byte("import SwiftUI\n\nstruct ItemRow: View {\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gen, err := NewGenerator(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gr := graph.New()
+	gr.UpsertNode(&graph.Node{ID: "v1", Label: "ItemRow", Type: graph.NodeView, Count: 50})
+	gr.UpsertNode(&graph.Node{ID: "s1", Label: "@State", Type: graph.NodeState})
+	gr.AddEdge(graph.Edge{From: "s1", To: "v1"})
+
+	report := gen.Generate(gr, GenerateOptions{SourceRoot: dir})
+	for _, issue := range report.Issues {
+		if issue.Type == issues.IssueExcessiveRerender {
+			if issue.SourceFile != "ItemRow.swift" || issue.LineNumber != 3 {
+				t.Errorf("expected ItemRow.swift:3, got %s:%d", issue.SourceFile, issue.LineNumber)
+			}
+			return
+		}
+	}
+	t.Error("Expected to detect excessive rerender issue")
+}
+
+func TestGeneratePatches(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"Cart.swift":     "import SwiftUI\n\nfinal class Cart: ObservableObject {\n    @Published var items: [String] = []\n}\n",
+		"CartView.swift": "import SwiftUI\n\nstruct CartView: View {\n    @ObservedObject var cart: Cart\n\n    var body: some View {\n        Text(\"\\(cart.items.count)\")\n    }\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gen, err := NewGenerator(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gr := graph.New()
+	gr.UpsertNode(&graph.Node{ID: "v1", Label: "CartView", Type: graph.NodeView, Count: 50})
+	gr.UpsertNode(&graph.Node{ID: "s1", Label: "@State", Type: graph.NodeState})
+	gr.AddEdge(graph.Edge{From: "s1", To: "v1"})
+
+	report := gen.Generate(gr, GenerateOptions{SourceRoot: dir})
+	for _, issue := range report.Issues {
+		if issue.Type != issues.IssueExcessiveRerender {
+			continue
+		}
+		if issue.Patch == nil || issue.Patch.FixID != "observable-macro" || len(issue.Patch.Files) != 2 {
+			t.Fatalf("expected an observable-macro patch of both files, got %+v", issue.Patch)
+		}
+		for _, fix := range issue.SuggestedFixes {
+			if fix.ID == "observable-macro" && (!fix.Tailored || !strings.Contains(fix.CodeAfter, "var cart: Cart")) {
+				t.Errorf("expected the sample to use the project's names:\n%s", fix.CodeAfter)
+			}
+		}
+		return
+	}
+	t.Error("Expected to detect excessive rerender issue")
+}
+
+func TestGenerateDeploymentTarget(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Cart.swift"), []byte("import SwiftUI\n\nfinal class Cart: ObservableObject {\n    @Published var items: [String] = []\n}\n\nstruct CartView: View {\n    @ObservedObject var cart: Cart\n    var body: some View { Text(\"x\") }\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gen, err := NewGenerator(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gr := graph.New()
+	gr.UpsertNode(&graph.Node{ID: "v1", Label: "CartView", Type: graph.NodeView, Count: 50})
+	gr.UpsertNode(&graph.Node{ID: "s1", Label: "@State", Type: graph.NodeState})
+	gr.AddEdge(graph.Edge{From: "s1", To: "v1"})
+
+	report := gen.Generate(gr, GenerateOptions{SourceRoot: dir, Target: project.Target{IOS: "15.0", Sources: []string{"flag"}}})
+	if report.Input.DeploymentTarget == nil || report.Input.DeploymentTarget.IOS != "15.0" {
+		t.Errorf("expected the deployment target in the input info, got %+v", report.Input.DeploymentTarget)
+	}
+	if !strings.Contains(strings.Join(report.AgentInstructions.Constraints, "\n"), "The project targets iOS 15.0") {
+		t.Errorf("expected a deployment target constraint, got %v", report.AgentInstructions.Constraints)
+	}
+	for _, issue := range report.Issues {
+		if issue.Type != issues.IssueExcessiveRerender {
+			continue
+		}
+		last := issue.SuggestedFixes[len(issue.SuggestedFixes)-1]
+		if last.ID != "observable-macro" || last.Availability == nil || last.Availability.Compatible {
+			t.Errorf("expected @Observable ranked last as unavailable, got %s %+v", last.ID, last.Availability)
+		}
+		if issue.Patch != nil {
+			t.Errorf("no patch should use a fix the target cannot run, got %s", issue.Patch.FixID)
+		}
+		return
+	}
+	t.Error("Expected to detect excessive rerender issue")
+}
+
+func TestGenerateExpectedImpact(t *testing.T) {
+	gen, _ := NewGenerator("")
+	gr := graph.New()
+	gr.UpsertNode(&graph.Node{ID: "timer", Label: "Timer.publish", Type: graph.NodeCause, Count: 60})
+	gr.UpsertNode(&graph.Node{ID: "now", Label: "@State now", Type: graph.NodeState, Count: 60})
+	gr.UpsertNode(&graph.Node{ID: "clock", Label: "ClockView", Type: graph.NodeView, Count: 60})
+	gr.UpsertNode(&graph.Node{ID: "list", Label: "ListView", Type: graph.NodeView, Count: 60})
+	gr.AddEdge(graph.Edge{From: "timer", To: "now"})
+	gr.AddEdge(graph.Edge{From: "now", To: "clock"})
+	gr.AddEdge(graph.Edge{From: "now", To: "list"})
+
+	report := gen.Generate(gr, GenerateOptions{})
+	for _, issue := range report.Issues {
+		if issue.Type != issues.IssueTimerCascade {
+			continue
+		}
+		im := issue.SuggestedFixes[0].Expected
+		if im == nil || im.ViewUpdatesSaved != 60 || !im.ResolvesIssue || im.Before.Score != report.Summary.PerformanceScore {
+			t.Errorf("expected the timer fix to save the list's 60 updates, got %+v", im)
+		}
+		for _, p := range report.AgentInstructions.Priority {
+			if strings.HasPrefix(p, "[high] "+issue.Title) && !strings.Contains(p, "60 fewer view updates") {
+				t.Errorf("priority should carry the expected savings, got %q", p)
+			}
+		}
+		return
+	}
+	t.Error("Expected to detect a timer cascade")
+}
+
+func TestGenerateHierarchy(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := "struct ListScreen: View {\n    var body: some View {\n        List { RowView() }\n    }\n}\n\nstruct RowView: View {\n    var body: some View { Text(\"row\") }\n}\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "Screen.swift"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gen, err := NewGenerator(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gr := graph.New()
+	gr.UpsertNode(&graph.Node{ID: "v1", Label: "RowView", Type: graph.NodeView, Count: 40})
+	gr.UpsertNode(&graph.Node{ID: "v2", Label: "ListScreen", Type: graph.NodeView, Count: 2})
+
+	report := gen.Generate(gr, GenerateOptions{})
+	if len(report.Hierarchy) != 1 {
+		t.Fatalf("expected one screen rollup, got %+v", report.Hierarchy)
+	}
+	root := report.Hierarchy[0]
+	if root.View != "ListScreen" || root.TotalUpdates != 42 || root.SelfUpdates != 2 {
+		t.Errorf("unexpected rollup: %+v", root)
+	}
+	// RowView is flagged as excessive, so its updates count as flagged
+	if root.FlaggedUpdates != 40 {
+		t.Errorf("expected 40 flagged updates, got %d", root.FlaggedUpdates)
+	}
+}
+
+func TestGenerateInstances(t *testing.T) {
+	gen, _ := NewGenerator("")
+	gr := graph.New()
+	gr.UpsertNode(&graph.Node{ID: "v1", Label: "RowView", Type: graph.NodeView, Count: 40, Instances: []graph.Instance{
+		{ID: "v1", Label: "RowView: 0x6000aaaa", Count: 36},
+		{ID: "v2", Label: "RowView: 0x6000bbbb", Count: 2},
+		{ID: "v3", Label: "RowView: 0x6000cccc", Count: 2},
+	}})
+
+	report := gen.Generate(gr, GenerateOptions{})
+	nd := report.Graph.Nodes[0]
+	if nd.InstanceCount != 3 || len(nd.Instances) != 3 {
+		t.Fatalf("expected 3 instances, got %+v", nd)
+	}
+	if nd.InstanceStats == nil || nd.InstanceStats.Min != 2 || nd.InstanceStats.Median != 2 || nd.InstanceStats.Max != 36 {
+		t.Errorf("unexpected instance stats: %+v", nd.InstanceStats)
+	}
+	if back := report.Graph.ToGraph(); len(back.Nodes["v1"].Instances) != 3 {
+		t.Error("instances lost when rebuilding the graph")
+	}
+}
+
+func TestGenerateNoHierarchy(t *testing.T) {
+	gen, _ := NewGenerator("")
+	gr := graph.New()
+	gr.UpsertNode(&graph.Node{ID: "v1", Label: "RowView", Type: graph.NodeView, Count: 40})
+
+	if report := gen.Generate(gr, GenerateOptions{}); report.Hierarchy != nil {
+		t.Errorf("expected no hierarchy without containment, got %+v", report.Hierarchy)
+	}
+}
+
 func TestCalculateSummary(t *testing.T) {
 	gen, _ := NewGenerator("")
 
@@ -218,6 +413,30 @@ func TestPerformanceScoreWithIssues(t *testing.T) {
 	}
 }
 
+func TestSummaryDeductions(t *testing.T) {
+	gen, _ := NewGenerator("")
+	summary := gen.calculateSummary(graph.New(), []issues.Issue{
+		{Severity: issues.SeverityCritical},
+		{Severity: issues.SeverityHigh},
+		{Severity: issues.SeverityMedium},
+		{Severity: issues.SeverityLow},
+	})
+
+	total := 0
+	for _, d := range summary.Deductions() {
+		total += d.Points
+	}
+	if 100-total != summary.PerformanceScore {
+		t.Errorf("deductions %v do not explain score %d", summary.Deductions(), summary.PerformanceScore)
+	}
+	if got := summary.Deductions(); len(got) != 3 || got[2].Issues != 2 || got[2].Points != 6 {
+		t.Errorf("unexpected deductions: %+v", got)
+	}
+	if got := (Summary{}).Deductions(); len(got) != 0 {
+		t.Errorf("expected no deductions without issues, got %+v", got)
+	}
+}
+
 func TestAgentInstructions(t *testing.T) {
 	gen, _ := NewGenerator("")
 
@@ -263,8 +482,8 @@ func TestToJSON(t *testing.T) {
 		t.Fatalf("ToJSON produced invalid JSON: %v", err)
 	}
 
-	if parsed.Version != "1.0" {
-		t.Errorf("Parsed version mismatch: got %s, expected 1.0", parsed.Version)
+	if parsed.Version != ReportVersion {
+		t.Errorf("Parsed version mismatch: got %s, expected %s", parsed.Version, ReportVersion)
 	}
 }
 
@@ -387,6 +606,7 @@ func TestInputInfoCaptured(t *testing.T) {
 		ExportDir:   "/path/to/exported",
 		SourceRoot:  "/path/to/source",
 		FilesParsed: 5,
+		Hints:       []string{"skipped a.json"},
 	})
 
 	if report.Input.TracePath != "/path/to/trace.trace" {
@@ -404,4 +624,130 @@ func TestInputInfoCaptured(t *testing.T) {
 	if report.Input.FilesParsed != 5 {
 		t.Errorf("Expected files parsed to be 5, got %d", report.Input.FilesParsed)
 	}
+
+	if len(report.Input.ParseHints) != 1 {
+		t.Errorf("Expected parse hints to be captured, got %v", report.Input.ParseHints)
+	}
+}
+
+func TestReadReportRoundTrip(t *testing.T) {
+	gen, _ := NewGenerator("")
+
+	gr := graph.New()
+	gr.UpsertNode(&graph.Node{ID: "c1", Label: "Button tap", Type: graph.NodeCause})
+	gr.UpsertNode(&graph.Node{ID: "v1", Label: "ItemRow", Type: graph.NodeView, Count: 50})
+	gr.AddEdge(graph.Edge{From: "c1", To: "v1", Label: "updates"})
+
+	report := gen.Generate(gr, GenerateOptions{TracePath: "test.trace"})
+	path := filepath.Join(t.TempDir(), "analysis.json")
+	if err := report.WriteJSON(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := ReadReport(path)
+	if err != nil {
+		t.Fatalf("ReadReport failed: %v", err)
+	}
+	if len(loaded.Issues) != len(report.Issues) {
+		t.Errorf("expected %d issues, got %d", len(report.Issues), len(loaded.Issues))
+	}
+
+	rebuilt := loaded.Graph.ToGraph()
+	if len(rebuilt.Nodes) != 2 || len(rebuilt.Edges) != 1 {
+		t.Fatalf("unexpected rebuilt graph: %d nodes, %d edges", len(rebuilt.Nodes), len(rebuilt.Edges))
+	}
+	if n := rebuilt.Nodes["v1"]; n.Type != graph.NodeView || n.Count != 50 {
+		t.Errorf("unexpected node v1: %+v", *n)
+	}
+}
+
+func TestReadReportRejectsOtherJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "other.json")
+	os.WriteFile(path, []byte(`{"nodes":[],"edges":[]}`), 0o644)
+	if _, err := ReadReport(path); err == nil {
+		t.Error("expected error for non-report JSON")
+	}
+}
+
+func TestGenerateWithMergedSources(t *testing.T) {
+	gen, _ := NewGenerator("")
+
+	gr := graph.New()
+	gr.UpsertNode(&graph.Node{ID: "s1", Label: "@State", Type: graph.NodeState})
+	gr.UpsertNode(&graph.Node{ID: "v1", Label: "ItemRow", Type: graph.NodeView, Count: 60,
+		SourceCounts: map[string]int{"iphone": 10, "ipad": 50}})
+	gr.AddEdge(graph.Edge{From: "s1", To: "v1"})
+
+	report := gen.Generate(gr, GenerateOptions{Sources: []string{"iphone", "ipad", "mac"}})
+
+	if len(report.Input.Sources) != 3 {
+		t.Fatalf("expected 3 source summaries, got %d", len(report.Input.Sources))
+	}
+	if report.Input.Sources[1].TotalViewUpdates != 50 {
+		t.Errorf("expected ipad view updates 50, got %d", report.Input.Sources[1].TotalViewUpdates)
+	}
+	if report.Input.Sources[2].NodesPresent != 0 {
+		t.Errorf("expected mac to contribute nothing, got %+v", report.Input.Sources[2])
+	}
+
+	var found bool
+	for _, issue := range report.Issues {
+		if issue.Type != issues.IssueExcessiveRerender {
+			continue
+		}
+		found = true
+		if issue.CountStats == nil || issue.CountStats.Min != 0 || issue.CountStats.Median != 10 || issue.CountStats.Max != 50 {
+			t.Errorf("unexpected issue count stats: %+v", issue.CountStats)
+		}
+	}
+	if !found {
+		t.Error("expected excessive rerender issue")
+	}
+
+	for _, n := range report.Graph.Nodes {
+		if n.ID == "v1" && n.SourceCounts["ipad"] != 50 {
+			t.Errorf("expected per-source counts on node data, got %v", n.SourceCounts)
+		}
+	}
+}
+
+// largeGraph builds a trace-sized graph: causes feeding shared states,
+// states observed by several views and views nested five deep, with most
+// nodes busy enough to be flagged
+func largeGraph(causes int) *graph.Graph {
+	gr := graph.New()
+	states, views := causes*4, causes*15
+	for i := 0; i < causes; i++ {
+		label := fmt.Sprintf("onTapGesture %d", i)
+		if i%10 == 0 {
+			label = fmt.Sprintf("Timer.publish %d", i)
+		}
+		gr.UpsertNode(&graph.Node{ID: fmt.Sprintf("c%d", i), Label: label, Type: graph.NodeCause, Count: 20 + i%50})
+		for j := 0; j < 4; j++ {
+			gr.AddEdge(graph.Edge{From: fmt.Sprintf("c%d", i), To: fmt.Sprintf("s%d", i*4+j)})
+		}
+	}
+	for i := 0; i < states; i++ {
+		gr.UpsertNode(&graph.Node{ID: fmt.Sprintf("s%d", i), Label: fmt.Sprintf("@ObservedObject model%d", i), Type: graph.NodeState, Count: 20})
+		for j := 0; j < 4; j++ {
+			gr.AddEdge(graph.Edge{From: fmt.Sprintf("s%d", i), To: fmt.Sprintf("v%d", (i*3+j*5)%views)})
+		}
+	}
+	for i := 0; i < views; i++ {
+		gr.UpsertNode(&graph.Node{ID: fmt.Sprintf("v%d", i), Label: fmt.Sprintf("Row%dView", i), Type: graph.NodeView, Count: 5 + i%40})
+		if i%5 != 4 {
+			gr.AddEdge(graph.Edge{From: fmt.Sprintf("v%d", i), To: fmt.Sprintf("v%d", i+1)})
+		}
+	}
+	return gr
+}
+
+// BenchmarkGenerate_LargeGraph guards the cost of ranking fixes by payoff,
+// which simulates fixes issue by issue, on a graph of about 2000 nodes
+func BenchmarkGenerate_LargeGraph(b *testing.B) {
+	gen, _ := NewGenerator("")
+	gr := largeGraph(100)
+	for i := 0; i < b.N; i++ {
+		gen.Generate(gr, GenerateOptions{})
+	}
 }
@@ -0,0 +1,555 @@
+package aioutput
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/correlation"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/suggestions"
+)
+
+// BytesPerToken is the ratio used to estimate tokens from JSON size. Real
+// tokenizers average closer to four bytes per token on prose; JSON keys,
+// quotes and braces tokenize worse, so three keeps the estimate on the safe
+// side of a budget.
+const BytesPerToken = 3
+
+// graphSteps are the node limits tried, in order, when the graph must shrink;
+// nodes affected by a kept issue are always kept on top of the limit.
+var graphSteps = []int{200, 100, 50, 25, 10, 0}
+
+// ErrBudgetTooSmall is returned by Fit when even the summary does not fit
+var ErrBudgetTooSmall = errors.New("token budget too small for the report summary")
+
+// Budget records the token budget a report was fitted to
+type Budget struct {
+	MaxTokens       int `json:"max_tokens"`
+	EstimatedTokens int `json:"estimated_tokens"`
+}
+
+// Elision records content left out of a budgeted report and where to get it
+type Elision struct {
+	Section  string   `json:"section"`
+	Omitted  int      `json:"omitted"`
+	Reason   string   `json:"reason"`
+	Retrieve string   `json:"retrieve"`
+	IDs      []string `json:"ids,omitempty"` // omitted issue IDs
+}
+
+// EstimateTokens estimates the tokens an LLM needs to read data
+func EstimateTokens(data []byte) int {
+	return (len(data) + BytesPerToken - 1) / BytesPerToken
+}
+
+// Fit returns a copy of the report whose JSON (compact or indented, as it
+// will be written) is estimated at no more than maxTokens. Content is shed
+// least valuable first: extra source matches, the hierarchy, instance
+// detail, fix code, the edits behind fix estimates, plan steps, source
+// patches, low-count graph nodes, alternative fixes and finally the least
+// severe issues with their plan tasks. Each step is recorded in
+// Elisions. The report is returned unchanged (but with Budget set) if it
+// already fits.
+func (r *Report) Fit(maxTokens int, compact bool) (*Report, error) {
+	if maxTokens <= 0 {
+		return nil, fmt.Errorf("max tokens must be positive, got %d", maxTokens)
+	}
+	f := &fitter{max: maxTokens, compact: compact}
+	out, err := r.clone()
+	if err != nil {
+		return nil, err
+	}
+	f.r = out
+	sortBySeverity(out.Issues)
+
+	steps := []func(){
+		f.bestMatchOnly,
+		f.dropHierarchy,
+		f.dropInstances,
+		func() { f.dropFixCode(false) },
+		func() { f.dropFixCode(true) },
+		f.dropEstimateEdits,
+		f.dropPlanSteps,
+		f.dropPatches,
+		f.dropSnippets,
+	}
+	if f.fits() {
+		return f.finish()
+	}
+	for _, step := range steps {
+		step()
+		if f.fits() {
+			return f.finish()
+		}
+	}
+	full := out.Graph
+	f.refs = issues.NewResolver(full.ToGraph())
+	for _, n := range graphSteps {
+		f.trimGraph(full, n)
+		if f.fits() {
+			return f.finish()
+		}
+	}
+	f.firstFixOnly()
+	if f.fits() {
+		return f.finish()
+	}
+	if f.dropIssues(full) {
+		return f.finish()
+	}
+	f.dropGuidance()
+	if f.fits() {
+		return f.finish()
+	}
+	return nil, fmt.Errorf("%w: %d tokens needed, budget is %d", ErrBudgetTooSmall, f.size(), maxTokens)
+}
+
+type fitter struct {
+	r       *Report
+	max     int
+	compact bool
+	refs    *issues.Resolver // resolves issue nodes in the full graph
+}
+
+func (r *Report) clone() (*Report, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("marshal report: %w", err)
+	}
+	var out Report
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("copy report: %w", err)
+	}
+	return &out, nil
+}
+
+// size estimates the tokens of the report as it would be written, with the
+// budget filled in at its widest so finishing cannot push it over.
+func (f *fitter) size() int {
+	f.r.Budget = &Budget{MaxTokens: f.max, EstimatedTokens: f.max}
+	return f.measure()
+}
+
+// measure estimates the tokens of the report as it stands
+func (f *fitter) measure() int {
+	var data []byte
+	var err error
+	if f.compact {
+		data, err = json.Marshal(f.r)
+	} else {
+		data, err = json.MarshalIndent(f.r, "", "  ")
+	}
+	if err != nil {
+		return f.max + 1
+	}
+	return EstimateTokens(data)
+}
+
+func (f *fitter) fits() bool {
+	return f.size() <= f.max
+}
+
+func (f *fitter) finish() (*Report, error) {
+	// The estimate is part of what it counts: a shorter number than the
+	// maximum can shrink the report, so settle on one that counts itself
+	est := f.size()
+	for i := 0; i < 4; i++ {
+		f.r.Budget = &Budget{MaxTokens: f.max, EstimatedTokens: est}
+		got := f.measure()
+		if got == est {
+			break
+		}
+		est = got
+	}
+	return f.r, nil
+}
+
+// elide records (or updates) the elision of a section
+func (f *fitter) elide(e Elision) {
+	for i := range f.r.Elisions {
+		if f.r.Elisions[i].Section == e.Section {
+			e.Omitted += f.r.Elisions[i].Omitted
+			e.IDs = append(f.r.Elisions[i].IDs, e.IDs...)
+			f.r.Elisions[i] = e
+			return
+		}
+	}
+	f.r.Elisions = append(f.r.Elisions, e)
+}
+
+func (f *fitter) bestMatchOnly() {
+	best := map[string]int{}
+	for i, m := range f.r.SourceCorrelations {
+		if j, ok := best[m.TraceNodeID]; !ok || m.Confidence > f.r.SourceCorrelations[j].Confidence {
+			best[m.TraceNodeID] = i
+		}
+	}
+	var kept []correlation.SourceMatch
+	for i, m := range f.r.SourceCorrelations {
+		if best[m.TraceNodeID] == i {
+			kept = append(kept, m)
+		}
+	}
+	if n := len(f.r.SourceCorrelations) - len(kept); n > 0 {
+		f.r.SourceCorrelations = kept
+		f.elide(Elision{Section: "source_correlations", Omitted: n,
+			Reason:   "kept the most confident match per node",
+			Retrieve: "find_source_for_node (swiftuice mcp)"})
+	}
+}
+
+func (f *fitter) dropHierarchy() {
+	if n := len(f.r.Hierarchy); n > 0 {
+		f.r.Hierarchy = nil
+		f.elide(Elision{Section: "hierarchy", Omitted: n,
+			Reason:   "view hierarchy rollups dropped",
+			Retrieve: "analyze without -max-tokens"})
+	}
+}
+
+func (f *fitter) dropInstances() {
+	n := len(f.r.Graph.Containment)
+	for i := range f.r.Graph.Nodes {
+		nd := &f.r.Graph.Nodes[i]
+		n += len(nd.Instances)
+		nd.Instances = nil
+		nd.InstanceStats = nil
+	}
+	f.r.Graph.Containment = nil
+	if n > 0 {
+		f.elide(Elision{Section: "graph.instances", Omitted: n,
+			Reason:   "collapsed instances and containment edges dropped; instance_count is kept",
+			Retrieve: "query_graph_paths (swiftuice mcp) or analyze without -max-tokens"})
+	}
+}
+
+// dropFixCode removes code samples from the fixes of issues below high
+// severity, or of all issues
+func (f *fitter) dropFixCode(all bool) {
+	n := 0
+	for i := range f.r.Issues {
+		is := &f.r.Issues[i]
+		if !all && is.Severity.AtLeast(issues.SeverityHigh) {
+			continue
+		}
+		for j := range is.SuggestedFixes {
+			fix := &is.SuggestedFixes[j]
+			if fix.CodeBefore != "" || fix.CodeAfter != "" {
+				fix.CodeBefore, fix.CodeAfter = "", ""
+				n++
+			}
+		}
+	}
+	if n > 0 {
+		f.elide(Elision{Section: "issues.suggested_fixes.code", Omitted: n,
+			Reason:   "code samples dropped, least severe issues first; approach and steps are kept",
+			Retrieve: "get_issue_detail (swiftuice mcp)"})
+	}
+}
+
+// dropEstimateEdits keeps each fix's expected savings but not the graph
+// edits and assumption they were simulated from
+func (f *fitter) dropEstimateEdits() {
+	n := 0
+	for i := range f.r.Issues {
+		for j := range f.r.Issues[i].SuggestedFixes {
+			if im := f.r.Issues[i].SuggestedFixes[j].Expected; im != nil && (im.Edits != nil || im.Assumption != "") {
+				im.Edits, im.Assumption = nil, ""
+				n++
+			}
+		}
+	}
+	if n > 0 {
+		f.elide(Elision{Section: "issues.suggested_fixes.expected.edits", Omitted: n,
+			Reason:   "simulated graph edits and assumptions dropped; expected savings are kept",
+			Retrieve: "get_issue_detail (swiftuice mcp)"})
+	}
+}
+
+// dropPlanSteps keeps the plan's tasks but not the steps they copy from
+// their chosen fix
+func (f *fitter) dropPlanSteps() {
+	if f.r.Plan == nil {
+		return
+	}
+	n := 0
+	for i := range f.r.Plan.Tasks {
+		if f.r.Plan.Tasks[i].Steps != nil {
+			f.r.Plan.Tasks[i].Steps = nil
+			n++
+		}
+	}
+	if n > 0 {
+		f.elide(Elision{Section: "plan.tasks.steps", Omitted: n,
+			Reason:   "task steps dropped; each task names its fix",
+			Retrieve: "swiftuice plan"})
+	}
+}
+
+func (f *fitter) dropPatches() {
+	var ids []string
+	for i := range f.r.Issues {
+		if f.r.Issues[i].Patch != nil {
+			f.r.Issues[i].Patch = nil
+			ids = append(ids, f.r.Issues[i].ID)
+		}
+	}
+	if len(ids) > 0 {
+		f.elide(Elision{Section: "issues.patch", Omitted: len(ids),
+			Reason:   "source patches dropped",
+			Retrieve: "swiftuice fix -issue <id> -dry-run",
+			IDs:      ids})
+	}
+}
+
+func (f *fitter) dropSnippets() {
+	n := 0
+	for i := range f.r.SourceCorrelations {
+		if f.r.SourceCorrelations[i].CodeSnippet != "" {
+			f.r.SourceCorrelations[i].CodeSnippet = ""
+			n++
+		}
+	}
+	if n > 0 {
+		f.elide(Elision{Section: "source_correlations.code_snippet", Omitted: n,
+			Reason:   "matched source lines dropped; file and line are kept",
+			Retrieve: "find_source_for_node (swiftuice mcp)"})
+	}
+}
+
+// trimGraph keeps the nodes affected by the remaining issues plus the limit
+// busiest others, and the edges between kept nodes.
+func (f *fitter) trimGraph(full GraphData, limit int) {
+	keep := map[string]bool{}
+	remaining := make([]issues.Issue, len(f.r.Issues))
+	for i, is := range f.r.Issues {
+		remaining[i] = is.Issue
+	}
+	for _, id := range f.refs.AffectedNodeIDs(remaining) {
+		keep[id] = true
+	}
+	rest := make([]NodeData, 0, len(full.Nodes))
+	for _, n := range full.Nodes {
+		if !keep[n.ID] {
+			rest = append(rest, n)
+		}
+	}
+	sort.SliceStable(rest, func(i, j int) bool { return rest[i].UpdateCount > rest[j].UpdateCount })
+	for i := 0; i < limit && i < len(rest); i++ {
+		keep[rest[i].ID] = true
+	}
+
+	g := GraphData{Nodes: []NodeData{}, Edges: []EdgeData{}}
+	for _, n := range full.Nodes {
+		if keep[n.ID] {
+			g.Nodes = append(g.Nodes, n)
+		}
+	}
+	for _, e := range full.Edges {
+		if keep[e.From] && keep[e.To] {
+			g.Edges = append(g.Edges, e)
+		}
+	}
+	f.r.Graph = g
+
+	f.setElision(Elision{Section: "graph.nodes", Omitted: len(full.Nodes) - len(g.Nodes),
+		Reason:   "kept nodes affected by issues and the busiest others",
+		Retrieve: "query_graph_paths (swiftuice mcp) or swiftuice query"})
+	f.setElision(Elision{Section: "graph.edges", Omitted: len(full.Edges) - len(g.Edges),
+		Reason:   "kept edges between kept nodes",
+		Retrieve: "query_graph_paths (swiftuice mcp) or swiftuice query"})
+}
+
+// setElision records an elision whose count replaces any earlier one
+func (f *fitter) setElision(e Elision) {
+	for i := range f.r.Elisions {
+		if f.r.Elisions[i].Section == e.Section {
+			if e.Omitted == 0 {
+				f.r.Elisions = append(f.r.Elisions[:i], f.r.Elisions[i+1:]...)
+			} else {
+				f.r.Elisions[i] = e
+			}
+			return
+		}
+	}
+	if e.Omitted > 0 {
+		f.r.Elisions = append(f.r.Elisions, e)
+	}
+}
+
+func (f *fitter) firstFixOnly() {
+	n := 0
+	for i := range f.r.Issues {
+		if fixes := f.r.Issues[i].SuggestedFixes; len(fixes) > 1 {
+			n += len(fixes) - 1
+			f.r.Issues[i].SuggestedFixes = fixes[:1]
+		}
+	}
+	if n > 0 {
+		f.elide(Elision{Section: "issues.suggested_fixes", Omitted: n,
+			Reason:   "kept the first (recommended) fix per issue",
+			Retrieve: "get_issue_detail (swiftuice mcp)"})
+	}
+}
+
+// dropIssues drops the least severe issues, with the graph nodes only they
+// keep, until the report fits or none are left. Each round measures the
+// report once and drops as many issues as the estimated sizes of what goes
+// with them say it takes to make up the difference.
+func (f *fitter) dropIssues(full GraphData) bool {
+	sizes := f.issueSizes(full)
+	for len(f.r.Issues) > 0 {
+		over := (f.size() - f.max) * BytesPerToken
+		if over <= 0 {
+			return true
+		}
+		for saved := 0; len(f.r.Issues) > 0 && saved < over; {
+			saved += sizes[len(f.r.Issues)-1]
+			f.dropLastIssue()
+		}
+		f.trimGraph(full, 0)
+	}
+	return f.fits()
+}
+
+// issueSizes estimates the bytes dropping each issue saves, in order,
+// when issues are dropped from the last: its own entry, its plan entries,
+// and the graph nodes and edges no more severe issue keeps. What the
+// elision of its ID adds is taken off.
+func (f *fitter) issueSizes(full GraphData) []int {
+	sizes := make([]int, len(f.r.Issues))
+	index := make(map[string]int, len(f.r.Issues))
+	owner := map[string]int{} // node ID -> the most severe issue keeping it
+	for i, is := range f.r.Issues {
+		index[is.ID] = i
+		sizes[i] = f.bytes(is, 2) - f.bytes(is.ID, 3)
+		for _, id := range f.refs.AffectedNodeIDs([]issues.Issue{is.Issue}) {
+			if _, ok := owner[id]; !ok {
+				owner[id] = i
+			}
+		}
+	}
+	for _, n := range full.Nodes {
+		if i, ok := owner[n.ID]; ok {
+			sizes[i] += f.bytes(n, 3)
+		}
+	}
+	for _, e := range full.Edges {
+		from, ok := owner[e.From]
+		to, ok2 := owner[e.To]
+		if ok && ok2 {
+			sizes[max(from, to)] += f.bytes(e, 3)
+		}
+	}
+	if p := f.r.Plan; p != nil {
+		for _, t := range p.Tasks {
+			// A task goes with the last of its issues to be dropped
+			most := -1
+			for _, id := range t.IssueIDs {
+				if i, ok := index[id]; ok && (most < 0 || i < most) {
+					most = i
+				}
+			}
+			if most >= 0 {
+				sizes[most] += f.bytes(t, 3)
+			}
+		}
+		for _, u := range p.Unplanned {
+			if i, ok := index[u.IssueID]; ok {
+				sizes[i] += f.bytes(u, 3)
+			}
+		}
+	}
+	return sizes
+}
+
+// bytes estimates the bytes v takes up as an element of a report array
+// nested depth levels deep
+func (f *fitter) bytes(v any, depth int) int {
+	if f.compact {
+		data, _ := json.Marshal(v)
+		return len(data) + 1
+	}
+	indent := strings.Repeat("  ", depth)
+	data, _ := json.MarshalIndent(v, indent, "  ")
+	return len(indent) + len(data) + 2
+}
+
+// dropLastIssue drops the least severe remaining issue; Summary still
+// counts every issue found.
+func (f *fitter) dropLastIssue() {
+	last := f.r.Issues[len(f.r.Issues)-1]
+	f.r.Issues = f.r.Issues[:len(f.r.Issues)-1]
+	f.elide(Elision{Section: "issues", Omitted: 1,
+		Reason:   "least severe issues dropped; summary counts include them",
+		Retrieve: "list_issues and get_issue_detail (swiftuice mcp)",
+		IDs:      []string{last.ID}})
+	f.dropPlanIssue(last.ID)
+}
+
+// dropPlanIssue takes a dropped issue out of the plan, removing tasks left
+// without issues and dependencies on them
+func (f *fitter) dropPlanIssue(id string) {
+	p := f.r.Plan
+	if p == nil {
+		return
+	}
+	removed := map[string]bool{}
+	tasks := p.Tasks[:0]
+	for _, t := range p.Tasks {
+		ids := t.IssueIDs[:0]
+		for _, is := range t.IssueIDs {
+			if is != id {
+				ids = append(ids, is)
+			}
+		}
+		t.IssueIDs = ids
+		if len(ids) == 0 {
+			removed[t.ID] = true
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+	p.Tasks = tasks
+	for i := range p.Tasks {
+		deps := p.Tasks[i].DependsOn[:0]
+		for _, d := range p.Tasks[i].DependsOn {
+			if !removed[d] {
+				deps = append(deps, d)
+			}
+		}
+		if len(deps) == 0 {
+			deps = nil
+		}
+		p.Tasks[i].DependsOn = deps
+	}
+	unplanned := p.Unplanned[:0]
+	for _, u := range p.Unplanned {
+		if u.IssueID != id {
+			unplanned = append(unplanned, u)
+		}
+	}
+	if len(unplanned) == 0 {
+		unplanned = nil
+	}
+	p.Unplanned = unplanned
+}
+
+func (f *fitter) dropGuidance() {
+	n := len(f.r.Recommendations)
+	f.r.Recommendations = []suggestions.Recommendation{}
+	f.r.AgentInstructions = AgentInstructions{TaskDescription: f.r.AgentInstructions.TaskDescription}
+	f.r.Plan = nil
+	f.elide(Elision{Section: "recommendations", Omitted: n,
+		Reason:   "recommendations, agent instruction lists and the plan dropped",
+		Retrieve: "analyze without -max-tokens"})
+}
+
+// sortBySeverity orders issues most severe first, busiest first within a
+// severity, so budget trimming drops from the end.
+func sortBySeverity(list []IssueWithFixes) {
+	issues.SortBySeverity(list, func(is IssueWithFixes) (issues.Severity, int) { return is.Severity, is.UpdateCount })
+}
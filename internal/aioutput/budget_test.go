@@ -0,0 +1,169 @@
+package aioutput
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+)
+
+// budgetReport builds a report with many nodes, issues of several
+// severities and source correlations
+func budgetReport(t *testing.T) *Report {
+	t.Helper()
+	root := t.TempDir()
+	gr := graph.New()
+	gr.UpsertNode(&graph.Node{ID: "c1", Label: "Timer", Type: graph.NodeCause, Count: 60})
+	gr.UpsertNode(&graph.Node{ID: "s1", Label: "@State items", Type: graph.NodeState})
+	gr.AddEdge(graph.Edge{From: "c1", To: "s1"})
+	for i := 0; i < 60; i++ {
+		name := fmt.Sprintf("Row%dView", i)
+		gr.UpsertNode(&graph.Node{ID: fmt.Sprintf("v%d", i), Label: name, Type: graph.NodeView, Count: 5 + i})
+		gr.AddEdge(graph.Edge{From: "s1", To: fmt.Sprintf("v%d", i)})
+		src := fmt.Sprintf("struct %s: View {\n    var body: some View { Text(\"%s\") }\n}\n", name, name)
+		if err := os.WriteFile(filepath.Join(root, name+".swift"), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gen, err := NewGenerator(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gen.Generate(gr, GenerateOptions{SourceRoot: root})
+}
+
+func TestFit_AlreadyFits(t *testing.T) {
+	r := budgetReport(t)
+	fitted, err := r.Fit(1_000_000, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fitted.Elisions) != 0 || len(fitted.Graph.Nodes) != len(r.Graph.Nodes) || len(fitted.Issues) != len(r.Issues) {
+		t.Errorf("expected nothing elided, got %+v", fitted.Elisions)
+	}
+	if fitted.Budget == nil || fitted.Budget.MaxTokens != 1_000_000 || fitted.Budget.EstimatedTokens == 0 {
+		t.Errorf("unexpected budget %+v", fitted.Budget)
+	}
+	if r.Budget != nil {
+		t.Error("Fit must not modify the original report")
+	}
+}
+
+func TestFit_UnderBudget(t *testing.T) {
+	r := budgetReport(t)
+	data, _ := json.MarshalIndent(r, "", "  ")
+	full := EstimateTokens(data)
+
+	for _, compact := range []bool{false, true} {
+		for _, max := range []int{full / 2, full / 4, full / 10} {
+			fitted, err := r.Fit(max, compact)
+			if err != nil {
+				t.Fatalf("max %d: %v", max, err)
+			}
+			var out []byte
+			if compact {
+				out, _ = json.Marshal(fitted)
+			} else {
+				out, _ = json.MarshalIndent(fitted, "", "  ")
+			}
+			if got := EstimateTokens(out); got > max || got != fitted.Budget.EstimatedTokens {
+				t.Errorf("max %d compact %v: estimated %d tokens, budget says %d", max, compact, got, fitted.Budget.EstimatedTokens)
+			}
+			var back Report
+			if err := json.Unmarshal(out, &back); err != nil {
+				t.Fatalf("max %d: invalid JSON: %v", max, err)
+			}
+			if len(back.Elisions) == 0 {
+				t.Errorf("max %d: expected elisions", max)
+			}
+			if back.Summary.IssuesFound != r.Summary.IssuesFound {
+				t.Errorf("max %d: summary must keep counting every issue", max)
+			}
+			for i := 1; i < len(back.Issues); i++ {
+				if !back.Issues[i-1].Severity.AtLeast(back.Issues[i].Severity) {
+					t.Errorf("max %d: issues not ordered by severity", max)
+				}
+			}
+			if len(back.Issues) > 0 && r.Summary.CriticalIssues > 0 && back.Issues[0].Severity != issues.SeverityCritical {
+				t.Errorf("max %d: critical issues must be kept first", max)
+			}
+			if back.Plan != nil {
+				kept := map[string]bool{}
+				for _, is := range back.Issues {
+					kept[is.ID] = true
+				}
+				for _, task := range back.Plan.Tasks {
+					for _, id := range task.IssueIDs {
+						if !kept[id] {
+							t.Errorf("max %d: plan task %s refers to dropped issue %s", max, task.ID, id)
+						}
+					}
+					for _, id := range task.DependsOn {
+						if !kept[id] {
+							t.Errorf("max %d: plan task %s depends on dropped task %s", max, task.ID, id)
+						}
+					}
+					kept[task.ID] = true
+				}
+			}
+		}
+	}
+}
+
+func TestFit_ElisionOrder(t *testing.T) {
+	r := budgetReport(t)
+	data, _ := json.MarshalIndent(r, "", "  ")
+	fitted, err := r.Fit(EstimateTokens(data)*3/4, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, e := range fitted.Elisions {
+		seen[e.Section] = true
+		if e.Omitted <= 0 || e.Retrieve == "" {
+			t.Errorf("incomplete elision %+v", e)
+		}
+	}
+	if !seen["source_correlations"] {
+		t.Errorf("expected duplicate source matches to go first, got %+v", fitted.Elisions)
+	}
+	if seen["issues"] {
+		t.Errorf("issues dropped before cheaper content: %+v", fitted.Elisions)
+	}
+	matched := map[string]bool{}
+	for _, m := range fitted.SourceCorrelations {
+		if matched[m.TraceNodeID] {
+			t.Errorf("more than one match kept for %s", m.TraceNodeID)
+		}
+		matched[m.TraceNodeID] = true
+	}
+}
+
+func TestFit_TooSmall(t *testing.T) {
+	if _, err := budgetReport(t).Fit(50, true); !errors.Is(err, ErrBudgetTooSmall) {
+		t.Errorf("expected ErrBudgetTooSmall, got %v", err)
+	}
+	if _, err := budgetReport(t).Fit(0, true); err == nil {
+		t.Error("expected an error for a zero budget")
+	}
+}
+
+// BenchmarkFit_ManyIssues guards the cost of dropping issues to fit, which
+// must not re-measure the report for every issue
+func BenchmarkFit_ManyIssues(b *testing.B) {
+	gen, _ := NewGenerator("")
+	r := gen.Generate(largeGraph(100), GenerateOptions{})
+	data, _ := json.Marshal(r)
+	max := EstimateTokens(data) / 20
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.Fit(max, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,420 @@
+package aioutput
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/simulate"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/suggestions"
+)
+
+// Plan is an ordered list of tasks for an agent fixing the report's issues
+type Plan struct {
+	Tasks     []Task      `json:"tasks"`
+	Unplanned []Unplanned `json:"unplanned,omitempty"` // issues no task covers
+	Rerecord  []string    `json:"rerecord"`            // commands to measure the fixes
+}
+
+// Task is one fix applied to one or more issues
+type Task struct {
+	ID            string       `json:"id"`
+	Title         string       `json:"title"`
+	IssueIDs      []string     `json:"issue_ids"`
+	Nodes         []string     `json:"nodes"` // node IDs the issues and the fix touch
+	Targets       []Location   `json:"targets,omitempty"`
+	Fix           string       `json:"fix"`
+	Approach      string       `json:"approach"`
+	Steps         []string     `json:"steps,omitempty"`
+	Patch         string       `json:"patch,omitempty"` // command previewing the generated source patch
+	Preconditions []string     `json:"preconditions,omitempty"`
+	DependsOn     []string     `json:"depends_on,omitempty"` // tasks touching the same nodes or files
+	Expected      string       `json:"expected,omitempty"`
+	Verify        Verification `json:"verify"`
+}
+
+// Location is a source position a task edits
+type Location struct {
+	File string `json:"file"`
+	Line int    `json:"line,omitempty"`
+	Node string `json:"node,omitempty"` // label of the node found there
+}
+
+// Verification says how to tell a task worked after re-recording
+type Verification struct {
+	Views    []ViewCheck `json:"views,omitempty"` // largest drop first
+	Resolves bool        `json:"resolves_issues"` // whether the estimate clears the issues
+	Score    int         `json:"score,omitempty"` // expected performance score afterwards
+}
+
+// ViewCheck is a view whose update count the task should lower
+type ViewCheck struct {
+	Node     string `json:"node"`
+	Label    string `json:"label"`
+	Before   int    `json:"before"`
+	Expected *int   `json:"expected,omitempty"` // simulated count, when the fix has an estimate
+}
+
+// Unplanned is an issue left out of the plan and why
+type Unplanned struct {
+	IssueID string `json:"issue_id"`
+	Reason  string `json:"reason"`
+}
+
+// maxViewChecks caps the views a task's verification lists
+const maxViewChecks = 5
+
+// BuildPlan turns the report's issues into ordered tasks. Each issue gets
+// its best usable fix; issues sharing a fix on overlapping nodes become one
+// task, and a task depends on every earlier one touching the same nodes or
+// files. Tasks are ordered by the payoff of their fix, then by severity.
+func BuildPlan(r *Report) *Plan {
+	g := r.Graph.ToGraph()
+	refs := issues.NewResolver(g)
+	nodes := make(map[string]NodeData, len(r.Graph.Nodes))
+	for _, n := range r.Graph.Nodes {
+		nodes[n.ID] = n
+	}
+
+	type candidate struct {
+		issue IssueWithFixes
+		fix   suggestions.Fix
+	}
+	var candidates []candidate
+	plan := &Plan{Tasks: []Task{}, Rerecord: rerecordSteps(r)}
+	for _, is := range r.Issues {
+		fix, reason := chooseFix(is)
+		if reason != "" {
+			plan.Unplanned = append(plan.Unplanned, Unplanned{IssueID: is.ID, Reason: reason})
+			continue
+		}
+		candidates = append(candidates, candidate{is, fix})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if p := simulate.Payoff(candidates[i].fix.Expected, candidates[j].fix.Expected); p != 0 {
+			return p > 0
+		}
+		a, b := candidates[i].issue.Severity, candidates[j].issue.Severity
+		return a != b && a.AtLeast(b)
+	})
+
+	edits := map[string][]simulate.Edit{} // per task ID
+	for _, c := range candidates {
+		touched := touchedNodes(refs, c.issue, c.fix)
+		merged := false
+		for i := range plan.Tasks {
+			t := &plan.Tasks[i]
+			if t.Fix == c.fix.ID && overlaps(t.Nodes, touched) {
+				t.IssueIDs = append(t.IssueIDs, c.issue.ID)
+				t.Nodes = union(t.Nodes, touched)
+				t.Targets = addLocations(t.Targets, targets(c.issue, touched, nodes)...)
+				edits[t.ID] = append(edits[t.ID], fixEdits(c.fix)...)
+				merged = true
+				break
+			}
+		}
+		if merged {
+			continue
+		}
+		t := Task{
+			ID:            fmt.Sprintf("task-%d", len(plan.Tasks)+1),
+			Title:         fmt.Sprintf("%s: %s", primaryLabel(c.issue, nodes), c.fix.Approach),
+			IssueIDs:      []string{c.issue.ID},
+			Nodes:         touched,
+			Targets:       targets(c.issue, touched, nodes),
+			Fix:           c.fix.ID,
+			Approach:      c.fix.Approach,
+			Steps:         c.fix.Steps,
+			Preconditions: preconditions(c.fix),
+		}
+		if c.fix.Expected != nil {
+			t.Expected = c.fix.Expected.String()
+			t.Verify.Resolves = c.fix.Expected.ResolvesIssue
+			t.Verify.Score = c.fix.Expected.After.Score
+		}
+		if c.issue.Patch != nil && c.issue.Patch.FixID == c.fix.ID {
+			t.Patch = fmt.Sprintf("swiftuice fix -in <report> -issue %s -fix %s -dry-run", c.issue.ID, c.fix.ID)
+		}
+		edits[t.ID] = fixEdits(c.fix)
+		plan.Tasks = append(plan.Tasks, t)
+	}
+
+	for i := range plan.Tasks {
+		t := &plan.Tasks[i]
+		t.Verify.Views = viewChecks(g, t.Nodes, edits[t.ID])
+		for _, earlier := range plan.Tasks[:i] {
+			if overlaps(earlier.Nodes, t.Nodes) || sharesFile(earlier.Targets, t.Targets) {
+				t.DependsOn = append(t.DependsOn, earlier.ID)
+			}
+		}
+	}
+	return plan
+}
+
+// chooseFix returns the issue's first fix the deployment target allows,
+// or why there is none
+func chooseFix(is IssueWithFixes) (suggestions.Fix, string) {
+	if len(is.SuggestedFixes) == 0 {
+		return suggestions.Fix{}, "no fix in the catalog applies"
+	}
+	for _, f := range is.SuggestedFixes {
+		if f.Availability == nil || f.Availability.Compatible {
+			return f, ""
+		}
+	}
+	a := is.SuggestedFixes[0].Availability
+	return suggestions.Fix{}, fmt.Sprintf("every fix needs a newer deployment target (%s: %s)", a.Requires, a.Reason)
+}
+
+// touchedNodes are the issue's affected nodes plus those its fix's
+// simulated edits change. Cause chains are left out: issues commonly share
+// a cause without their fixes interfering.
+func touchedNodes(refs *issues.Resolver, is IssueWithFixes, fix suggestions.Fix) []string {
+	ids := refs.AffectedNodeIDs([]issues.Issue{{AffectedNodes: is.AffectedNodes}})
+	for _, e := range fixEdits(fix) {
+		for _, id := range []string{e.Node, e.From, e.To} {
+			if id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return union(nil, ids)
+}
+
+func fixEdits(fix suggestions.Fix) []simulate.Edit {
+	if fix.Expected == nil {
+		return nil
+	}
+	return fix.Expected.Edits
+}
+
+// targets locates an issue and the nodes a task touches in source
+func targets(is IssueWithFixes, touched []string, nodes map[string]NodeData) []Location {
+	var out []Location
+	if is.SourceFile != "" {
+		out = addLocations(out, Location{File: is.SourceFile, Line: is.LineNumber, Node: primaryLabel(is, nodes)})
+	}
+	for _, id := range touched {
+		if n, ok := nodes[id]; ok && n.SourceFile != "" {
+			out = addLocations(out, Location{File: n.SourceFile, Line: n.LineNumber, Node: n.Label})
+		}
+	}
+	if is.Patch != nil {
+		for _, f := range is.Patch.Files {
+			out = addLocations(out, Location{File: f.Path})
+		}
+	}
+	return out
+}
+
+// addLocations appends locations not already listed; a file without a line
+// is covered by any location in that file
+func addLocations(list []Location, locs ...Location) []Location {
+	for _, l := range locs {
+		dup := false
+		for _, have := range list {
+			dup = dup || (have.File == l.File && (have.Line == l.Line || l.Line == 0))
+		}
+		if !dup {
+			list = append(list, l)
+		}
+	}
+	return list
+}
+
+func primaryLabel(is IssueWithFixes, nodes map[string]NodeData) string {
+	if len(is.AffectedNodes) == 0 {
+		return is.Title
+	}
+	if n, ok := nodes[is.AffectedNodes[0]]; ok {
+		return n.Label
+	}
+	return is.AffectedNodes[0]
+}
+
+// preconditions lists what the fix needs from the project
+func preconditions(fix suggestions.Fix) []string {
+	var req []string
+	if fix.MinIOS != "" {
+		req = append(req, "iOS "+fix.MinIOS)
+	}
+	if fix.SwiftVersion != "" {
+		req = append(req, "Swift "+fix.SwiftVersion)
+	}
+	if len(req) == 0 {
+		return nil
+	}
+	if fix.Availability == nil || fix.Availability.Unchecked() {
+		return []string{fmt.Sprintf("Needs %s; check the deployment target first", strings.Join(req, ", "))}
+	}
+	return []string{fmt.Sprintf("Needs %s (the project's target allows it)", fix.Availability.Requires)}
+}
+
+// viewChecks lists the views a task should make update less often: those
+// whose count drops when the task's edits are simulated, or else the views
+// it touches with their current counts
+func viewChecks(g *graph.Graph, touched []string, edits []simulate.Edit) []ViewCheck {
+	var out []ViewCheck
+	var after *graph.Graph
+	if len(edits) > 0 {
+		after, _ = simulate.Apply(g, dedupeEdits(edits))
+	}
+	if after != nil {
+		for _, n := range g.SortedNodes() {
+			if n.Type != graph.NodeView {
+				continue
+			}
+			if m, ok := after.Nodes[n.ID]; ok && m.Count < n.Count {
+				count := m.Count
+				out = append(out, ViewCheck{Node: n.ID, Label: n.Label, Before: n.Count, Expected: &count})
+			}
+		}
+	}
+	if len(out) == 0 {
+		for _, id := range touched {
+			if n, ok := g.Nodes[id]; ok && n.Type == graph.NodeView {
+				out = append(out, ViewCheck{Node: id, Label: n.Label, Before: n.Count})
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].drop() > out[j].drop() })
+	if len(out) > maxViewChecks {
+		out = out[:maxViewChecks]
+	}
+	return out
+}
+
+func (v ViewCheck) drop() int {
+	if v.Expected == nil {
+		return 0
+	}
+	return v.Before - *v.Expected
+}
+
+// dedupeEdits drops repeated edits, which merged tasks produce when their
+// issues share nodes
+func dedupeEdits(edits []simulate.Edit) []simulate.Edit {
+	seen := map[string]bool{}
+	var out []simulate.Edit
+	for _, e := range edits {
+		if k := e.String(); !seen[k] {
+			seen[k] = true
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// rerecordSteps are the commands that measure the fixes: record the same
+// interaction again and verify the planned issues against it
+func rerecordSteps(r *Report) []string {
+	verify := "swiftuice verify -report <report> -in after.trace"
+	if r.Input.SourceRoot != "" {
+		verify = fmt.Sprintf("swiftuice verify -report <report> -in after.trace -source %s", r.Input.SourceRoot)
+	}
+	return []string{
+		"swiftuice record -app <bundle-id> -out after.trace (repeat the interaction traced before)",
+		verify + " (-task <id> to check one task; exits 4 if the fixes fall short)",
+	}
+}
+
+func overlaps(a, b []string) bool {
+	set := make(map[string]bool, len(a))
+	for _, id := range a {
+		set[id] = true
+	}
+	for _, id := range b {
+		if set[id] {
+			return true
+		}
+	}
+	return false
+}
+
+func sharesFile(a, b []Location) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x.File == y.File {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// union returns the sorted IDs in a or b
+func union(a, b []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, id := range append(append([]string(nil), a...), b...) {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Text renders the plan for a terminal
+func (p *Plan) Text() string {
+	var b strings.Builder
+	if len(p.Tasks) == 0 {
+		b.WriteString("No tasks: nothing in the report has a usable fix.\n")
+	}
+	for i, t := range p.Tasks {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s  %s\n", t.ID, t.Title)
+		fmt.Fprintf(&b, "  issues:   %s\n", strings.Join(t.IssueIDs, ", "))
+		fmt.Fprintf(&b, "  fix:      %s\n", t.Fix)
+		if len(t.DependsOn) > 0 {
+			fmt.Fprintf(&b, "  after:    %s\n", strings.Join(t.DependsOn, ", "))
+		}
+		for _, pre := range t.Preconditions {
+			fmt.Fprintf(&b, "  requires: %s\n", pre)
+		}
+		for _, l := range t.Targets {
+			loc := l.File
+			if l.Line > 0 {
+				loc = fmt.Sprintf("%s:%d", l.File, l.Line)
+			}
+			if l.Node != "" {
+				loc += " (" + l.Node + ")"
+			}
+			fmt.Fprintf(&b, "  edit:     %s\n", loc)
+		}
+		for j, s := range t.Steps {
+			fmt.Fprintf(&b, "  %d. %s\n", j+1, s)
+		}
+		if t.Patch != "" {
+			fmt.Fprintf(&b, "  patch:    %s\n", t.Patch)
+		}
+		if t.Expected != "" {
+			fmt.Fprintf(&b, "  expected: %s\n", t.Expected)
+		}
+		for _, v := range t.Verify.Views {
+			if v.Expected != nil {
+				fmt.Fprintf(&b, "  verify:   %s updates %d → about %d\n", v.Label, v.Before, *v.Expected)
+			} else {
+				fmt.Fprintf(&b, "  verify:   %s updates fewer than %d times\n", v.Label, v.Before)
+			}
+		}
+	}
+	if len(p.Unplanned) > 0 {
+		b.WriteString("\nNot planned:\n")
+		for _, u := range p.Unplanned {
+			fmt.Fprintf(&b, "  %s: %s\n", u.IssueID, u.Reason)
+		}
+	}
+	if len(p.Tasks) > 0 {
+		b.WriteString("\nAfter each task, re-record and verify:\n")
+		for _, s := range p.Rerecord {
+			fmt.Fprintf(&b, "  %s\n", s)
+		}
+	}
+	return b.String()
+}
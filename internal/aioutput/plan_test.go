@@ -0,0 +1,138 @@
+package aioutput
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/simulate"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/suggestions"
+)
+
+// planReport has a model feeding two views and a timer feeding a third,
+// with issues on each view and on the model
+func planReport() *Report {
+	impact := func(gain int, edits ...simulate.Edit) *simulate.Impact {
+		return &simulate.Impact{ScoreGain: gain, Edits: edits}
+	}
+	split := simulate.Edit{Op: simulate.OpSplitState, Node: "model"}
+	return &Report{
+		Graph: GraphData{
+			Nodes: []NodeData{
+				{ID: "tap", Label: "Tap", Type: "cause", UpdateCount: 40},
+				{ID: "timer", Label: "Timer", Type: "cause", UpdateCount: 60},
+				{ID: "model", Label: "Model", Type: "state", UpdateCount: 40, SourceFile: "Model.swift", LineNumber: 3},
+				{ID: "list", Label: "ListView", Type: "view", UpdateCount: 40, SourceFile: "ListView.swift", LineNumber: 5},
+				{ID: "header", Label: "HeaderView", Type: "view", UpdateCount: 40},
+				{ID: "clock", Label: "ClockView", Type: "view", UpdateCount: 60},
+			},
+			Edges: []EdgeData{{From: "tap", To: "model"}, {From: "model", To: "list"}, {From: "model", To: "header"}, {From: "timer", To: "clock"}},
+		},
+		Issues: []IssueWithFixes{
+			{Issue: issues.Issue{ID: "issue-1", Severity: issues.SeverityHigh, AffectedNodes: []string{"list"}},
+				SuggestedFixes: []suggestions.Fix{{ID: "split-state", Approach: "Split state", Expected: impact(10, split)}}},
+			{Issue: issues.Issue{ID: "issue-2", Severity: issues.SeverityHigh, AffectedNodes: []string{"header"}},
+				SuggestedFixes: []suggestions.Fix{{ID: "split-state", Approach: "Split state", Expected: impact(10, split)}}},
+			{Issue: issues.Issue{ID: "issue-3", Severity: issues.SeverityMedium, AffectedNodes: []string{"model"}},
+				SuggestedFixes: []suggestions.Fix{{ID: "derived-state", Approach: "Derive state", Steps: []string{"Compute it"}}}},
+			{Issue: issues.Issue{ID: "issue-4", Severity: issues.SeverityCritical, AffectedNodes: []string{"clock"}},
+				SuggestedFixes: []suggestions.Fix{{ID: "observable-macro", Approach: "Use @Observable", MinIOS: "17.0",
+					Availability: &suggestions.Availability{Requires: "iOS 17.0", Reason: "targets iOS 15.0"}}}},
+			{Issue: issues.Issue{ID: "issue-5", Severity: issues.SeverityLow, AffectedNodes: []string{"clock"}},
+				SuggestedFixes: []suggestions.Fix{{ID: "equatable-view", Approach: "Make it equatable", MinIOS: "13.0",
+					Expected: impact(3, simulate.Edit{Op: simulate.OpScale, Node: "clock", Factor: 0.5})}}},
+		},
+	}
+}
+
+func TestBuildPlan(t *testing.T) {
+	p := BuildPlan(planReport())
+
+	if len(p.Tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got %+v", p.Tasks)
+	}
+	split, equatable, derived := p.Tasks[0], p.Tasks[1], p.Tasks[2]
+	if split.Fix != "split-state" || strings.Join(split.IssueIDs, ",") != "issue-1,issue-2" {
+		t.Errorf("issues sharing a fix on the same state should be one task, got %+v", split)
+	}
+	if equatable.Fix != "equatable-view" || derived.Fix != "derived-state" {
+		t.Errorf("tasks should be ordered by payoff, got %s then %s", equatable.Fix, derived.Fix)
+	}
+	if len(equatable.DependsOn) != 0 || strings.Join(derived.DependsOn, ",") != "task-1" {
+		t.Errorf("only the task touching the split model depends on it, got %v and %v", equatable.DependsOn, derived.DependsOn)
+	}
+	if len(p.Unplanned) != 1 || p.Unplanned[0].IssueID != "issue-4" || !strings.Contains(p.Unplanned[0].Reason, "iOS 17.0") {
+		t.Errorf("expected the issue with only an unavailable fix left out, got %+v", p.Unplanned)
+	}
+
+	var files []string
+	for _, l := range split.Targets {
+		files = append(files, l.File)
+	}
+	if strings.Join(files, ",") != "ListView.swift,Model.swift" {
+		t.Errorf("expected the split to target the list and the model, got %+v", split.Targets)
+	}
+	if len(equatable.Preconditions) != 1 || !strings.Contains(equatable.Preconditions[0], "check the deployment target") {
+		t.Errorf("expected a precondition on an unknown target, got %v", equatable.Preconditions)
+	}
+
+	if v := equatable.Verify.Views; len(v) != 1 || v[0].Node != "clock" || v[0].Expected == nil || *v[0].Expected != 30 {
+		t.Errorf("expected the clock to drop to about 30 updates, got %+v", v)
+	}
+	if v := derived.Verify.Views; len(v) != 0 {
+		t.Errorf("a fix without an estimate has no views of its own to check, got %+v", v)
+	}
+	if len(p.Rerecord) == 0 || !strings.Contains(p.Rerecord[0], "swiftuice record") {
+		t.Errorf("expected re-record steps, got %v", p.Rerecord)
+	}
+}
+
+func TestGeneratePlan(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"Cart.swift":     "import SwiftUI\n\nfinal class Cart: ObservableObject {\n    @Published var items: [String] = []\n}\n",
+		"CartView.swift": "import SwiftUI\n\nstruct CartView: View {\n    @ObservedObject var cart: Cart\n\n    var body: some View {\n        Text(\"\\(cart.items.count)\")\n    }\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gen, err := NewGenerator(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gr := graph.New()
+	gr.UpsertNode(&graph.Node{ID: "v1", Label: "CartView", Type: graph.NodeView, Count: 50})
+	gr.UpsertNode(&graph.Node{ID: "s1", Label: "@State", Type: graph.NodeState})
+	gr.AddEdge(graph.Edge{From: "s1", To: "v1"})
+
+	report := gen.Generate(gr, GenerateOptions{SourceRoot: dir})
+	if report.Plan == nil {
+		t.Fatal("expected a plan in the report")
+	}
+	planned := map[string]int{}
+	for _, task := range report.Plan.Tasks {
+		for _, id := range task.IssueIDs {
+			planned[id]++
+		}
+		if task.Patch != "" && !strings.Contains(task.Patch, "swiftuice fix") {
+			t.Errorf("unexpected patch command %q", task.Patch)
+		}
+	}
+	for _, u := range report.Plan.Unplanned {
+		planned[u.IssueID]++
+	}
+	for _, is := range report.Issues {
+		if planned[is.ID] != 1 {
+			t.Errorf("%s: planned %d times, want once", is.ID, planned[is.ID])
+		}
+	}
+	text := report.Plan.Text()
+	if !strings.Contains(text, "task-1") || !strings.Contains(text, "CartView.swift") {
+		t.Errorf("expected the first task and its file in the text:\n%s", text)
+	}
+}
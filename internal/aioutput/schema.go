@@ -0,0 +1,219 @@
+package aioutput
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/jsonschema"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/simulate"
+)
+
+// ReportVersion is the report format this swiftuice writes. The minor
+// version grows when fields are added, the major one when fields are
+// renamed or removed; ReadReport migrates older versions.
+//
+// 1.1 is the first version with a published schema. 1.0 reports lack
+// optional sections added since, such as the plan. 1.2 marks views
+// repeated in the hierarchy.
+const ReportVersion = "1.2"
+
+// reportSchema is the JSON Schema of the current report version. It is
+// generated from Report; TestSchemaUpToDate fails when the two drift and
+// rewrites it with -update.
+//
+//go:embed report.schema.json
+var reportSchema []byte
+
+// Schema returns the JSON Schema of the current report version
+func Schema() []byte {
+	return reportSchema
+}
+
+// GenerateSchema derives the report schema from the Report type
+func GenerateSchema() ([]byte, error) {
+	severities := []issues.Severity{issues.SeverityCritical, issues.SeverityHigh, issues.SeverityMedium, issues.SeverityLow, issues.SeverityInfo}
+	r := jsonschema.Reflector{Enums: map[reflect.Type][]string{
+		reflect.TypeOf(issues.Severity("")):  stringsOf(severities),
+		reflect.TypeOf(issues.IssueType("")): stringsOf(issues.DetectorTypes),
+		reflect.TypeOf(simulate.Op("")):      stringsOf(simulate.Ops),
+	}}
+	s := r.Reflect(reflect.TypeOf(Report{}))
+	s["title"] = "swiftuice analysis report"
+	s["description"] = fmt.Sprintf("Report format %s, written by swiftuice analyze", ReportVersion)
+	props := s["properties"].(jsonschema.Schema)
+	props["version"] = jsonschema.Schema{"type": "string", "enum": []any{ReportVersion}}
+	props["tool"] = jsonschema.Schema{"type": "string", "enum": []any{"swiftuice"}}
+	return jsonschema.Marshal(s)
+}
+
+func stringsOf[T ~string](list []T) []string {
+	out := make([]string, len(list))
+	for i, v := range list {
+		out[i] = string(v)
+	}
+	return out
+}
+
+// Validation is the result of checking a report document against the schema
+type Validation struct {
+	Version      string             `json:"version"`
+	MigratedFrom string             `json:"migrated_from,omitempty"` // set when an older report was migrated first
+	Errors       []jsonschema.Error `json:"errors,omitempty"`
+}
+
+// Valid reports whether the document matched the schema
+func (v *Validation) Valid() bool {
+	return len(v.Errors) == 0
+}
+
+// ValidateReport checks a report document against the current schema. An
+// older report is migrated first, so what is checked is what swiftuice
+// would read. The decoded report is returned when the document is valid.
+func ValidateReport(data []byte) (*Validation, *Report, error) {
+	var schema jsonschema.Schema
+	if err := json.Unmarshal(reportSchema, &schema); err != nil {
+		return nil, nil, fmt.Errorf("embedded schema: %w", err)
+	}
+	version, data, steps, err := upgrade(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	res := &Validation{Version: ReportVersion}
+	if version != ReportVersion {
+		res.MigratedFrom = version
+	}
+	if res.Errors, err = jsonschema.Validate(schema, data); err != nil || !res.Valid() {
+		return res, nil, err
+	}
+	r, err := decode(data, steps)
+	return res, r, err
+}
+
+// migration upgrades reports of one version to the next. raw edits the
+// document before it is decoded, for renamed or restructured fields; typed
+// fills in what the new version derives from the rest.
+type migration struct {
+	from, to string
+	raw      func(doc map[string]any)
+	typed    func(r *Report)
+}
+
+var migrations = []migration{
+	{from: "1.0", to: "1.1", typed: func(r *Report) {
+		if r.Plan == nil && r.Budget == nil {
+			r.Plan = BuildPlan(r)
+		}
+	}},
+	{from: "1.1", to: "1.2"},
+}
+
+// decodeReport parses a report document, migrating it to ReportVersion
+func decodeReport(data []byte) (*Report, error) {
+	_, data, steps, err := upgrade(data)
+	if err != nil {
+		return nil, err
+	}
+	return decode(data, steps)
+}
+
+// upgrade applies the raw migrations a report needs to reach
+// ReportVersion. It returns the report's original version, the document
+// as of ReportVersion before the typed migrations, and the migrations.
+func upgrade(data []byte) (string, []byte, []migration, error) {
+	version, err := reportVersion(data)
+	if err != nil || version == ReportVersion {
+		return version, data, nil, err
+	}
+	var steps []migration
+	for v := version; v != ReportVersion; {
+		i := migrationFrom(v)
+		if i < 0 {
+			return "", nil, nil, fmt.Errorf("no migration from report version %s to %s", v, ReportVersion)
+		}
+		steps = append(steps, migrations[i])
+		v = migrations[i].to
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return "", nil, nil, fmt.Errorf("parse report: %w", err)
+	}
+	for _, m := range steps {
+		if m.raw != nil {
+			m.raw(doc)
+		}
+	}
+	doc["version"] = ReportVersion
+	if data, err = json.Marshal(doc); err != nil {
+		return "", nil, nil, fmt.Errorf("migrate report: %w", err)
+	}
+	return version, data, steps, nil
+}
+
+func decode(data []byte, steps []migration) (*Report, error) {
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parse report: %w", err)
+	}
+	for _, m := range steps {
+		if m.typed != nil {
+			m.typed(&r)
+		}
+	}
+	return &r, nil
+}
+
+func migrationFrom(version string) int {
+	for i, m := range migrations {
+		if m.from == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// reportVersion reads a report's version, refusing reports of another tool
+// and versions newer than this swiftuice reads
+func reportVersion(data []byte) (string, error) {
+	var header struct {
+		Tool    any `json:"tool"`
+		Version any `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return "", fmt.Errorf("parse report: %w", err)
+	}
+	version, ok := header.Version.(string)
+	if header.Tool != "swiftuice" || !ok {
+		return "", errNotReport
+	}
+	if newer(version, ReportVersion) {
+		return "", fmt.Errorf("report version %s is newer than this swiftuice reads (%s); upgrade swiftuice", version, ReportVersion)
+	}
+	return version, nil
+}
+
+var errNotReport = errors.New("not a swiftuice report")
+
+// newer reports whether version a is later than b; versions are
+// major.minor
+func newer(a, b string) bool {
+	pa, pb := versionParts(a), versionParts(b)
+	if pa[0] != pb[0] {
+		return pa[0] > pb[0]
+	}
+	return pa[1] > pb[1]
+}
+
+func versionParts(v string) [2]int {
+	var out [2]int
+	for i, p := range strings.SplitN(v, ".", 2) {
+		out[i], _ = strconv.Atoi(p)
+	}
+	return out
+}
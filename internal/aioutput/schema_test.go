@@ -0,0 +1,124 @@
+package aioutput
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+var update = flag.Bool("update", false, "rewrite report.schema.json from the Report type")
+
+func TestSchemaUpToDate(t *testing.T) {
+	generated, err := GenerateSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if err := os.WriteFile("report.schema.json", generated, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	if !bytes.Equal(generated, Schema()) {
+		t.Error("report.schema.json is out of date with Report; run go test ./internal/aioutput -run TestSchemaUpToDate -update, and bump ReportVersion if fields changed")
+	}
+}
+
+func TestValidateReport(t *testing.T) {
+	for name, r := range map[string]*Report{"budget": budgetReport(t), "plain": plainReport()} {
+		data, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, back, err := ValidateReport(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Valid() || back == nil || res.MigratedFrom != "" {
+			t.Errorf("%s: generated report should validate, got %+v", name, res.Errors)
+		}
+	}
+}
+
+func TestValidateReportErrors(t *testing.T) {
+	data, _ := json.Marshal(plainReport())
+	var doc map[string]any
+	json.Unmarshal(data, &doc)
+	doc["summary"].(map[string]any)["performance_score"] = "high"
+	doc["issues"].([]any)[0].(map[string]any)["severity"] = "urgent"
+	doc["dashboard_field"] = true
+	delete(doc, "graph")
+	data, _ = json.Marshal(doc)
+
+	res, back, err := ValidateReport(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Valid() || back != nil {
+		t.Fatal("expected validation errors")
+	}
+	var got []string
+	for _, e := range res.Errors {
+		got = append(got, e.Error())
+	}
+	for _, want := range []string{
+		`$: missing required property "graph"`,
+		"$.summary.performance_score: want integer, got string",
+		"$.issues[0].severity: urgent is not one of",
+		"$.dashboard_field: unknown property",
+	} {
+		if !strings.Contains(strings.Join(got, "\n"), want) {
+			t.Errorf("missing error %q in:\n%s", want, strings.Join(got, "\n"))
+		}
+	}
+}
+
+func TestMigrateReport(t *testing.T) {
+	r := plainReport()
+	r.Version = "1.0"
+	r.Plan = nil
+	data, _ := json.Marshal(r)
+
+	res, back, err := ValidateReport(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid() || res.MigratedFrom != "1.0" || res.Version != ReportVersion {
+		t.Fatalf("expected a valid migrated report, got %+v", res)
+	}
+	if back.Version != ReportVersion || back.Plan == nil || len(back.Plan.Tasks) == 0 {
+		t.Errorf("migration should fill in the plan, got %+v", back.Plan)
+	}
+
+	path := t.TempDir() + "/old.json"
+	os.WriteFile(path, data, 0o644)
+	read, err := ReadReport(path)
+	if err != nil || read.Version != ReportVersion || read.Plan == nil {
+		t.Errorf("ReadReport should migrate, got %v %+v", err, read)
+	}
+
+	for version, want := range map[string]string{"2.0": "newer than", "1.7": "newer than", "0.9": "no migration"} {
+		r.Version = version
+		data, _ := json.Marshal(r)
+		if _, _, err := ValidateReport(data); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("version %s: got %v, want %q", version, err, want)
+		}
+	}
+}
+
+// plainReport is a report without a source root
+func plainReport() *Report {
+	gen, _ := NewGenerator("")
+	gr := graph.New()
+	gr.UpsertNode(&graph.Node{ID: "c1", Label: "Button tap", Type: graph.NodeCause, Count: 50})
+	gr.UpsertNode(&graph.Node{ID: "s1", Label: "@State counter", Type: graph.NodeState, Count: 50})
+	gr.UpsertNode(&graph.Node{ID: "v1", Label: "ContentView", Type: graph.NodeView, Count: 50})
+	gr.AddEdge(graph.Edge{From: "c1", To: "s1"})
+	gr.AddEdge(graph.Edge{From: "s1", To: "v1"})
+	return gen.Generate(gr, GenerateOptions{TracePath: "test.trace"})
+}
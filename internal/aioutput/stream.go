@@ -0,0 +1,122 @@
+package aioutput
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/correlation"
+)
+
+// RecordType tags each record of a Stream
+type RecordType string
+
+const (
+	RecordProgress    RecordType = "progress"
+	RecordNode        RecordType = "node"
+	RecordEdge        RecordType = "edge"
+	RecordIssue       RecordType = "issue"
+	RecordCorrelation RecordType = "correlation"
+	RecordSummary     RecordType = "summary"
+)
+
+// Record is one line of a Stream. Type names the payload field that is
+// set; the others are omitted.
+type Record struct {
+	Type RecordType `json:"type"`
+	Seq  int        `json:"seq"`
+
+	// Update is set on an issue record that replaces the earlier one with
+	// the same ID, after correlation added the source location,
+	// project-specific fix samples and a patch
+	Update bool `json:"update,omitempty"`
+
+	Progress    *Progress                `json:"progress,omitempty"`
+	Node        *NodeData                `json:"node,omitempty"`
+	Edge        *EdgeData                `json:"edge,omitempty"`
+	Issue       *IssueWithFixes          `json:"issue,omitempty"`
+	Correlation *correlation.SourceMatch `json:"correlation,omitempty"`
+	Summary     *Summary                 `json:"summary,omitempty"`
+}
+
+// Progress reports where an analysis is. Done and Total count the stage's
+// units of work (nodes correlated, for instance) when it has them.
+type Progress struct {
+	Stage   string `json:"stage"` // parse, detect, correlate
+	Message string `json:"message"`
+	Done    int    `json:"done,omitempty"`
+	Total   int    `json:"total,omitempty"`
+}
+
+// Stream writes report records as newline-delimited JSON while the report
+// is produced, so a consumer can act on the first issue before source
+// correlation finishes. A nil Stream discards records.
+type Stream struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	seq int
+	err error
+}
+
+// NewStream writes records to w, one JSON object per line
+func NewStream(w io.Writer) *Stream {
+	return &Stream{enc: json.NewEncoder(w)}
+}
+
+// Err returns the first write error; records after it are dropped
+func (s *Stream) Err() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Stream) emit(r Record) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	s.seq++
+	r.Seq = s.seq
+	s.err = s.enc.Encode(r)
+}
+
+// Progress records the start or end of an analysis stage
+func (s *Stream) Progress(stage, message string, done, total int) {
+	s.emit(Record{Type: RecordProgress, Progress: &Progress{Stage: stage, Message: message, Done: done, Total: total}})
+}
+
+// graph records the nodes and edges, ordered by ID, before correlation
+// adds their source locations
+func (s *Stream) graph(data GraphData) {
+	if s == nil {
+		return
+	}
+	nodes := append([]NodeData(nil), data.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	for i := range nodes {
+		s.emit(Record{Type: RecordNode, Node: &nodes[i]})
+	}
+	for i := range data.Edges {
+		s.emit(Record{Type: RecordEdge, Edge: &data.Edges[i]})
+	}
+}
+
+func (s *Stream) issue(issue IssueWithFixes, update bool) {
+	s.emit(Record{Type: RecordIssue, Update: update, Issue: &issue})
+}
+
+func (s *Stream) correlation(m correlation.SourceMatch) {
+	s.emit(Record{Type: RecordCorrelation, Correlation: &m})
+}
+
+func (s *Stream) summary(sum Summary) {
+	s.emit(Record{Type: RecordSummary, Summary: &sum})
+}
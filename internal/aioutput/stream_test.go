@@ -0,0 +1,137 @@
+package aioutput
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+func TestGenerateStream(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ItemRow.swift"), []byte("import SwiftUI\n\nstruct ItemRow: View {\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gen, err := NewGenerator(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gr := graph.New()
+	gr.UpsertNode(&graph.Node{ID: "v1", Label: "ItemRow", Type: graph.NodeView, Count: 50})
+	gr.UpsertNode(&graph.Node{ID: "s1", Label: "@State", Type: graph.NodeState})
+	gr.AddEdge(graph.Edge{From: "s1", To: "v1"})
+
+	var buf bytes.Buffer
+	stream := NewStream(&buf)
+	report := gen.Generate(gr, GenerateOptions{SourceRoot: dir, Stream: stream})
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	var records []Record
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("line %d is not a JSON record: %v", len(records)+1, err)
+		}
+		if r.Seq != len(records)+1 {
+			t.Errorf("record %d has seq %d", len(records)+1, r.Seq)
+		}
+		records = append(records, r)
+	}
+
+	// each stage's records come out in pipeline order: the first issue
+	// before any correlation, the refined issues after
+	var order []RecordType
+	first := map[RecordType]int{}
+	last := map[RecordType]int{}
+	for i, r := range records {
+		if _, ok := first[r.Type]; !ok {
+			first[r.Type] = i
+			order = append(order, r.Type)
+		}
+		last[r.Type] = i
+	}
+	if len(records) == 0 || records[len(records)-1].Type != RecordSummary {
+		t.Fatalf("expected the summary last, got %v", order)
+	}
+	for _, pair := range [][2]RecordType{{RecordNode, RecordEdge}, {RecordEdge, RecordIssue}, {RecordIssue, RecordCorrelation}} {
+		if first[pair[0]] > first[pair[1]] {
+			t.Errorf("%s records should start before %s records, got %v", pair[0], pair[1], order)
+		}
+	}
+	if last[RecordIssue] < last[RecordCorrelation] {
+		t.Errorf("correlated issues should follow the correlations, got %v", order)
+	}
+
+	counts := map[RecordType]int{}
+	var updated *IssueWithFixes
+	for _, r := range records {
+		counts[r.Type]++
+		if r.Type == RecordIssue && r.Update {
+			updated = r.Issue
+		}
+	}
+	if counts[RecordNode] != 2 || counts[RecordEdge] != 1 || counts[RecordIssue] != 2*len(report.Issues) || counts[RecordCorrelation] != len(report.SourceCorrelations) {
+		t.Errorf("unexpected record counts %v for %d issues and %d correlations", counts, len(report.Issues), len(report.SourceCorrelations))
+	}
+	if updated == nil || updated.SourceFile != "ItemRow.swift" {
+		t.Errorf("the issue update should carry the source location, got %+v", updated)
+	}
+	if got := records[len(records)-1].Summary; got == nil || *got != report.Summary {
+		t.Errorf("summary record %+v does not match the report", got)
+	}
+}
+
+func TestGenerateStreamWithoutSource(t *testing.T) {
+	var buf bytes.Buffer
+	stream := NewStream(&buf)
+	gen, _ := NewGenerator("")
+	gr := graph.New()
+	gr.UpsertNode(&graph.Node{ID: "v1", Label: "ItemRow", Type: graph.NodeView, Count: 50})
+	report := gen.Generate(gr, GenerateOptions{Stream: stream})
+
+	var issueRecords int
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var r Record
+		json.Unmarshal(line, &r)
+		if r.Type == RecordCorrelation || r.Update {
+			t.Errorf("no correlation without source, got %s", line)
+		}
+		if r.Type == RecordIssue {
+			issueRecords++
+		}
+	}
+	if issueRecords != len(report.Issues) || issueRecords == 0 {
+		t.Errorf("expected one record per issue, got %d for %d", issueRecords, len(report.Issues))
+	}
+}
+
+type failingWriter struct{ writes int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("closed pipe")
+}
+
+func TestStreamStopsAtFirstError(t *testing.T) {
+	w := &failingWriter{}
+	stream := NewStream(w)
+	stream.Progress("parse", "reading", 0, 0)
+	stream.Progress("parse", "done", 0, 0)
+	if stream.Err() == nil || w.writes != 1 {
+		t.Errorf("expected one failed write and its error, got %d writes, %v", w.writes, stream.Err())
+	}
+
+	var none *Stream
+	none.Progress("parse", "reading", 0, 0)
+	if none.Err() != nil {
+		t.Error("a nil stream should discard records")
+	}
+}
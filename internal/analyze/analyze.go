@@ -9,11 +9,17 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
-	"sort"
 	"strings"
+	"time"
 
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/correlation"
 	"github.com/greenstevester/swiftui-cause-effect-cli/internal/export"
 	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/merge"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/normalize"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/suggestions"
 	"github.com/greenstevester/swiftui-cause-effect-cli/internal/xctrace"
 )
 
@@ -21,14 +27,29 @@ var ErrNoData = errors.New("no parseable cause-and-effect data")
 
 type Options struct {
 	Input      string // dir from export OR .trace path
+	GraphIn    string // saved graph (.json/.graphml); skips trace parsing when set
 	OutSummary string
 	OutDOT     string
+	OutGraph   string // optional saved graph output (.json/.graphml)
+	OutMermaid string // optional Mermaid flowchart output (.mmd, or .md for a fenced block)
+	OutSVG     string // optional SVG image drawn without Graphviz
+	MermaidTop int    // heaviest paths kept in the Mermaid output (0 = all)
+	ExportDir  string // where a .trace Input is exported (default: <trace dir>/exported)
+	RawLabels  bool   // skip label normalization and instance collapsing
+	SourceRoot string // Swift source root; needed for DOTCluster
+	DOT        DOTOptions
+	DOTCluster string // cluster DOT nodes by correlated "file" or "module"
+	DOTIssues  bool   // highlight detected issue paths in the DOT output
 	XcTrace    *xctrace.CLI
+	Catalog    *suggestions.Catalog // fixes to suggest (nil = the built-in catalog)
+	Stream     *aioutput.Stream     // receives parse progress (nil = none)
 }
 
 type Result struct {
 	SummaryPath string
 	DotPath     string
+	MermaidPath string
+	SVGPath     string
 }
 
 // AnalysisResult contains the parsed graph and metadata for further processing
@@ -37,10 +58,17 @@ type AnalysisResult struct {
 	InputDir    string
 	FilesParsed int
 	Hints       []string
+	Sources     []string // input names when several traces were merged
 }
 
+// ExportDirName is the directory next to a .trace its export is written to
+const ExportDirName = "exported"
+
 // ParseTrace parses a trace or export directory and returns the graph for further analysis
 func ParseTrace(opts Options) (*AnalysisResult, error) {
+	if opts.GraphIn != "" {
+		return loadGraph(opts.GraphIn)
+	}
 	if opts.XcTrace == nil {
 		opts.XcTrace = xctrace.New()
 	}
@@ -51,7 +79,11 @@ func ParseTrace(opts Options) (*AnalysisResult, error) {
 
 	inputDir := opts.Input
 	if !inputInfo.IsDir() && strings.HasSuffix(strings.ToLower(opts.Input), ".trace") {
-		tmpDir := filepath.Join(filepath.Dir(opts.Input), "exported")
+		tmpDir := opts.ExportDir
+		if tmpDir == "" {
+			tmpDir = filepath.Join(filepath.Dir(opts.Input), ExportDirName)
+		}
+		opts.Stream.Progress("parse", "exporting "+filepath.Base(opts.Input), 0, 0)
 		if err := export.ExportTrace(opts.XcTrace, export.Options{TracePath: opts.Input, OutDir: tmpDir, Format: "auto"}); err != nil {
 			return nil, err
 		}
@@ -60,12 +92,16 @@ func ParseTrace(opts Options) (*AnalysisResult, error) {
 
 	g := graph.New()
 	stats := &summaryStats{}
-	if err := parseDirectory(inputDir, g, stats); err != nil {
+	if err := parseDirectory(inputDir, g, stats, opts.Stream); err != nil {
 		return nil, err
 	}
 	if len(g.Nodes) == 0 || len(g.Edges) == 0 {
 		return nil, ErrNoData
 	}
+	if !opts.RawLabels {
+		opts.Stream.Progress("parse", "normalizing labels", 0, 0)
+		normalize.Collapse(g)
+	}
 
 	return &AnalysisResult{
 		Graph:       g,
@@ -75,43 +111,195 @@ func ParseTrace(opts Options) (*AnalysisResult, error) {
 	}, nil
 }
 
-func Summarize(opts Options) (Result, error) {
-	if opts.XcTrace == nil {
-		opts.XcTrace = xctrace.New()
+// ParseMulti parses several traces or export directories and merges them into
+// one graph aligned by node identity (see merge.Key). Inputs without
+// parseable data are skipped with a hint; it fails only if none parse.
+func ParseMulti(inputs []string, agg merge.Aggregate, opts Options) (*AnalysisResult, error) {
+	bases := make([]string, len(inputs))
+	for i, in := range inputs {
+		clean := filepath.Clean(in)
+		bases[i] = strings.TrimSuffix(filepath.Base(clean), filepath.Ext(clean))
+	}
+	names := merge.UniqueNames(bases)
+
+	merged := &AnalysisResult{}
+	var sources []merge.Source
+	for i, in := range inputs {
+		o := opts
+		o.Input = in
+		if strings.HasSuffix(strings.ToLower(in), ".trace") {
+			o.ExportDir = filepath.Join(filepath.Dir(in), ExportDirName, names[i])
+		}
+		opts.Stream.Progress("parse", "reading "+names[i], i, len(inputs))
+		res, err := ParseTrace(o)
+		if errors.Is(err, ErrNoData) {
+			merged.Hints = append(merged.Hints, fmt.Sprintf("%s: no parseable cause-and-effect data, skipped", names[i]))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", in, err)
+		}
+		sources = append(sources, merge.Source{Name: names[i], Graph: res.Graph})
+		merged.FilesParsed += res.FilesParsed
+		for _, h := range res.Hints {
+			merged.Hints = append(merged.Hints, names[i]+": "+h)
+		}
 	}
-	inputInfo, err := os.Stat(opts.Input)
+	if len(sources) == 0 {
+		return nil, ErrNoData
+	}
+
+	merged.Graph = merge.Graphs(sources, agg)
+	merged.Sources = merge.Names(sources)
+	return merged, nil
+}
+
+// ExpandInputs lists the inputs inside a directory of recordings: each
+// .trace bundle and each subdirectory (one export per run or device). The
+// exports an earlier run left in ExportDirName are skipped, or every trace
+// would count twice.
+func ExpandInputs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var inputs []string
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, ".") || (e.IsDir() && name == ExportDirName) {
+			continue
+		}
+		if e.IsDir() || strings.HasSuffix(strings.ToLower(name), ".trace") {
+			inputs = append(inputs, filepath.Join(dir, name))
+		}
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no traces or export directories in %s", dir)
+	}
+	return inputs, nil
+}
+
+// LatestInput returns the most recently modified recording in dir. Trace
+// bundles win over export directories.
+func LatestInput(dir string) (string, error) {
+	inputs, err := ExpandInputs(dir)
+	if err != nil {
+		return "", err
+	}
+	var traces []string
+	for _, in := range inputs {
+		if strings.HasSuffix(strings.ToLower(in), ".trace") {
+			traces = append(traces, in)
+		}
+	}
+	if len(traces) > 0 {
+		inputs = traces
+	}
+	latest := ""
+	var latestTime time.Time
+	for _, in := range inputs {
+		info, err := os.Stat(in)
+		if err != nil {
+			return "", err
+		}
+		if latest == "" || info.ModTime().After(latestTime) {
+			latest, latestTime = in, info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// Summarize writes the human-readable outputs (Markdown summary, DOT and
+// optional Mermaid/SVG) for a trace, export or saved graph. The summary is
+// built from the same report as the analyze command.
+func Summarize(opts Options) (Result, error) {
+	parsed, err := ParseTrace(opts)
 	if err != nil {
 		return Result{}, err
 	}
+	g := parsed.Graph
 
-	inputDir := opts.Input
-	if !inputInfo.IsDir() && strings.HasSuffix(strings.ToLower(opts.Input), ".trace") {
-		// Convenience: if user passed a .trace, export it first.
-		tmpDir := filepath.Join(filepath.Dir(opts.Input), "exported")
-		if err := export.ExportTrace(opts.XcTrace, export.Options{TracePath: opts.Input, OutDir: tmpDir, Format: "auto"}); err != nil {
+	if opts.OutGraph != "" {
+		if err := graph.Save(g, opts.OutGraph); err != nil {
 			return Result{}, err
 		}
-		inputDir = tmpDir
 	}
 
-	g := graph.New()
-	stats := &summaryStats{}
-	if err := parseDirectory(inputDir, g, stats); err != nil {
+	generator, err := aioutput.NewGenerator(opts.SourceRoot)
+	if err != nil {
 		return Result{}, err
 	}
-	if len(g.Nodes) == 0 || len(g.Edges) == 0 {
-		return Result{}, ErrNoData
+	report := generator.Generate(g, aioutput.GenerateOptions{
+		TracePath:   opts.Input,
+		ExportDir:   parsed.InputDir,
+		SourceRoot:  opts.SourceRoot,
+		FilesParsed: parsed.FilesParsed,
+		Hints:       parsed.Hints,
+		Catalog:     opts.Catalog,
+	})
+	if opts.GraphIn != "" {
+		report.Input.TracePath = opts.GraphIn
+	}
+	detected := make([]issues.Issue, len(report.Issues))
+	for i, issue := range report.Issues {
+		detected[i] = issue.Issue
 	}
+	highlight := issues.AffectedNodeIDs(g, detected)
 
-	summary := renderMarkdown(g, stats)
-	if err := os.WriteFile(opts.OutSummary, []byte(summary), 0o644); err != nil {
-		return Result{}, err
+	dotOpts := opts.DOT
+	if opts.DOTIssues {
+		dotOpts.Highlight = highlight
+	}
+	if opts.DOTCluster != "" {
+		if opts.SourceRoot == "" {
+			return Result{}, fmt.Errorf("clustering by %s needs a source root", opts.DOTCluster)
+		}
+		if dotOpts.Groups, err = correlation.GroupBy(report.SourceCorrelations, opts.DOTCluster); err != nil {
+			return Result{}, err
+		}
 	}
-	dot := renderDOT(g)
+	dot := RenderDOTWithOptions(g, dotOpts)
 	if err := os.WriteFile(opts.OutDOT, []byte(dot), 0o644); err != nil {
 		return Result{}, err
 	}
-	return Result{SummaryPath: opts.OutSummary, DotPath: opts.OutDOT}, nil
+	summary := RenderMarkdown(report)
+	if opts.OutSVG != "" {
+		if err := WriteSVG(opts.OutSVG, g, dotOpts); err != nil {
+			return Result{}, err
+		}
+		summary += fmt.Sprintf("- Image: ![Cause & Effect graph](%s)\n", relTo(opts.OutSummary, opts.OutSVG))
+	}
+	if err := os.WriteFile(opts.OutSummary, []byte(summary), 0o644); err != nil {
+		return Result{}, err
+	}
+	if opts.OutMermaid != "" {
+		if err := WriteMermaid(opts.OutMermaid, g, MermaidOptions{TopN: opts.MermaidTop, Highlight: highlight}); err != nil {
+			return Result{}, err
+		}
+	}
+	return Result{SummaryPath: opts.OutSummary, DotPath: opts.OutDOT, MermaidPath: opts.OutMermaid, SVGPath: opts.OutSVG}, nil
+}
+
+// relTo returns target relative to the directory holding from, for links
+// between output files; it falls back to target as given.
+func relTo(from, target string) string {
+	rel, err := filepath.Rel(filepath.Dir(from), target)
+	if err != nil {
+		return filepath.ToSlash(target)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// loadGraph reads a saved graph instead of parsing a trace export
+func loadGraph(path string) (*AnalysisResult, error) {
+	g, err := graph.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.Nodes) == 0 || len(g.Edges) == 0 {
+		return nil, ErrNoData
+	}
+	return &AnalysisResult{Graph: g}, nil
 }
 
 type summaryStats struct {
@@ -119,30 +307,39 @@ type summaryStats struct {
 	Hints       []string
 }
 
-func parseDirectory(dir string, g *graph.Graph, stats *summaryStats) error {
-	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+// parseDirectory parses the export files under dir into g, reporting each
+// file parsed to the stream
+func parseDirectory(dir string, g *graph.Graph, stats *summaryStats, stream *aioutput.Stream) error {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 		if d.IsDir() {
 			return nil
 		}
-		ext := strings.ToLower(filepath.Ext(path))
-		switch ext {
-		case ".json":
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json", ".xml", ".csv", ".txt":
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i, path := range files {
+		if strings.ToLower(filepath.Ext(path)) == ".json" {
 			if err := parseJSON(path, g, stats); err != nil {
 				// best-effort: keep going
 				stats.Hints = append(stats.Hints, fmt.Sprintf("JSON parse skipped %s: %v", filepath.Base(path), err))
 			}
-			stats.FilesParsed++
-		case ".xml", ".csv", ".txt":
-			if err := parseTextLike(path, g, stats); err != nil {
-				stats.Hints = append(stats.Hints, fmt.Sprintf("text parse skipped %s: %v", filepath.Base(path), err))
-			}
-			stats.FilesParsed++
+		} else if err := parseTextLike(path, g, stats); err != nil {
+			stats.Hints = append(stats.Hints, fmt.Sprintf("text parse skipped %s: %v", filepath.Base(path), err))
 		}
-		return nil
-	})
+		stats.FilesParsed++
+		stream.Progress("parse", fmt.Sprintf("parsed %s (%d nodes, %d edges so far)", filepath.Base(path), len(g.Nodes), len(g.Edges)), i+1, len(files))
+	}
+	return nil
 }
 
 // parseJSON tries to interpret a few likely export shapes.
@@ -170,7 +367,18 @@ func parseJSON(path string, g *graph.Graph, stats *summaryStats) error {
 			label := asString(m["label"], asString(m["title"], ""))
 			kind := strings.ToLower(asString(m["type"], asString(m["kind"], "")))
 			count := asInt(m["count"], asInt(m["updates"], 0))
-			g.UpsertNode(&graph.Node{ID: idOrHash(id, label), Label: label, Type: classify(kind, label), Count: count})
+			nodeID := idOrHash(id, label)
+			g.UpsertNode(&graph.Node{ID: nodeID, Label: label, Type: classify(kind, label), Count: count})
+
+			// View hierarchy, when the export carries it
+			if parent := asString(m["parent"], asString(m["parent_id"], asString(m["parentId"], ""))); parent != "" {
+				g.AddContainment(parent, nodeID)
+			}
+			if children, ok := m["children"].([]any); ok {
+				for _, c := range children {
+					g.AddContainment(nodeID, asString(c, ""))
+				}
+			}
 		}
 		for _, e := range edgesRaw {
 			m, ok := e.(map[string]any)
@@ -183,6 +391,10 @@ func parseJSON(path string, g *graph.Graph, stats *summaryStats) error {
 			if from == "" || to == "" {
 				continue
 			}
+			if isContainment(asString(m["kind"], asString(m["type"], label))) {
+				g.AddContainment(from, to)
+				continue
+			}
 			g.AddEdge(graph.Edge{From: from, To: to, Label: label})
 		}
 		return nil
@@ -192,6 +404,16 @@ func parseJSON(path string, g *graph.Graph, stats *summaryStats) error {
 	return parseTextReader(strings.NewReader(string(b)), g, stats)
 }
 
+// isContainment reports whether an export edge kind describes view nesting
+// rather than an update relationship.
+func isContainment(kind string) bool {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "contains", "containment", "child", "parent-child", "hierarchy":
+		return true
+	}
+	return false
+}
+
 func parseTextLike(path string, g *graph.Graph, stats *summaryStats) error {
 	f, err := os.Open(path)
 	if err != nil {
@@ -242,87 +464,6 @@ func parseTextReader(r io.Reader, g *graph.Graph, stats *summaryStats) error {
 	return s.Err()
 }
 
-func renderDOT(g *graph.Graph) string {
-	var b strings.Builder
-	b.WriteString("digraph CauseEffect {\n")
-	b.WriteString("  rankdir=LR;\n")
-	for _, n := range g.Nodes {
-		shape := "box"
-		switch n.Type {
-		case graph.NodeCause:
-			shape = "ellipse"
-		case graph.NodeState:
-			shape = "diamond"
-		case graph.NodeView:
-			shape = "box"
-		}
-		label := escapeDOT(n.Label)
-		if n.Count > 0 {
-			label = fmt.Sprintf("%s\\ncount=%d", label, n.Count)
-		}
-		b.WriteString(fmt.Sprintf("  \"%s\" [shape=%s,label=\"%s\"];\n", n.ID, shape, label))
-	}
-	for _, e := range g.Edges {
-		lbl := escapeDOT(e.Label)
-		if lbl != "" {
-			b.WriteString(fmt.Sprintf("  \"%s\" -> \"%s\" [label=\"%s\"];\n", e.From, e.To, lbl))
-		} else {
-			b.WriteString(fmt.Sprintf("  \"%s\" -> \"%s\";\n", e.From, e.To))
-		}
-	}
-	b.WriteString("}\n")
-	return b.String()
-}
-
-func renderMarkdown(g *graph.Graph, stats *summaryStats) string {
-	var causes, states, views []*graph.Node
-	for _, n := range g.Nodes {
-		switch n.Type {
-		case graph.NodeCause:
-			causes = append(causes, n)
-		case graph.NodeState:
-			states = append(states, n)
-		case graph.NodeView:
-			views = append(views, n)
-		}
-	}
-	sort.Slice(views, func(i, j int) bool { return views[i].Count > views[j].Count })
-	if len(views) > 10 {
-		views = views[:10]
-	}
-
-	var b strings.Builder
-	b.WriteString("# SwiftUI Cause & Effect Summary\n\n")
-	b.WriteString(fmt.Sprintf("Parsed %d files. Nodes: %d, Edges: %d.\n\n", stats.FilesParsed, len(g.Nodes), len(g.Edges)))
-	b.WriteString("## What this tool could extract\n")
-	b.WriteString(fmt.Sprintf("- Causes: %d\n- State changes: %d\n- View updates: %d\n\n", len(causes), len(states), len(views)))
-	b.WriteString("## Top view-update nodes (best effort)\n")
-	if len(views) == 0 {
-		b.WriteString("No explicit counts found in exported data.\n\n")
-	} else {
-		for _, v := range views {
-			b.WriteString(fmt.Sprintf("- %s (count=%d)\n", v.Label, v.Count))
-		}
-		b.WriteString("\n")
-	}
-
-	b.WriteString("## Notes\n")
-	b.WriteString("- The SwiftUI Cause & Effect Graph is collected by the SwiftUI instrument (Xcode 26) and is primarily designed for interactive use in Instruments.\n")
-	b.WriteString("- Export schemas can change; this CLI uses heuristic parsing and may miss relationships.\n")
-	b.WriteString("- If export produced no parseable artifacts, open the .trace in Instruments and use the Cause & Effect Graph UI.\n\n")
-
-	if len(stats.Hints) > 0 {
-		b.WriteString("## Parse hints\n")
-		for _, h := range stats.Hints {
-			b.WriteString("- " + h + "\n")
-		}
-		b.WriteString("\n")
-	}
-	b.WriteString("## Outputs\n")
-	b.WriteString("- Graphviz: see the generated `.dot` file (render with `dot -Tpng graph.dot -o graph.png`).\n")
-	return b.String()
-}
-
 func asString(v any, def string) string {
 	if v == nil {
 		return def
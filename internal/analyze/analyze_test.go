@@ -1,12 +1,18 @@
 package analyze
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
 	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/merge"
 )
 
 func TestAsString(t *testing.T) {
@@ -263,7 +269,7 @@ func TestRenderDOT(t *testing.T) {
 	g.AddEdge(graph.Edge{From: "c1", To: "s1", Label: "causes"})
 	g.AddEdge(graph.Edge{From: "s1", To: "v1", Label: "updates"})
 
-	dot := renderDOT(g)
+	dot := RenderDOT(g)
 
 	// Check structure
 	if !strings.Contains(dot, "digraph CauseEffect") {
@@ -302,9 +308,11 @@ func TestRenderMarkdown(t *testing.T) {
 	g.UpsertNode(&graph.Node{ID: "v1", Label: "View", Type: graph.NodeView, Count: 10})
 	g.AddEdge(graph.Edge{From: "c1", To: "s1"})
 
-	stats := &summaryStats{FilesParsed: 3, Hints: []string{"test hint"}}
-
-	md := renderMarkdown(g, stats)
+	gen, err := aioutput.NewGenerator("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := RenderMarkdown(gen.Generate(g, aioutput.GenerateOptions{FilesParsed: 3, Hints: []string{"test hint"}}))
 
 	if !strings.Contains(md, "# SwiftUI Cause & Effect Summary") {
 		t.Error("missing title")
@@ -370,6 +378,39 @@ func TestParseJSON_WithNodesAndEdges(t *testing.T) {
 	}
 }
 
+func TestParseJSON_Containment(t *testing.T) {
+	jsonContent := `{
+		"nodes": [
+			{"id": "screen", "label": "ListScreen", "type": "view", "children": ["list"]},
+			{"id": "list", "label": "List", "type": "view"},
+			{"id": "row1", "label": "RowView", "type": "view", "count": 20, "parent_id": "list"},
+			{"id": "row2", "label": "RowView", "type": "view", "count": 20, "parentId": "list"},
+			{"id": "s1", "label": "@State items", "type": "state"}
+		],
+		"edges": [
+			{"from": "s1", "to": "row1", "label": "updates"},
+			{"from": "list", "to": "row2", "kind": "contains"}
+		]
+	}`
+
+	jsonPath := filepath.Join(t.TempDir(), "test.json")
+	if err := os.WriteFile(jsonPath, []byte(jsonContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := graph.New()
+	if err := parseJSON(jsonPath, g, &summaryStats{}); err != nil {
+		t.Fatalf("parseJSON failed: %v", err)
+	}
+
+	if len(g.Edges) != 1 {
+		t.Errorf("expected only the update edge, got %+v", g.Edges)
+	}
+	if len(g.Containment) != 3 {
+		t.Errorf("expected 3 containment edges, got %+v", g.Containment)
+	}
+}
+
 func TestSummarize_NoData(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -430,3 +471,248 @@ View body() called
 		t.Error("graph.dot was not created")
 	}
 }
+
+func TestParseTrace_GraphIn(t *testing.T) {
+	tmpDir := t.TempDir()
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "c1", Label: "Tap", Type: graph.NodeCause})
+	g.UpsertNode(&graph.Node{ID: "v1", Label: "ItemRow", Type: graph.NodeView, Count: 12})
+	g.AddEdge(graph.Edge{From: "c1", To: "v1"})
+
+	path := filepath.Join(tmpDir, "graph.graphml")
+	if err := graph.Save(g, path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Input points nowhere: the trace must not be touched when GraphIn is set
+	result, err := ParseTrace(Options{Input: filepath.Join(tmpDir, "missing.trace"), GraphIn: path})
+	if err != nil {
+		t.Fatalf("ParseTrace failed: %v", err)
+	}
+	if len(result.Graph.Nodes) != 2 || len(result.Graph.Edges) != 1 {
+		t.Errorf("unexpected graph: %d nodes, %d edges", len(result.Graph.Nodes), len(result.Graph.Edges))
+	}
+	if result.Graph.Nodes["v1"].Count != 12 {
+		t.Errorf("expected count 12, got %d", result.Graph.Nodes["v1"].Count)
+	}
+}
+
+func TestParseTrace_StreamsProgress(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		content := "button tap happened\n@State var counter changed\nView body() called\n"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if _, err := ParseTrace(Options{Input: dir, Stream: aioutput.NewStream(&buf)}); err != nil {
+		t.Fatal(err)
+	}
+	var files []int
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec aioutput.Record
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatal(err)
+		}
+		if p := rec.Progress; p != nil && p.Stage == "parse" && p.Total == 2 {
+			files = append(files, p.Done)
+		}
+	}
+	if len(files) != 2 || files[0] != 1 || files[1] != 2 {
+		t.Errorf("expected a progress record per file parsed, got %v in\n%s", files, buf.String())
+	}
+}
+
+func TestParseTrace_GraphInWithoutEdges(t *testing.T) {
+	// Held to the same bar as a parsed trace
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "v1", Label: "ItemRow", Type: graph.NodeView, Count: 12})
+	path := filepath.Join(t.TempDir(), "graph.json")
+	if err := graph.Save(g, path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseTrace(Options{GraphIn: path}); !errors.Is(err, ErrNoData) {
+		t.Errorf("expected ErrNoData, got %v", err)
+	}
+}
+
+func TestSummarize_GraphRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := `button tap happened
+@State var counter changed
+View body() called
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "trace.txt"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	graphPath := filepath.Join(tmpDir, "graph.json")
+	if _, err := Summarize(Options{
+		Input:      tmpDir,
+		OutSummary: filepath.Join(tmpDir, "summary.md"),
+		OutDOT:     filepath.Join(tmpDir, "graph.dot"),
+		OutGraph:   graphPath,
+	}); err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+
+	outDir := t.TempDir()
+	if _, err := Summarize(Options{
+		GraphIn:    graphPath,
+		OutSummary: filepath.Join(outDir, "summary.md"),
+		OutDOT:     filepath.Join(outDir, "graph.dot"),
+	}); err != nil {
+		t.Fatalf("Summarize from saved graph failed: %v", err)
+	}
+
+	first, _ := os.ReadFile(filepath.Join(tmpDir, "graph.dot"))
+	second, _ := os.ReadFile(filepath.Join(outDir, "graph.dot"))
+	if !strings.Contains(string(first), "->") {
+		t.Fatalf("expected edges in the DOT output:\n%s", first)
+	}
+	if string(first) != string(second) {
+		t.Errorf("DOT output differs after round trip:\n%s\n---\n%s", first, second)
+	}
+}
+
+func TestParseMulti(t *testing.T) {
+	root := t.TempDir()
+	write := func(run, content string) {
+		dir := filepath.Join(root, run)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "graph.json"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("run1", `{"nodes":[{"id":"a","label":"Tap","type":"cause"},{"id":"b","label":"RowView","type":"view","count":10}],"edges":[{"from":"a","to":"b"}]}`)
+	write("run2", `{"nodes":[{"id":"x","label":"Tap","type":"cause"},{"id":"y","label":"RowView","type":"view","count":14}],"edges":[{"from":"x","to":"y"}]}`)
+	write("empty", `{"unrelated": true}`)
+
+	inputs, err := ExpandInputs(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 3 {
+		t.Fatalf("expected 3 inputs, got %v", inputs)
+	}
+
+	result, err := ParseMulti(inputs, merge.AggregateSum, Options{})
+	if err != nil {
+		t.Fatalf("ParseMulti failed: %v", err)
+	}
+	if len(result.Sources) != 2 {
+		t.Errorf("expected 2 sources (empty skipped), got %v", result.Sources)
+	}
+	if len(result.Hints) == 0 {
+		t.Error("expected a hint for the skipped input")
+	}
+	if len(result.Graph.Nodes) != 2 || len(result.Graph.Edges) != 1 {
+		t.Fatalf("unexpected merged graph: %d nodes, %d edges", len(result.Graph.Nodes), len(result.Graph.Edges))
+	}
+	for _, n := range result.Graph.Nodes {
+		if n.Type == graph.NodeView && n.Count != 24 {
+			t.Errorf("expected summed count 24, got %d", n.Count)
+		}
+	}
+}
+
+func TestExpandInputs_SkipsEarlierExports(t *testing.T) {
+	root := t.TempDir()
+	graphJSON := `{"nodes":[{"id":"a","label":"Tap","type":"cause"},{"id":"b","label":"RowView","type":"view","count":10}],"edges":[{"from":"a","to":"b"}]}`
+	write := func(dir string) {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "graph.json"), []byte(graphJSON), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(root, "run1"))
+	write(filepath.Join(root, "run2"))
+
+	// each -in-dir run exports traces into root/exported/<name>, which the
+	// next run must not read as one more recording
+	for run := 1; run <= 2; run++ {
+		inputs, err := ExpandInputs(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(inputs) != 2 {
+			t.Fatalf("run %d: expected 2 inputs, got %v", run, inputs)
+		}
+		result, err := ParseMulti(inputs, merge.AggregateSum, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range result.Graph.Nodes {
+			if n.Type == graph.NodeView && n.Count != 20 {
+				t.Errorf("run %d: expected summed count 20, got %d", run, n.Count)
+			}
+		}
+		write(filepath.Join(root, ExportDirName, "run3"))
+	}
+}
+
+func TestLatestInput(t *testing.T) {
+	root := t.TempDir()
+	old := time.Now().Add(-time.Hour)
+	for _, name := range []string{"a.trace", "b.trace", "exported"} {
+		if err := os.Mkdir(filepath.Join(root, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chtimes(filepath.Join(root, "a.trace"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// exported is as new as b.trace, but is never an input
+	got, err := LatestInput(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != filepath.Join(root, "b.trace") {
+		t.Errorf("expected b.trace, got %s", got)
+	}
+
+	if _, err := LatestInput(t.TempDir()); err == nil {
+		t.Error("expected an error for an empty directory")
+	}
+}
+
+func TestParseTrace_Normalizes(t *testing.T) {
+	content := `{
+		"nodes": [
+			{"id": "s1", "label": "@State items", "type": "state"},
+			{"id": "r1", "label": "RowView: 0x600003a1c000", "type": "view", "count": 30},
+			{"id": "r2", "label": "RowView: 0x600003a1d000", "type": "view", "count": 10}
+		],
+		"edges": [
+			{"from": "s1", "to": "r1"},
+			{"from": "s1", "to": "r2"}
+		]
+	}`
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "graph.json"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ParseTrace(Options{Input: dir})
+	if err != nil {
+		t.Fatalf("ParseTrace failed: %v", err)
+	}
+	row := result.Graph.Nodes["r1"]
+	if len(result.Graph.Nodes) != 2 || row == nil || row.Label != "RowView" || row.Count != 40 || len(row.Instances) != 2 {
+		t.Errorf("expected instances collapsed into r1, got %+v", result.Graph.Nodes)
+	}
+
+	raw, err := ParseTrace(Options{Input: dir, RawLabels: true})
+	if err != nil {
+		t.Fatalf("ParseTrace failed: %v", err)
+	}
+	if len(raw.Graph.Nodes) != 3 {
+		t.Errorf("expected raw labels to keep 3 nodes, got %d", len(raw.Graph.Nodes))
+	}
+}
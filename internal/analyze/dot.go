@@ -0,0 +1,244 @@
+package analyze
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+// DOTOptions controls RenderDOTWithOptions. The zero value renders the
+// plain graph.
+type DOTOptions struct {
+	// Heatmap fills nodes from blue (few updates) to red (many)
+	Heatmap bool
+	// Groups maps node IDs to a cluster name (e.g. source file or module,
+	// see correlation.GroupBy); grouped nodes are drawn in one subgraph.
+	Groups map[string]string
+	// Highlight marks issue nodes; they and the edges leading into them
+	// are drawn in bold red.
+	Highlight []string
+	// MinCount drops counted nodes below this update count, along with
+	// uncounted nodes (most states and causes) left without a path to a
+	// kept counted node.
+	MinCount int
+	// Legend adds a key for shapes, heat colors and highlighting
+	Legend bool
+}
+
+// RenderDOT renders the graph in Graphviz DOT format
+func RenderDOT(g *graph.Graph) string {
+	return RenderDOTWithOptions(g, DOTOptions{})
+}
+
+// RenderDOTWithOptions renders the graph in Graphviz DOT format with
+// optional styling, clustering and pruning. Nodes and edges are emitted in
+// sorted order so the output is stable across runs.
+func RenderDOTWithOptions(g *graph.Graph, opts DOTOptions) string {
+	keep := pruneByCount(g, opts.MinCount)
+
+	hot := map[string]bool{}
+	for _, id := range opts.Highlight {
+		if keep[id] {
+			hot[id] = true
+		}
+	}
+	feeds := issuePathNodes(g, hot)
+
+	maxCount := 0
+	for id := range keep {
+		if c := g.Nodes[id].Count; c > maxCount {
+			maxCount = c
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph CauseEffect {\n")
+	b.WriteString("  rankdir=LR;\n")
+	if opts.Heatmap || len(hot) > 0 || len(opts.Groups) > 0 {
+		b.WriteString("  node [fontname=\"Helvetica\"];\n")
+	}
+
+	clusters := map[string][]*graph.Node{}
+	var loose []*graph.Node
+	for _, n := range g.SortedNodes() {
+		if !keep[n.ID] {
+			continue
+		}
+		if group := opts.Groups[n.ID]; group != "" {
+			clusters[group] = append(clusters[group], n)
+		} else {
+			loose = append(loose, n)
+		}
+	}
+
+	node := func(indent string, n *graph.Node) {
+		fmt.Fprintf(&b, "%s\"%s\" [%s];\n", indent, n.ID, dotNodeAttrs(n, opts.Heatmap, maxCount, hot[n.ID]))
+	}
+	names := make([]string, 0, len(clusters))
+	for name := range clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		fmt.Fprintf(&b, "  subgraph cluster_%d {\n", i)
+		fmt.Fprintf(&b, "    label=\"%s\";\n    style=rounded;\n    color=\"#888888\";\n", escapeDOT(name))
+		for _, n := range clusters[name] {
+			node("    ", n)
+		}
+		b.WriteString("  }\n")
+	}
+	for _, n := range loose {
+		node("  ", n)
+	}
+
+	for _, e := range g.SortedEdges() {
+		// Edges into nodes the export never listed are drawn unless the
+		// graph is pruned
+		if opts.MinCount > 0 && (!keep[e.From] || !keep[e.To]) {
+			continue
+		}
+		var attrs []string
+		if lbl := escapeDOT(e.Label); lbl != "" {
+			attrs = append(attrs, fmt.Sprintf("label=\"%s\"", lbl))
+		}
+		if feeds[e.From] && feeds[e.To] {
+			attrs = append(attrs, "color=\"#c62828\"", "penwidth=2.5")
+		}
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, "  \"%s\" -> \"%s\" [%s];\n", e.From, e.To, strings.Join(attrs, ","))
+		} else {
+			fmt.Fprintf(&b, "  \"%s\" -> \"%s\";\n", e.From, e.To)
+		}
+	}
+
+	if opts.Legend {
+		writeDOTLegend(&b, opts.Heatmap, len(hot) > 0)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotShape(t graph.NodeType) string {
+	switch t {
+	case graph.NodeCause:
+		return "ellipse"
+	case graph.NodeState:
+		return "diamond"
+	}
+	return "box"
+}
+
+func dotNodeAttrs(n *graph.Node, heatmap bool, maxCount int, hot bool) string {
+	label := escapeDOT(n.Label)
+	if n.Count > 0 {
+		label = fmt.Sprintf("%s\\ncount=%d", label, n.Count)
+	}
+	attrs := []string{"shape=" + dotShape(n.Type), fmt.Sprintf("label=\"%s\"", label)}
+	if heatmap {
+		attrs = append(attrs, "style=filled", fmt.Sprintf("fillcolor=\"%s\"", heatColor(n.Count, maxCount)))
+	}
+	if hot {
+		attrs = append(attrs, "color=\"#c62828\"", "penwidth=2.5")
+	}
+	return strings.Join(attrs, ",")
+}
+
+// heatColor maps a count onto a Graphviz HSV color, see heatHSV
+func heatColor(count, maxCount int) string {
+	h, sat, v := heatHSV(count, maxCount)
+	return fmt.Sprintf("%.3f %.3f %.3f", h, sat, v)
+}
+
+// heatHSV maps a count onto a color from pale blue to red on a log scale,
+// so a few very hot nodes do not wash out the rest. Components are 0..1.
+func heatHSV(count, maxCount int) (h, s, v float64) {
+	if count <= 0 || maxCount <= 0 {
+		return 0.6, 0.08, 1
+	}
+	t := math.Log1p(float64(count)) / math.Log1p(float64(maxCount))
+	return 0.6 * (1 - t), 0.15 + 0.6*t, 1
+}
+
+func writeDOTLegend(b *strings.Builder, heatmap, highlight bool) {
+	b.WriteString("  subgraph cluster_legend {\n")
+	b.WriteString("    label=\"Legend\";\n    style=dashed;\n    color=\"#888888\";\n")
+	b.WriteString("    \"legend_cause\" [shape=ellipse,label=\"cause\"];\n")
+	b.WriteString("    \"legend_state\" [shape=diamond,label=\"state\"];\n")
+	b.WriteString("    \"legend_view\" [shape=box,label=\"view\"];\n")
+	b.WriteString("    \"legend_cause\" -> \"legend_state\" -> \"legend_view\" [style=invis];\n")
+	if heatmap {
+		fmt.Fprintf(b, "    \"legend_cold\" [shape=box,style=filled,fillcolor=\"%s\",label=\"few updates\"];\n", heatColor(1, 100))
+		fmt.Fprintf(b, "    \"legend_hot\" [shape=box,style=filled,fillcolor=\"%s\",label=\"many updates\"];\n", heatColor(100, 100))
+		b.WriteString("    \"legend_cold\" -> \"legend_hot\" [style=invis];\n")
+	}
+	if highlight {
+		b.WriteString("    \"legend_issue\" [shape=box,color=\"#c62828\",penwidth=2.5,label=\"issue\"];\n")
+	}
+	b.WriteString("  }\n")
+}
+
+// pruneByCount returns the nodes to draw for a count threshold. Counted nodes are kept at or above min; uncounted nodes
+// are kept while they still connect to a kept counted node in either
+// direction.
+func pruneByCount(g *graph.Graph, min int) map[string]bool {
+	keep := make(map[string]bool, len(g.Nodes))
+	if min <= 0 {
+		for id := range g.Nodes {
+			keep[id] = true
+		}
+		return keep
+	}
+	succ := map[string][]string{}
+	pred := map[string][]string{}
+	for _, e := range g.Edges {
+		succ[e.From] = append(succ[e.From], e.To)
+		pred[e.To] = append(pred[e.To], e.From)
+	}
+	var counted []string
+	for id, n := range g.Nodes {
+		if n.Count >= min {
+			counted = append(counted, id)
+		}
+	}
+
+	// One walk each way from the kept counted nodes finds every node with
+	// a path to or from one of them
+	for _, id := range counted {
+		keep[id] = true
+	}
+	for _, next := range []map[string][]string{succ, pred} {
+		seen := map[string]bool{}
+		stack := append([]string(nil), counted...)
+		for len(stack) > 0 {
+			id := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, n := range next[id] {
+				if seen[n] {
+					continue
+				}
+				seen[n] = true
+				stack = append(stack, n)
+				if node, ok := g.Nodes[n]; ok && node.Count == 0 {
+					keep[n] = true
+				}
+			}
+		}
+	}
+	return keep
+}
+
+// issuePathNodes returns the highlighted nodes plus every node upstream of
+// one; an edge between two such nodes lies on a path into an issue.
+func issuePathNodes(g *graph.Graph, hot map[string]bool) map[string]bool {
+	nodes := map[string]bool{}
+	for id := range hot {
+		nodes[id] = true
+		for _, up := range g.Upstream(id) {
+			nodes[up] = true
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,163 @@
+package analyze
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+func TestRenderDOT_Deterministic(t *testing.T) {
+	g := mermaidGraph()
+	first := RenderDOT(g)
+	for i := 0; i < 5; i++ {
+		if got := RenderDOT(g); got != first {
+			t.Fatalf("output changed between runs:\n%s\nvs\n%s", first, got)
+		}
+	}
+	if strings.Contains(first, "fillcolor") || strings.Contains(first, "subgraph") {
+		t.Errorf("default output should be unstyled:\n%s", first)
+	}
+}
+
+func TestRenderDOT_Heatmap(t *testing.T) {
+	out := RenderDOTWithOptions(mermaidGraph(), DOTOptions{Heatmap: true})
+
+	if !strings.Contains(out, `"clock" [shape=box,label="ClockView\ncount=60",style=filled,fillcolor="0.000 0.750 1.000"]`) {
+		t.Errorf("hottest node should be red:\n%s", out)
+	}
+	if !strings.Contains(out, `"items" [shape=diamond,label="@State \"items\"",style=filled,fillcolor="0.600 0.080 1.000"]`) {
+		t.Errorf("uncounted node should be pale:\n%s", out)
+	}
+}
+
+func TestRenderDOT_Clusters(t *testing.T) {
+	out := RenderDOTWithOptions(mermaidGraph(), DOTOptions{Groups: map[string]string{
+		"row":   "Features/List",
+		"items": "Features/List",
+		"clock": "Clock",
+	}})
+
+	// Clusters are ordered by name
+	clock := strings.Index(out, `label="Clock";`)
+	list := strings.Index(out, `label="Features/List";`)
+	if clock < 0 || list < 0 || clock > list {
+		t.Fatalf("expected Clock then Features/List clusters:\n%s", out)
+	}
+	body := out[list : strings.Index(out[list:], "}")+list]
+	if !strings.Contains(body, `"items"`) || !strings.Contains(body, `"row"`) {
+		t.Errorf("Features/List cluster should hold items and row:\n%s", body)
+	}
+	if strings.Contains(body, `"tap"`) {
+		t.Errorf("ungrouped node inside a cluster:\n%s", body)
+	}
+}
+
+func TestRenderDOT_Highlight(t *testing.T) {
+	out := RenderDOTWithOptions(mermaidGraph(), DOTOptions{Highlight: []string{"row", "missing"}})
+
+	if !strings.Contains(out, `"row" [shape=box,label="ForEach<RowView>\ncount=40",color="#c62828",penwidth=2.5]`) {
+		t.Errorf("issue node not highlighted:\n%s", out)
+	}
+	if !strings.Contains(out, `"items" -> "row" [label="updates",color="#c62828",penwidth=2.5]`) {
+		t.Errorf("issue path edge not highlighted:\n%s", out)
+	}
+	if !strings.Contains(out, `"now" -> "clock";`) {
+		t.Errorf("unrelated edge should stay plain:\n%s", out)
+	}
+}
+
+func TestRenderDOT_MinCount(t *testing.T) {
+	out := RenderDOTWithOptions(mermaidGraph(), DOTOptions{MinCount: 50})
+
+	for _, id := range []string{`"timer"`, `"now"`, `"clock"`} {
+		if !strings.Contains(out, id+" [") {
+			t.Errorf("expected %s to be kept:\n%s", id, out)
+		}
+	}
+	// tap and row fall below the threshold; items and misc lose their link
+	for _, id := range []string{`"tap"`, `"row"`, `"items"`, `"misc"`} {
+		if strings.Contains(out, id) {
+			t.Errorf("expected %s to be pruned:\n%s", id, out)
+		}
+	}
+}
+
+func TestRenderDOT_KeepsDanglingEdges(t *testing.T) {
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "s", Label: "@State items", Type: graph.NodeState})
+	g.AddEdge(graph.Edge{From: "s", To: "missing"})
+	g.AddEdge(graph.Edge{From: "gone", To: "s"})
+
+	out := RenderDOT(g)
+	for _, want := range []string{`"s" -> "missing";`, `"gone" -> "s";`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("unpruned output should keep %s:\n%s", want, out)
+		}
+	}
+	if out := RenderDOTWithOptions(g, DOTOptions{MinCount: 1}); strings.Contains(out, "->") {
+		t.Errorf("pruned output should drop edges to unknown nodes:\n%s", out)
+	}
+}
+
+func TestRenderDOT_Legend(t *testing.T) {
+	out := RenderDOTWithOptions(mermaidGraph(), DOTOptions{Legend: true, Heatmap: true, Highlight: []string{"clock"}})
+
+	if !strings.Contains(out, "subgraph cluster_legend") {
+		t.Fatalf("missing legend:\n%s", out)
+	}
+	for _, want := range []string{`"legend_view"`, `"legend_hot"`, `"legend_issue"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("legend missing %s:\n%s", want, out)
+		}
+	}
+	if plain := RenderDOTWithOptions(mermaidGraph(), DOTOptions{Legend: true}); strings.Contains(plain, "legend_hot") || strings.Contains(plain, "legend_issue") {
+		t.Errorf("legend should only explain styles in use:\n%s", plain)
+	}
+}
+
+func TestSummarize_DOTOptions(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"nodes":[{"id":"s","label":"@State items","type":"state"},{"id":"v","label":"RowView","type":"view","count":50}],"edges":[{"from":"s","to":"v"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "graph.json"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(dir, "src", "Sources", "Feed")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "RowView.swift"), []byte("struct RowView: View {\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dotPath := filepath.Join(dir, "graph.dot")
+	_, err := Summarize(Options{
+		Input:      dir,
+		OutSummary: filepath.Join(dir, "summary.md"),
+		OutDOT:     dotPath,
+		SourceRoot: filepath.Join(dir, "src"),
+		DOT:        DOTOptions{Heatmap: true, Legend: true},
+		DOTCluster: "module",
+		DOTIssues:  true,
+	})
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	b, err := os.ReadFile(dotPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dot := string(b)
+	for _, want := range []string{`label="Feed";`, "fillcolor=", `"s" -> "v" [color="#c62828",penwidth=2.5]`, "cluster_legend"} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("expected %q in DOT output:\n%s", want, dot)
+		}
+	}
+
+	_, err = Summarize(Options{Input: dir, OutSummary: filepath.Join(dir, "summary.md"), OutDOT: dotPath, DOTCluster: "file"})
+	if err == nil {
+		t.Error("expected an error when clustering without a source root")
+	}
+}
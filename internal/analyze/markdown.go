@@ -0,0 +1,204 @@
+package analyze
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/correlation"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+)
+
+// topViews is the number of view nodes listed by update count
+const topViews = 10
+
+var severityHeadings = []struct {
+	severity issues.Severity
+	title    string
+}{
+	{issues.SeverityCritical, "Critical"},
+	{issues.SeverityHigh, "High"},
+	{issues.SeverityMedium, "Medium"},
+	{issues.SeverityLow, "Low"},
+	{issues.SeverityInfo, "Info"},
+}
+
+// RenderMarkdown renders the human-readable summary of an analysis report:
+// the score and how it was reached, issues grouped by severity with their
+// cause chains, source locations and fixes, the busiest views,
+// recommendations and parse hints.
+func RenderMarkdown(r *aioutput.Report) string {
+	refs := issues.NewResolver(r.Graph.ToGraph())
+	nodes := map[string]aioutput.NodeData{}
+	for _, n := range r.Graph.Nodes {
+		nodes[n.ID] = n
+	}
+	lookup := func(ref string) (aioutput.NodeData, bool) {
+		id, ok := refs.NodeID(ref)
+		return nodes[id], ok
+	}
+	best := map[string]correlation.SourceMatch{}
+	for _, m := range r.SourceCorrelations {
+		if cur, ok := best[m.TraceNodeID]; !ok || m.Confidence > cur.Confidence {
+			best[m.TraceNodeID] = m
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("# SwiftUI Cause & Effect Summary\n\n")
+	b.WriteString(fmt.Sprintf("Parsed %d files. Nodes: %d, Edges: %d.\n\n", r.Input.FilesParsed, len(r.Graph.Nodes), len(r.Graph.Edges)))
+
+	s := r.Summary
+	b.WriteString(fmt.Sprintf("## Performance score: %d/100 (%s)\n\n", s.PerformanceScore, s.HealthStatus))
+	if deductions := s.Deductions(); len(deductions) == 0 {
+		b.WriteString("No issues detected; nothing was deducted.\n\n")
+	} else {
+		b.WriteString("| Deduction | Issues | Points |\n|---|---:|---:|\n")
+		total := 0
+		for _, d := range deductions {
+			b.WriteString(fmt.Sprintf("| %s | %d | -%d |\n", d.Reason, d.Issues, d.Points))
+			total += d.Points
+		}
+		b.WriteString(fmt.Sprintf("| **Total** (from 100, floored at 0) | %d | -%d |\n\n", s.IssuesFound, total))
+	}
+
+	b.WriteString("## What this tool could extract\n")
+	b.WriteString(fmt.Sprintf("- Causes: %d\n- State changes: %d\n- View updates: %d\n", s.TotalCauses, s.TotalStateChanges, s.TotalViewUpdates))
+	if r.Input.SourceRoot != "" {
+		b.WriteString(fmt.Sprintf("- Source: %d Swift files under `%s`, %d correlations\n", r.Input.SwiftFiles, r.Input.SourceRoot, len(r.SourceCorrelations)))
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Issues by severity\n\n")
+	if len(r.Issues) == 0 {
+		b.WriteString("No issues detected.\n\n")
+	}
+	n := 0
+	for _, h := range severityHeadings {
+		var group []aioutput.IssueWithFixes
+		for _, is := range r.Issues {
+			if is.Severity == h.severity {
+				group = append(group, is)
+			}
+		}
+		if len(group) == 0 {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("### %s (%d)\n\n", h.title, len(group)))
+		for _, is := range group {
+			n++
+			writeIssue(&b, n, is, lookup, best)
+		}
+	}
+
+	var views []aioutput.NodeData
+	for _, nd := range r.Graph.Nodes {
+		if nd.Type == "view" {
+			views = append(views, nd)
+		}
+	}
+	sort.SliceStable(views, func(i, j int) bool { return views[i].UpdateCount > views[j].UpdateCount })
+	if len(views) > topViews {
+		views = views[:topViews]
+	}
+	b.WriteString("## Top view-update nodes (best effort)\n")
+	if len(views) == 0 {
+		b.WriteString("No explicit counts found in exported data.\n\n")
+	} else {
+		for _, v := range views {
+			if v.InstanceCount > 1 {
+				b.WriteString(fmt.Sprintf("- %s (count=%d across %d instances)\n", v.Label, v.UpdateCount, v.InstanceCount))
+			} else {
+				b.WriteString(fmt.Sprintf("- %s (count=%d)\n", v.Label, v.UpdateCount))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.Recommendations) > 0 {
+		b.WriteString("## Recommendations\n")
+		for _, rec := range r.Recommendations {
+			b.WriteString(fmt.Sprintf("- **%s**: %s\n", rec.Title, rec.Description))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Notes\n")
+	b.WriteString("- The SwiftUI Cause & Effect Graph is collected by the SwiftUI instrument (Xcode 26) and is primarily designed for interactive use in Instruments.\n")
+	b.WriteString("- Export schemas can change; this CLI uses heuristic parsing and may miss relationships.\n")
+	b.WriteString("- If export produced no parseable artifacts, open the .trace in Instruments and use the Cause & Effect Graph UI.\n\n")
+
+	if len(r.Input.ParseHints) > 0 {
+		b.WriteString("## Parse hints\n")
+		for _, h := range r.Input.ParseHints {
+			b.WriteString("- " + h + "\n")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("## Outputs\n")
+	b.WriteString("- Graphviz: see the generated `.dot` file (render with `dot -Tpng graph.dot -o graph.png`, or pass `-svg` to draw it without Graphviz).\n")
+	return b.String()
+}
+
+func writeIssue(b *strings.Builder, n int, is aioutput.IssueWithFixes, lookup func(string) (aioutput.NodeData, bool), best map[string]correlation.SourceMatch) {
+	b.WriteString(fmt.Sprintf("#### %d. %s\n\n", n, is.Title))
+	if is.Description != "" {
+		b.WriteString(is.Description + "\n\n")
+	}
+	if is.Impact != "" {
+		b.WriteString("- Impact: " + is.Impact + "\n")
+	}
+	if is.UpdateCount > 0 {
+		b.WriteString(fmt.Sprintf("- Updates: %d\n", is.UpdateCount))
+	}
+	if len(is.CauseChain) > 0 {
+		var chain []string
+		for _, ref := range is.CauseChain {
+			if nd, ok := lookup(ref); ok {
+				ref = nd.Label
+			}
+			chain = append(chain, ref)
+		}
+		b.WriteString("- Cause chain: " + strings.Join(chain, " → ") + "\n")
+	}
+
+	// Source locations of the affected nodes, with the matched line
+	var snippets []correlation.SourceMatch
+	for _, ref := range is.AffectedNodes {
+		nd, ok := lookup(ref)
+		if !ok {
+			continue
+		}
+		if m, ok := best[nd.ID]; ok {
+			b.WriteString(fmt.Sprintf("- Source: `%s:%d` (%s)\n", m.RelativePath, m.LineNumber, nd.Label))
+			if m.CodeSnippet != "" {
+				snippets = append(snippets, m)
+			}
+		}
+	}
+	b.WriteString("\n")
+	for _, m := range snippets {
+		b.WriteString(fmt.Sprintf("```swift\n// %s:%d\n%s\n```\n\n", m.RelativePath, m.LineNumber, m.CodeSnippet))
+	}
+
+	if len(is.SuggestedFixes) > 0 {
+		b.WriteString("Recommended fixes:\n\n")
+		for i, f := range is.SuggestedFixes {
+			b.WriteString(fmt.Sprintf("%d. **%s** (effort: %s, impact: %s) — %s", i+1, f.Approach, f.Effort, f.Impact, f.Description))
+			if f.Expected != nil {
+				b.WriteString(fmt.Sprintf(" Expected: %s.", f.Expected))
+			}
+			if a := f.Availability; a != nil && !a.Compatible {
+				b.WriteString(fmt.Sprintf(" _Not available: %s._", a.Reason))
+			} else if a.Unchecked() {
+				b.WriteString(fmt.Sprintf(" _Requires %s._", a.Requires))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+		if f := is.SuggestedFixes[0]; f.CodeAfter != "" {
+			b.WriteString(fmt.Sprintf("```swift\n%s\n```\n\n", strings.TrimRight(f.CodeAfter, "\n")))
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package analyze
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/correlation"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/suggestions"
+)
+
+func TestRenderMarkdown_Report(t *testing.T) {
+	r := &aioutput.Report{
+		Input:   aioutput.InputInfo{FilesParsed: 2, ParseHints: []string{"JSON parse skipped a.json"}},
+		Summary: aioutput.Summary{IssuesFound: 2, CriticalIssues: 1, PerformanceScore: 72, HealthStatus: "warning"},
+		Issues: []aioutput.IssueWithFixes{
+			{Issue: issues.Issue{ID: "i2", Severity: issues.SeverityLow, Title: "Timer churn", AffectedNodes: []string{"clock"}}},
+			{
+				Issue: issues.Issue{ID: "i1", Severity: issues.SeverityCritical, Title: "Rows re-render", Description: "ItemRow updates 240 times",
+					AffectedNodes: []string{"ItemRow"}, CauseChain: []string{"tap", "query", "row"}, UpdateCount: 240},
+				SuggestedFixes: []suggestions.Fix{{Approach: "Equatable rows", Description: "Skip unchanged rows", Effort: "low", Impact: "high", CodeAfter: "struct ItemRow: View, Equatable {"}},
+			},
+		},
+		Graph: aioutput.GraphData{Nodes: []aioutput.NodeData{
+			{ID: "tap", Label: "Keystroke", Type: "cause"},
+			{ID: "query", Label: "@State query", Type: "state"},
+			{ID: "row", Label: "ItemRow", Type: "view", UpdateCount: 240, InstanceCount: 12},
+			{ID: "clock", Label: "ClockView", Type: "view", UpdateCount: 3},
+		}},
+		SourceCorrelations: []correlation.SourceMatch{
+			{TraceNodeID: "row", RelativePath: "Views/ItemRow.swift", LineNumber: 3, Confidence: 0.9, CodeSnippet: "struct ItemRow: View {"},
+			{TraceNodeID: "row", RelativePath: "Views/Other.swift", LineNumber: 9, Confidence: 0.2},
+		},
+		Recommendations: []suggestions.Recommendation{{Title: "Split views", Description: "Keep bodies small"}},
+	}
+
+	md := RenderMarkdown(r)
+	for _, want := range []string{
+		"## Performance score: 72/100 (warning)",
+		"| critical issues (25 each) | 1 | -25 |",
+		"| other issues (3 each) | 1 | -3 |",
+		"### Critical (1)\n\n#### 1. Rows re-render",
+		"### Low (1)\n\n#### 2. Timer churn",
+		"- Cause chain: Keystroke → @State query → ItemRow",
+		"- Source: `Views/ItemRow.swift:3` (ItemRow)",
+		"```swift\n// Views/ItemRow.swift:3\nstruct ItemRow: View {\n```",
+		"1. **Equatable rows** (effort: low, impact: high) — Skip unchanged rows",
+		"```swift\nstruct ItemRow: View, Equatable {\n```",
+		"- ItemRow (count=240 across 12 instances)",
+		"- **Split views**: Keep bodies small",
+		"## Parse hints\n- JSON parse skipped a.json",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected %q in summary:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "Other.swift") {
+		t.Error("only the most confident match should be shown")
+	}
+}
+
+func TestSummarize_Findings(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"nodes":[{"id":"s","label":"@State items","type":"state"},{"id":"v","label":"RowView","type":"view","count":50}],"edges":[{"from":"s","to":"v"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "graph.json"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(dir, "src")
+	if err := os.Mkdir(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "RowView.swift"), []byte("import SwiftUI\n\nstruct RowView: View {\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Summarize(Options{
+		Input:      dir,
+		SourceRoot: src,
+		OutSummary: filepath.Join(dir, "summary.md"),
+		OutDOT:     filepath.Join(dir, "graph.dot"),
+	})
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	b, err := os.ReadFile(res.SummaryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := string(b)
+	for _, want := range []string{"## Issues by severity", "Recommended fixes:", "- Source: `RowView.swift:3` (RowView)"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected %q in summary:\n%s", want, md)
+		}
+	}
+}
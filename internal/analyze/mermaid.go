@@ -0,0 +1,226 @@
+package analyze
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+// MermaidOptions controls RenderMermaid
+type MermaidOptions struct {
+	// TopN keeps only the N heaviest root-to-leaf paths (0 = whole graph).
+	// Paths through highlighted nodes are kept first.
+	TopN int
+	// Highlight marks nodes (e.g. from issues.AffectedNodeIDs); they and the
+	// edges leading into them are styled as hot.
+	Highlight []string
+}
+
+// maxMermaidPaths bounds path enumeration on dense graphs
+const maxMermaidPaths = 10000
+
+// RenderMermaid renders the graph as a Mermaid flowchart, which GitHub
+// renders inline in issues and pull requests.
+func RenderMermaid(g *graph.Graph, opts MermaidOptions) string {
+	hot := map[string]bool{}
+	for _, id := range opts.Highlight {
+		if _, ok := g.Nodes[id]; ok {
+			hot[id] = true
+		}
+	}
+
+	keep := map[string]bool{}
+	var note string
+	if opts.TopN > 0 {
+		paths, truncated := rootToLeafPaths(g)
+		sort.SliceStable(paths, func(i, j int) bool {
+			hi, hj := touches(paths[i], hot), touches(paths[j], hot)
+			if hi != hj {
+				return hi
+			}
+			return pathWeight(g, paths[i]) > pathWeight(g, paths[j])
+		})
+		if len(paths) > opts.TopN {
+			more := ""
+			if truncated {
+				more = "+"
+			}
+			note = fmt.Sprintf("%%%% showing the %d heaviest of %d%s paths\n", opts.TopN, len(paths), more)
+			paths = paths[:opts.TopN]
+		}
+		for _, p := range paths {
+			for _, id := range p {
+				keep[id] = true
+			}
+		}
+	} else {
+		for id := range g.Nodes {
+			keep[id] = true
+		}
+	}
+
+	feeds := issuePathNodes(g, hot)
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	b.WriteString(note)
+
+	ids := map[string]string{}
+	for i, n := range g.SortedNodes() {
+		if !keep[n.ID] {
+			continue
+		}
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+		b.WriteString("  " + ids[n.ID] + mermaidShape(n) + "\n")
+	}
+
+	var hotLinks []string
+	link := 0
+	for _, e := range g.SortedEdges() {
+		from, okFrom := ids[e.From]
+		to, okTo := ids[e.To]
+		if !okFrom || !okTo {
+			continue
+		}
+		if e.Label != "" {
+			fmt.Fprintf(&b, "  %s -->|%s| %s\n", from, escapeMermaid(e.Label), to)
+		} else {
+			fmt.Fprintf(&b, "  %s --> %s\n", from, to)
+		}
+		if feeds[e.From] && feeds[e.To] {
+			hotLinks = append(hotLinks, fmt.Sprint(link))
+		}
+		link++
+	}
+
+	var hotNodes []string
+	for _, id := range opts.Highlight {
+		if mid, ok := ids[id]; ok && hot[id] {
+			hotNodes = append(hotNodes, mid)
+		}
+	}
+	if len(hotNodes) > 0 {
+		b.WriteString("  classDef hot fill:#ffd6d6,stroke:#c62828,stroke-width:2px\n")
+		b.WriteString("  class " + strings.Join(hotNodes, ",") + " hot\n")
+	}
+	if len(hotLinks) > 0 {
+		b.WriteString("  linkStyle " + strings.Join(hotLinks, ",") + " stroke:#c62828,stroke-width:2px\n")
+	}
+	return b.String()
+}
+
+// mermaidShape returns the node declaration suffix: stadium for causes,
+// hexagon for state, rectangle for views and a flag shape for the rest.
+func mermaidShape(n *graph.Node) string {
+	label := escapeMermaid(n.Label)
+	if label == "" {
+		label = escapeMermaid(n.ID)
+	}
+	if n.Count > 0 {
+		label = fmt.Sprintf("%s<br/>count=%d", label, n.Count)
+	}
+	switch n.Type {
+	case graph.NodeCause:
+		return `(["` + label + `"])`
+	case graph.NodeState:
+		return `{{"` + label + `"}}`
+	case graph.NodeView:
+		return `["` + label + `"]`
+	}
+	return `>"` + label + `"]`
+}
+
+// WriteMermaid writes the flowchart to path. A .md path gets a ```mermaid
+// fence so the file can be pasted into an issue or PR as is.
+func WriteMermaid(path string, g *graph.Graph, opts MermaidOptions) error {
+	out := RenderMermaid(g, opts)
+	if strings.EqualFold(filepath.Ext(path), ".md") {
+		out = "```mermaid\n" + out + "```\n"
+	}
+	return os.WriteFile(path, []byte(out), 0o644)
+}
+
+// escapeMermaid makes text safe inside a quoted Mermaid label
+func escapeMermaid(s string) string {
+	r := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "|", "#124;", "\n", " ")
+	return r.Replace(trim(s, 80))
+}
+
+// rootToLeafPaths enumerates simple paths from nodes without predecessors
+// to nodes without successors; nodes on pure cycles are reached from their
+// lowest ID. The second result reports whether enumeration was cut short.
+func rootToLeafPaths(g *graph.Graph) ([][]string, bool) {
+	hasPred := map[string]bool{}
+	hasSucc := map[string]bool{}
+	for _, e := range g.Edges {
+		hasPred[e.To] = true
+		hasSucc[e.From] = true
+	}
+
+	var paths [][]string
+	truncated := false
+	reached := map[string]bool{}
+	onPath := map[string]bool{}
+	var walk func(path []string)
+	walk = func(path []string) {
+		if len(paths) >= maxMermaidPaths {
+			truncated = true
+			return
+		}
+		id := path[len(path)-1]
+		reached[id] = true
+		extended := false
+		for _, next := range g.Successors(id) {
+			if onPath[next] {
+				continue
+			}
+			extended = true
+			onPath[next] = true
+			walk(append(path, next))
+			onPath[next] = false
+		}
+		if !extended {
+			paths = append(paths, append([]string(nil), path...))
+		}
+	}
+
+	start := func(id string) {
+		onPath[id] = true
+		walk([]string{id})
+		onPath[id] = false
+	}
+	for _, n := range g.SortedNodes() {
+		if !hasPred[n.ID] {
+			start(n.ID)
+		}
+	}
+	for _, n := range g.SortedNodes() {
+		if !reached[n.ID] && hasSucc[n.ID] {
+			start(n.ID)
+		}
+	}
+	return paths, truncated
+}
+
+func pathWeight(g *graph.Graph, path []string) int {
+	w := 0
+	for _, id := range path {
+		if n, ok := g.Nodes[id]; ok {
+			w += n.Count
+		}
+	}
+	return w
+}
+
+func touches(path []string, hot map[string]bool) bool {
+	for _, id := range path {
+		if hot[id] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package analyze
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+func mermaidGraph() *graph.Graph {
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "tap", Label: "Button tap", Type: graph.NodeCause, Count: 2})
+	g.UpsertNode(&graph.Node{ID: "timer", Label: "Timer", Type: graph.NodeCause, Count: 60})
+	g.UpsertNode(&graph.Node{ID: "items", Label: `@State "items"`, Type: graph.NodeState})
+	g.UpsertNode(&graph.Node{ID: "now", Label: "@Published now", Type: graph.NodeState})
+	g.UpsertNode(&graph.Node{ID: "row", Label: "ForEach<RowView>", Type: graph.NodeView, Count: 40})
+	g.UpsertNode(&graph.Node{ID: "clock", Label: "ClockView", Type: graph.NodeView, Count: 60})
+	g.UpsertNode(&graph.Node{ID: "misc", Label: "Other", Type: graph.NodeOther})
+	g.AddEdge(graph.Edge{From: "tap", To: "items", Label: "causes"})
+	g.AddEdge(graph.Edge{From: "items", To: "row", Label: "updates"})
+	g.AddEdge(graph.Edge{From: "timer", To: "now"})
+	g.AddEdge(graph.Edge{From: "now", To: "clock"})
+	return g
+}
+
+func TestRenderMermaid(t *testing.T) {
+	out := RenderMermaid(mermaidGraph(), MermaidOptions{})
+
+	if !strings.HasPrefix(out, "flowchart LR\n") {
+		t.Errorf("missing flowchart header:\n%s", out)
+	}
+	for _, want := range []string{
+		`(["Timer<br/>count=60"])`,                // cause: stadium
+		`{{"@State #quot;items#quot;"}}`,          // state: hexagon, quotes escaped
+		`["ForEach#lt;RowView#gt;<br/>count=40"]`, // view: rectangle, brackets escaped
+		`>"Other"]`, // other
+		`-->|causes|`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "classDef hot") {
+		t.Error("nothing should be highlighted without highlight IDs")
+	}
+	if out != RenderMermaid(mermaidGraph(), MermaidOptions{}) {
+		t.Error("output should be deterministic")
+	}
+}
+
+func TestRenderMermaid_Highlight(t *testing.T) {
+	out := RenderMermaid(mermaidGraph(), MermaidOptions{Highlight: []string{"row", "missing"}})
+	if !strings.Contains(out, "classDef hot") {
+		t.Fatalf("missing hot class:\n%s", out)
+	}
+	// Nodes sorted by ID: clock n0, items n1, misc n2, now n3, row n4, ...
+	if !strings.Contains(out, "class n4 hot") {
+		t.Errorf("expected row node highlighted:\n%s", out)
+	}
+	// Edges sorted: items→row (0), now→clock (1), tap→items (2), timer→now (3)
+	if !strings.Contains(out, "linkStyle 0,2 ") {
+		t.Errorf("expected the path into row highlighted:\n%s", out)
+	}
+}
+
+func TestRenderMermaid_TopN(t *testing.T) {
+	out := RenderMermaid(mermaidGraph(), MermaidOptions{TopN: 1})
+	if !strings.Contains(out, "ClockView") || strings.Contains(out, "RowView") {
+		t.Errorf("expected only the heaviest (timer) path:\n%s", out)
+	}
+	if !strings.Contains(out, "%% showing the 1 heaviest of 3 paths") {
+		t.Errorf("expected pruning note:\n%s", out)
+	}
+
+	// Highlighted paths win over heavier ones
+	out = RenderMermaid(mermaidGraph(), MermaidOptions{TopN: 1, Highlight: []string{"row"}})
+	if !strings.Contains(out, "RowView") || strings.Contains(out, "ClockView") {
+		t.Errorf("expected the highlighted path to be kept:\n%s", out)
+	}
+}
+
+func TestRootToLeafPaths_Cycle(t *testing.T) {
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "a", Label: "A"})
+	g.UpsertNode(&graph.Node{ID: "b", Label: "B"})
+	g.AddEdge(graph.Edge{From: "a", To: "b"})
+	g.AddEdge(graph.Edge{From: "b", To: "a"})
+	paths, truncated := rootToLeafPaths(g)
+	if truncated || len(paths) != 1 || strings.Join(paths[0], ",") != "a,b" {
+		t.Errorf("unexpected paths for a pure cycle: %v", paths)
+	}
+}
+
+func TestSummarize_Mermaid(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"nodes":[{"id":"s","label":"@State items","type":"state"},{"id":"v","label":"RowView","type":"view","count":50}],"edges":[{"from":"s","to":"v"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "graph.json"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "graph.md")
+	res, err := Summarize(Options{
+		Input:      dir,
+		OutSummary: filepath.Join(dir, "summary.md"),
+		OutDOT:     filepath.Join(dir, "graph.dot"),
+		OutMermaid: out,
+	})
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if res.MermaidPath != out {
+		t.Errorf("expected mermaid path %s, got %s", out, res.MermaidPath)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := string(b)
+	if !strings.HasPrefix(md, "```mermaid\nflowchart LR\n") || !strings.HasSuffix(md, "```\n") {
+		t.Errorf("expected a fenced flowchart:\n%s", md)
+	}
+	// RowView re-renders excessively, so it is highlighted
+	if !strings.Contains(md, "classDef hot") {
+		t.Errorf("expected issue highlighting:\n%s", md)
+	}
+}
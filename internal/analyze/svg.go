@@ -0,0 +1,201 @@
+package analyze
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/layout"
+)
+
+// legendHeight is the strip reserved below the graph for the legend
+const legendHeight = 56
+
+// RenderSVG draws the graph as a standalone SVG using the built-in layered
+// layout, so no Graphviz install is needed. Shapes, heat colors, issue
+// highlighting, pruning and the legend follow DOTOptions; source clusters
+// (Groups) are only drawn by the DOT output.
+func RenderSVG(g *graph.Graph, opts DOTOptions) string {
+	keep := pruneByCount(g, opts.MinCount)
+	ids := make([]string, 0, len(keep))
+	for id := range keep {
+		ids = append(ids, id)
+	}
+	sub := g.Subgraph(ids)
+
+	hot := map[string]bool{}
+	for _, id := range opts.Highlight {
+		if keep[id] {
+			hot[id] = true
+		}
+	}
+	feeds := issuePathNodes(sub, hot)
+
+	maxCount := 0
+	for _, n := range sub.Nodes {
+		if n.Count > maxCount {
+			maxCount = n.Count
+		}
+	}
+
+	lopts := layout.DefaultOptions()
+	l := layout.Compute(sub, lopts)
+	width, height := l.Width, l.Height
+	if opts.Legend {
+		items := 3
+		if opts.Heatmap {
+			items += 2
+		}
+		if len(hot) > 0 {
+			items++
+		}
+		width = math.Max(width, 40+104*float64(items))
+		height += legendHeight
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" font-family="Helvetica, Arial, sans-serif" font-size="12">`+"\n", width, height, width, height)
+	b.WriteString("<defs>\n")
+	for _, m := range []struct{ id, color string }{{"arrow", "#333333"}, {"arrow-hot", "#c62828"}} {
+		fmt.Fprintf(&b, `  <marker id="%s" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="7" markerHeight="7" orient="auto"><path d="M0,0L10,5L0,10z" fill="%s"/></marker>`+"\n", m.id, m.color)
+	}
+	b.WriteString("</defs>\n")
+	b.WriteString(`<rect width="100%" height="100%" fill="#ffffff"/>` + "\n")
+
+	b.WriteString(`<g class="edges">` + "\n")
+	for _, r := range l.Routes {
+		stroke, marker, sw := "#333333", "arrow", "1"
+		if feeds[r.From] && feeds[r.To] {
+			stroke, marker, sw = "#c62828", "arrow-hot", "2.5"
+		}
+		fmt.Fprintf(&b, `  <path d="%s" fill="none" stroke="%s" stroke-width="%s" marker-end="url(#%s)"/>`+"\n", svgPath(r), stroke, sw, marker)
+		if r.Label != "" && !r.SelfLoop {
+			k := (len(r.Points) - 1) / 2
+			p, q := r.Points[k], r.Points[k+1]
+			fmt.Fprintf(&b, `  <text x="%.1f" y="%.1f" text-anchor="middle" font-size="10" fill="#555555">%s</text>`+"\n", (p.X+q.X)/2, (p.Y+q.Y)/2-4, html.EscapeString(lopts.Truncate(r.Label)))
+		}
+	}
+	b.WriteString("</g>\n")
+
+	b.WriteString(`<g class="nodes">` + "\n")
+	for _, n := range sub.SortedNodes() {
+		box := l.Boxes[n.ID]
+		fill := "#ffffff"
+		if opts.Heatmap {
+			fill = hsvHex(heatHSV(n.Count, maxCount))
+		}
+		stroke, sw := "#333333", "1"
+		if hot[n.ID] {
+			stroke, sw = "#c62828", "2.5"
+		}
+		label := n.Label
+		if label == "" {
+			label = n.ID
+		}
+		title := label
+		if n.Count > 0 {
+			title = fmt.Sprintf("%s (count=%d)", label, n.Count)
+		}
+		fmt.Fprintf(&b, `  <g id="%s"><title>%s</title>`, html.EscapeString("node-"+n.ID), html.EscapeString(title))
+		b.WriteString(svgShape(n.Type, box.X, box.Y, box.W, box.H, fill, stroke, sw))
+		cx, cy := box.X+box.W/2, box.Y+box.H/2
+		text := html.EscapeString(lopts.Truncate(label))
+		if n.Count > 0 {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle">%s<tspan x="%.1f" dy="14" font-size="10" fill="#555555">count=%d</tspan></text>`, cx, cy-2, text, cx, n.Count)
+		} else {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle">%s</text>`, cx, cy+4, text)
+		}
+		b.WriteString("</g>\n")
+	}
+	b.WriteString("</g>\n")
+
+	if opts.Legend {
+		writeSVGLegend(&b, l.Height, width, opts.Heatmap, len(hot) > 0)
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// WriteSVG renders the graph to path
+func WriteSVG(path string, g *graph.Graph, opts DOTOptions) error {
+	return os.WriteFile(path, []byte(RenderSVG(g, opts)), 0o644)
+}
+
+// svgShape draws the DOT shapes: ellipse for causes, diamond for state and
+// box for everything else.
+func svgShape(t graph.NodeType, x, y, w, h float64, fill, stroke, sw string) string {
+	style := fmt.Sprintf(`fill="%s" stroke="%s" stroke-width="%s"`, fill, stroke, sw)
+	switch dotShape(t) {
+	case "ellipse":
+		return fmt.Sprintf(`<ellipse cx="%.1f" cy="%.1f" rx="%.1f" ry="%.1f" %s/>`, x+w/2, y+h/2, w/2, h/2, style)
+	case "diamond":
+		return fmt.Sprintf(`<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f" %s/>`, x+w/2, y, x+w, y+h/2, x+w/2, y+h, x, y+h/2, style)
+	}
+	return fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" %s/>`, x, y, w, h, style)
+}
+
+// svgPath joins the route points with horizontal-tangent curves; self
+// loops are drawn as a small arc off the node's right side.
+func svgPath(r layout.Route) string {
+	p := r.Points[0]
+	if r.SelfLoop {
+		return fmt.Sprintf("M%.1f,%.1f C%.1f,%.1f %.1f,%.1f %.1f,%.1f", p.X, p.Y-6, p.X+34, p.Y-30, p.X+34, p.Y+30, p.X, p.Y+6)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "M%.1f,%.1f", p.X, p.Y)
+	for _, q := range r.Points[1:] {
+		dx := (q.X - p.X) / 2
+		fmt.Fprintf(&b, " C%.1f,%.1f %.1f,%.1f %.1f,%.1f", p.X+dx, p.Y, q.X-dx, q.Y, q.X, q.Y)
+		p = q
+	}
+	return b.String()
+}
+
+func writeSVGLegend(b *strings.Builder, top, width float64, heatmap, highlight bool) {
+	y := top + 8
+	fmt.Fprintf(b, `<g class="legend"><line x1="20" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#cccccc" stroke-dasharray="4 3"/>`+"\n", y, width-20, y)
+	x := 20.0
+	item := func(t graph.NodeType, label, fill, stroke, sw string) {
+		b.WriteString("  " + svgShape(t, x, y+10, 96, 28, fill, stroke, sw))
+		fmt.Fprintf(b, `<text x="%.1f" y="%.1f" text-anchor="middle">%s</text>`+"\n", x+48, y+28, label)
+		x += 104
+	}
+	item(graph.NodeCause, "cause", "#ffffff", "#333333", "1")
+	item(graph.NodeState, "state", "#ffffff", "#333333", "1")
+	item(graph.NodeView, "view", "#ffffff", "#333333", "1")
+	if heatmap {
+		item(graph.NodeView, "few updates", hsvHex(heatHSV(1, 100)), "#333333", "1")
+		item(graph.NodeView, "many updates", hsvHex(heatHSV(100, 100)), "#333333", "1")
+	}
+	if highlight {
+		item(graph.NodeView, "issue", "#ffffff", "#c62828", "2.5")
+	}
+	b.WriteString("</g>\n")
+}
+
+// hsvHex converts an HSV color (components 0..1) to #rrggbb
+func hsvHex(h, s, v float64) string {
+	h = math.Mod(h, 1) * 6
+	i := math.Floor(h)
+	f := h - i
+	p, q, t := v*(1-s), v*(1-s*f), v*(1-s*(1-f))
+	var r, g, b float64
+	switch int(i) {
+	case 0:
+		r, g, b = v, t, p
+	case 1:
+		r, g, b = q, v, p
+	case 2:
+		r, g, b = p, v, t
+	case 3:
+		r, g, b = p, q, v
+	case 4:
+		r, g, b = t, p, v
+	default:
+		r, g, b = v, p, q
+	}
+	return fmt.Sprintf("#%02x%02x%02x", int(math.Round(r*255)), int(math.Round(g*255)), int(math.Round(b*255)))
+}
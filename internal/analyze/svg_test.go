@@ -0,0 +1,103 @@
+package analyze
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// wellFormed fails the test unless out parses as XML
+func wellFormed(t *testing.T, out string) {
+	t.Helper()
+	d := xml.NewDecoder(strings.NewReader(out))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("invalid SVG: %v\n%s", err, out)
+		}
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	out := RenderSVG(mermaidGraph(), DOTOptions{})
+	wellFormed(t, out)
+
+	if !strings.HasPrefix(out, `<svg xmlns="http://www.w3.org/2000/svg"`) {
+		t.Errorf("missing svg root:\n%s", out)
+	}
+	for _, want := range []string{
+		`<g id="node-timer"><title>Timer (count=60)</title><ellipse`,       // cause
+		`<g id="node-items"><title>@State &#34;items&#34;</title><polygon`, // state, escaped
+		`<g id="node-row"><title>ForEach&lt;RowView&gt; (count=40)</title><rect`,
+		`>causes</text>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	if got := strings.Count(out, `<path d="M`) - 2; got != 4 {
+		t.Errorf("expected 4 edges, got %d", got)
+	}
+	if out != RenderSVG(mermaidGraph(), DOTOptions{}) {
+		t.Error("output should be deterministic")
+	}
+}
+
+func TestRenderSVG_Options(t *testing.T) {
+	out := RenderSVG(mermaidGraph(), DOTOptions{Heatmap: true, Highlight: []string{"row"}, MinCount: 2, Legend: true})
+	wellFormed(t, out)
+
+	if strings.Contains(out, "node-misc") {
+		t.Errorf("misc should be pruned:\n%s", out)
+	}
+	// hottest nodes are red
+	if !strings.Contains(out, `fill="#ff4040"`) {
+		t.Errorf("expected red fill for the hottest node:\n%s", out)
+	}
+	if !strings.Contains(out, `stroke="#c62828" stroke-width="2.5" marker-end="url(#arrow-hot)"`) {
+		t.Errorf("expected a highlighted issue edge:\n%s", out)
+	}
+	if !strings.Contains(out, `<g class="legend">`) || !strings.Contains(out, ">many updates<") || !strings.Contains(out, ">issue<") {
+		t.Errorf("expected a full legend:\n%s", out)
+	}
+}
+
+func TestSummarize_SVG(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"nodes":[{"id":"s","label":"@State items","type":"state"},{"id":"v","label":"RowView","type":"view","count":50}],"edges":[{"from":"s","to":"v"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "graph.json"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+	if err := os.Mkdir(out, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	res, err := Summarize(Options{
+		Input:      dir,
+		OutSummary: filepath.Join(out, "summary.md"),
+		OutDOT:     filepath.Join(out, "graph.dot"),
+		OutSVG:     filepath.Join(out, "graph.svg"),
+	})
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	b, err := os.ReadFile(res.SVGPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wellFormed(t, string(b))
+
+	md, err := os.ReadFile(res.SummaryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(md), "![Cause & Effect graph](graph.svg)") {
+		t.Errorf("summary should embed the image:\n%s", md)
+	}
+}
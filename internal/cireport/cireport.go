@@ -0,0 +1,251 @@
+// Package cireport renders analysis findings in formats CI systems
+// understand: JUnit XML for test dashboards, GitHub Actions workflow
+// commands for inline annotations and Xcode diagnostics for build phases.
+package cireport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+)
+
+// Options controls which findings count as failures
+type Options struct {
+	// FailOn is the lowest severity reported as a failure (default high);
+	// less severe issues are still listed, as output or warnings.
+	FailOn issues.Severity
+	// MinScore is the performance score budget; a lower score fails the
+	// performance_score test case. Zero disables the budget.
+	MinScore int
+}
+
+func (o Options) failOn() issues.Severity {
+	if o.FailOn == "" {
+		return issues.SeverityHigh
+	}
+	return o.FailOn
+}
+
+type testSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Suites   []testSuite `xml:"testsuite"`
+}
+
+type testSuite struct {
+	Name       string     `xml:"name,attr"`
+	Tests      int        `xml:"tests,attr"`
+	Failures   int        `xml:"failures,attr"`
+	Timestamp  string     `xml:"timestamp,attr,omitempty"`
+	Properties []property `xml:"properties>property,omitempty"`
+	Cases      []testCase `xml:"testcase"`
+}
+
+type property struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type testCase struct {
+	Name      string   `xml:"name,attr"`
+	Classname string   `xml:"classname,attr"`
+	Failure   *failure `xml:"failure,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty"`
+}
+
+type failure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes one test case per detector, failing when it found an
+// issue at or above Options.FailOn, plus a performance_score budget case.
+func WriteJUnit(w io.Writer, r *aioutput.Report, opts Options) error {
+	min := opts.failOn()
+	suite := testSuite{
+		Name: "swiftuice",
+		Properties: []property{
+			{Name: "performance_score", Value: fmt.Sprint(r.Summary.PerformanceScore)},
+			{Name: "health_status", Value: r.Summary.HealthStatus},
+			{Name: "fail_on", Value: string(min)},
+		},
+	}
+	if !r.Generated.IsZero() {
+		suite.Timestamp = r.Generated.UTC().Format("2006-01-02T15:04:05")
+	}
+
+	byType := map[issues.IssueType][]aioutput.IssueWithFixes{}
+	for _, is := range r.Issues {
+		byType[is.Type] = append(byType[is.Type], is)
+	}
+	types := append([]issues.IssueType(nil), issues.DetectorTypes...)
+	for t := range byType {
+		if !containsType(types, t) {
+			types = append(types, t) // from a report written by another version
+		}
+	}
+
+	for _, t := range types {
+		tc := testCase{Name: string(t), Classname: "swiftuice.detectors"}
+		var failing, passing []string
+		for _, is := range byType[t] {
+			if is.Severity.AtLeast(min) {
+				failing = append(failing, describe(r, is))
+			} else {
+				passing = append(passing, describe(r, is))
+			}
+		}
+		if len(failing) > 0 {
+			tc.Failure = &failure{
+				Message: fmt.Sprintf("%d issue(s) at or above %s", len(failing), min),
+				Type:    string(t),
+				Text:    strings.Join(failing, "\n\n"),
+			}
+		}
+		if len(passing) > 0 {
+			tc.SystemOut = fmt.Sprintf("Below %s:\n\n%s", min, strings.Join(passing, "\n\n"))
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	score := testCase{Name: "performance_score", Classname: "swiftuice.budgets",
+		SystemOut: fmt.Sprintf("score %d/100 (%s)", r.Summary.PerformanceScore, r.Summary.HealthStatus)}
+	if opts.MinScore > 0 {
+		score.SystemOut += fmt.Sprintf(", budget %d", opts.MinScore)
+		if r.Summary.PerformanceScore < opts.MinScore {
+			score.Failure = &failure{
+				Message: fmt.Sprintf("performance score %d is below the budget of %d", r.Summary.PerformanceScore, opts.MinScore),
+				Type:    "budget",
+			}
+		}
+	}
+	suite.Cases = append(suite.Cases, score)
+
+	suite.Tests = len(suite.Cases)
+	for _, tc := range suite.Cases {
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+	}
+	doc := testSuites{Name: "swiftuice", Tests: suite.Tests, Failures: suite.Failures, Suites: []testSuite{suite}}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteJUnitFile writes the JUnit XML to path
+func WriteJUnitFile(path string, r *aioutput.Report, opts Options) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteJUnit(f, r, opts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteAnnotations writes one GitHub Actions workflow command per issue:
+// ::error for issues at or above Options.FailOn, ::warning for the rest
+// and ::notice for informational ones. Issues with a correlated source
+// location are attached to that file and line.
+func WriteAnnotations(w io.Writer, r *aioutput.Report, opts Options) error {
+	min := opts.failOn()
+	for _, is := range r.Issues {
+		level := "warning"
+		switch {
+		case is.Severity.AtLeast(min):
+			level = "error"
+		case is.Severity == issues.SeverityInfo:
+			level = "notice"
+		}
+		var props []string
+		if file := sourcePath(r, is); file != "" {
+			props = append(props, "file="+escapeProperty(file))
+			if is.LineNumber > 0 {
+				props = append(props, fmt.Sprintf("line=%d", is.LineNumber))
+			}
+		}
+		props = append(props, "title="+escapeProperty(fmt.Sprintf("[%s] %s", is.Severity, is.Title)))
+		msg := is.Description
+		if len(is.SuggestedFixes) > 0 {
+			msg += "\nSuggested fix: " + is.SuggestedFixes[0].Approach
+		}
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", level, strings.Join(props, ","), escapeData(msg)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sourcePath returns the issue's source file as a path from the working
+// directory: correlated paths are relative to the report's source root.
+func sourcePath(r *aioutput.Report, is aioutput.IssueWithFixes) string {
+	if is.SourceFile == "" {
+		return ""
+	}
+	path := is.SourceFile
+	if !filepath.IsAbs(path) && r.Input.SourceRoot != "" {
+		path = filepath.Join(r.Input.SourceRoot, path)
+	}
+	if filepath.IsAbs(path) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+func describe(r *aioutput.Report, is aioutput.IssueWithFixes) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", is.Severity, is.Title)
+	if is.Description != "" {
+		b.WriteString("\n" + is.Description)
+	}
+	if file := sourcePath(r, is); file != "" {
+		fmt.Fprintf(&b, "\nat %s:%d", file, is.LineNumber)
+	}
+	if len(is.SuggestedFixes) > 0 {
+		b.WriteString("\nSuggested fix: " + is.SuggestedFixes[0].Approach)
+	}
+	return b.String()
+}
+
+func containsType(types []issues.IssueType, t issues.IssueType) bool {
+	for _, x := range types {
+		if x == t {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeData escapes a workflow command message
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a workflow command property value
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,100 @@
+package cireport
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/suggestions"
+)
+
+func sampleReport() *aioutput.Report {
+	return &aioutput.Report{
+		Input:   aioutput.InputInfo{SourceRoot: "App"},
+		Summary: aioutput.Summary{PerformanceScore: 62, HealthStatus: "warning"},
+		Issues: []aioutput.IssueWithFixes{
+			{
+				Issue: issues.Issue{Type: issues.IssueExcessiveRerender, Severity: issues.SeverityCritical, Title: "ItemRow re-renders",
+					Description: "240 updates, 100% of the time", SourceFile: "Views/ItemRow.swift", LineNumber: 12},
+				SuggestedFixes: []suggestions.Fix{{Approach: "Equatable rows"}},
+			},
+			{Issue: issues.Issue{Type: issues.IssueFrequentTrigger, Severity: issues.SeverityMedium, Title: "Timer fires often"}},
+			{Issue: issues.Issue{Type: issues.IssueWholeObjectPassing, Severity: issues.SeverityInfo, Title: "Store passed whole"}},
+		},
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, sampleReport(), Options{MinScore: 70}); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc testSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf.String())
+	}
+	suite := doc.Suites[0]
+	if suite.Tests != len(issues.DetectorTypes)+1 {
+		t.Errorf("expected one case per detector plus the score budget, got %d", suite.Tests)
+	}
+	if suite.Failures != 2 || doc.Failures != 2 {
+		t.Errorf("expected the critical issue and the budget to fail, got %d", suite.Failures)
+	}
+
+	cases := map[string]testCase{}
+	for _, tc := range suite.Cases {
+		cases[tc.Name] = tc
+	}
+	rerender := cases[string(issues.IssueExcessiveRerender)]
+	if rerender.Failure == nil || !strings.Contains(rerender.Failure.Text, "at App/Views/ItemRow.swift:12") {
+		t.Errorf("expected a located failure: %+v", rerender.Failure)
+	}
+	trigger := cases[string(issues.IssueFrequentTrigger)]
+	if trigger.Failure != nil || !strings.Contains(trigger.SystemOut, "Timer fires often") {
+		t.Errorf("medium issue should pass with output: %+v", trigger)
+	}
+	if cases["performance_score"].Failure == nil {
+		t.Error("score 62 should break the budget of 70")
+	}
+}
+
+func TestWriteJUnit_FailOn(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, sampleReport(), Options{FailOn: issues.SeverityMedium}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `<testsuites name="swiftuice" tests="7" failures="2">`) {
+		t.Errorf("expected critical and medium failures only:\n%s", buf.String())
+	}
+}
+
+func TestWriteAnnotations(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteAnnotations(&buf, sampleReport(), Options{}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		"::error file=App/Views/ItemRow.swift,line=12,title=[critical] ItemRow re-renders::240 updates, 100%25 of the time%0ASuggested fix: Equatable rows",
+		"::warning title=[medium] Timer fires often::",
+		"::notice title=[info] Store passed whole::",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d annotations, got:\n%s", len(want), buf.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("annotation %d:\n got %s\nwant %s", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestEscapeProperty(t *testing.T) {
+	if got := escapeProperty("a:b,c%\n"); got != "a%3Ab%2Cc%25%0A" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,160 @@
+package cireport
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+)
+
+// Target limits Xcode diagnostics to the source files of one build target.
+// The zero value accepts every file.
+type Target struct {
+	Dirs  []string        // files anywhere under these directories
+	Files map[string]bool // or exactly these files (absolute, cleaned)
+}
+
+// Empty reports whether the target accepts every file
+func (t Target) Empty() bool {
+	return len(t.Dirs) == 0 && len(t.Files) == 0
+}
+
+// Contains reports whether path (absolute) belongs to the target
+func (t Target) Contains(path string) bool {
+	if t.Empty() {
+		return true
+	}
+	path = filepath.Clean(path)
+	if t.Files[path] {
+		return true
+	}
+	for _, dir := range t.Dirs {
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadFileList adds the files listed in an Xcode .xcfilelist (or any file
+// with one path per line) to the target. Build setting references such as
+// $(SRCROOT) are expanded from the environment; relative paths are taken
+// from the list's directory.
+func (t *Target) ReadFileList(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if t.Files == nil {
+		t.Files = map[string]bool{}
+	}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = expandBuildSettings(line)
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(filepath.Dir(path), line)
+		}
+		abs, err := filepath.Abs(line)
+		if err != nil {
+			return err
+		}
+		t.Files[abs] = true
+	}
+	return s.Err()
+}
+
+// AddDir adds a directory of the target's sources
+func (t *Target) AddDir(dir string) error {
+	abs, err := filepath.Abs(expandBuildSettings(dir))
+	if err != nil {
+		return err
+	}
+	t.Dirs = append(t.Dirs, abs)
+	return nil
+}
+
+var reBuildSetting = regexp.MustCompile(`\$\(([A-Za-z_][A-Za-z0-9_]*)\)`)
+
+// expandBuildSettings expands $(NAME), ${NAME} and $NAME from the environment.
+// Other parentheses, as in "MyApp (Beta)", are left alone.
+func expandBuildSettings(s string) string {
+	return os.Expand(reBuildSetting.ReplaceAllString(s, "$${$1}"), os.Getenv)
+}
+
+// WriteXcode writes one clang-style diagnostic per issue so an Xcode Run
+// Script phase shows findings inline:
+//
+//	/abs/path/ItemRow.swift:12:1: warning: [excessive_rerender] ItemRow re-renders ...
+//
+// Issues at or above Options.FailOn are errors, informational ones notes
+// and the rest warnings. Located issues outside the target are dropped, as
+// are unlocated ones whenever a target is given. It returns the number of
+// errors written.
+func WriteXcode(w io.Writer, r *aioutput.Report, opts Options, target Target) (int, error) {
+	min := opts.failOn()
+	errors := 0
+	for _, is := range r.Issues {
+		loc := "swiftuice"
+		if file := absSourcePath(r, is); file != "" {
+			if !target.Contains(file) {
+				continue
+			}
+			line := is.LineNumber
+			if line < 1 {
+				line = 1
+			}
+			loc = fmt.Sprintf("%s:%d:1", file, line)
+		} else if !target.Empty() {
+			continue
+		}
+
+		level := "warning"
+		switch {
+		case is.Severity.AtLeast(min):
+			level = "error"
+			errors++
+		case is.Severity == issues.SeverityInfo:
+			level = "note"
+		}
+		msg := fmt.Sprintf("[%s] %s", is.Type, is.Title)
+		if is.Description != "" {
+			msg += " — " + is.Description
+		}
+		if len(is.SuggestedFixes) > 0 {
+			msg += " (suggested fix: " + is.SuggestedFixes[0].Approach + ")"
+		}
+		msg = strings.Join(strings.Fields(msg), " ") // one line per diagnostic
+		if _, err := fmt.Fprintf(w, "%s: %s: %s\n", loc, level, msg); err != nil {
+			return errors, err
+		}
+	}
+	return errors, nil
+}
+
+// absSourcePath returns the issue's source file as an absolute path, which
+// Xcode needs to jump to the line.
+func absSourcePath(r *aioutput.Report, is aioutput.IssueWithFixes) string {
+	if is.SourceFile == "" {
+		return ""
+	}
+	path := is.SourceFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.Input.SourceRoot, path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	return abs
+}
@@ -0,0 +1,108 @@
+package cireport
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+)
+
+func xcodeReport(root string) *aioutput.Report {
+	return &aioutput.Report{
+		Input: aioutput.InputInfo{SourceRoot: root},
+		Issues: []aioutput.IssueWithFixes{
+			{Issue: issues.Issue{Type: issues.IssueExcessiveRerender, Severity: issues.SeverityCritical, Title: "ItemRow re-renders",
+				Description: "240 updates\nin 10s", SourceFile: "App/ItemRow.swift", LineNumber: 12}},
+			{Issue: issues.Issue{Type: issues.IssueFrequentTrigger, Severity: issues.SeverityMedium, Title: "Widget timer",
+				SourceFile: "Widget/Clock.swift", LineNumber: 4}},
+			{Issue: issues.Issue{Type: issues.IssueWholeObjectPassing, Severity: issues.SeverityInfo, Title: "Store passed whole"}},
+		},
+	}
+}
+
+func TestWriteXcode(t *testing.T) {
+	root := t.TempDir()
+	var buf bytes.Buffer
+	errs, err := WriteXcode(&buf, xcodeReport(root), Options{}, Target{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs != 1 {
+		t.Errorf("expected 1 error, got %d", errs)
+	}
+	want := strings.Join([]string{
+		filepath.Join(root, "App/ItemRow.swift") + ":12:1: error: [excessive_rerender] ItemRow re-renders — 240 updates in 10s",
+		filepath.Join(root, "Widget/Clock.swift") + ":4:1: warning: [frequent_trigger] Widget timer",
+		"swiftuice: note: [whole_object_passing] Store passed whole",
+	}, "\n") + "\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteXcode_Target(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("SRCROOT", root)
+
+	var target Target
+	if err := target.AddDir("$(SRCROOT)/App"); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := WriteXcode(&buf, xcodeReport(root), Options{}, target); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(buf.String(), "\n"); got != 1 || !strings.Contains(buf.String(), "ItemRow.swift") {
+		t.Errorf("expected only the App finding:\n%s", buf.String())
+	}
+
+	list := filepath.Join(root, "Widget.xcfilelist")
+	if err := os.WriteFile(list, []byte("# widget sources\n$(SRCROOT)/Widget/Clock.swift\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	target = Target{}
+	if err := target.ReadFileList(list); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if _, err := WriteXcode(&buf, xcodeReport(root), Options{}, target); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(buf.String(), "\n"); got != 1 || !strings.Contains(buf.String(), "Clock.swift") {
+		t.Errorf("expected only the listed file:\n%s", buf.String())
+	}
+}
+
+func TestTargetContains(t *testing.T) {
+	target := Target{Dirs: []string{"/src/App"}}
+	for path, want := range map[string]bool{
+		"/src/App/ItemRow.swift":  true,
+		"/src/App/Sub/Row.swift":  true,
+		"/src/AppTests/Row.swift": false,
+		"/src/Widget/Clock.swift": false,
+		"/src/App/../Other.swift": false,
+	} {
+		if got := target.Contains(path); got != want {
+			t.Errorf("Contains(%s) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestExpandBuildSettings(t *testing.T) {
+	t.Setenv("SRCROOT", "/src/MyApp (Beta)")
+	t.Setenv("TARGET_NAME", "Widget")
+	for in, want := range map[string]string{
+		"$(SRCROOT)/App":                  "/src/MyApp (Beta)/App",
+		"${SRCROOT}/$TARGET_NAME":         "/src/MyApp (Beta)/Widget",
+		"/work/MyApp (Beta)/Sources":      "/work/MyApp (Beta)/Sources",
+		"$(SRCROOT)/Shared (old)/x.swift": "/src/MyApp (Beta)/Shared (old)/x.swift",
+	} {
+		if got := expandBuildSettings(in); got != want {
+			t.Errorf("expandBuildSettings(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Package compare diffs two analysis reports, typically of the same screen
+// before and after a change, to show which issues were fixed, which are new
+// and how update counts moved.
+package compare
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+)
+
+// DefaultTopNodes is the number of node count changes kept in a Diff
+const DefaultTopNodes = 20
+
+// IssueRef identifies an issue in one of the compared reports
+type IssueRef struct {
+	Key         string           `json:"key"`
+	ID          string           `json:"id"`
+	Type        issues.IssueType `json:"type"`
+	Severity    issues.Severity  `json:"severity"`
+	Title       string           `json:"title"`
+	UpdateCount int              `json:"update_count,omitempty"`
+}
+
+// IssueChange is an issue found in both reports
+type IssueChange struct {
+	Base IssueRef `json:"base"`
+	Head IssueRef `json:"head"`
+}
+
+// NodeChange is the update count of one view, state or cause in both reports
+type NodeChange struct {
+	Label string `json:"label"`
+	Type  string `json:"type"`
+	Base  int    `json:"base"`
+	Head  int    `json:"head"`
+	Delta int    `json:"delta"`
+}
+
+// Diff is the difference between a base and a head report
+type Diff struct {
+	BaseScore  int `json:"base_score"`
+	HeadScore  int `json:"head_score"`
+	ScoreDelta int `json:"score_delta"`
+
+	New       []IssueRef    `json:"new_issues"`
+	Resolved  []IssueRef    `json:"resolved_issues"`
+	Unchanged []IssueChange `json:"persisting_issues"`
+
+	// Largest update count changes by magnitude, matched by label
+	Nodes []NodeChange `json:"node_changes,omitempty"`
+}
+
+// Reports compares two reports. Issues are matched by type and the labels
+// of their affected nodes, since issue and node IDs are not stable across
+// recordings; topNodes limits the node changes kept (0 = DefaultTopNodes).
+func Reports(base, head *aioutput.Report, topNodes int) *Diff {
+	if topNodes <= 0 {
+		topNodes = DefaultTopNodes
+	}
+	d := &Diff{
+		BaseScore:  base.Summary.PerformanceScore,
+		HeadScore:  head.Summary.PerformanceScore,
+		ScoreDelta: head.Summary.PerformanceScore - base.Summary.PerformanceScore,
+		New:        []IssueRef{},
+		Resolved:   []IssueRef{},
+		Unchanged:  []IssueChange{},
+	}
+
+	baseRefs := issueRefs(base)
+	headRefs := issueRefs(head)
+	remaining := map[string][]IssueRef{}
+	for _, ref := range baseRefs {
+		remaining[ref.Key] = append(remaining[ref.Key], ref)
+	}
+	for _, ref := range headRefs {
+		if prev := remaining[ref.Key]; len(prev) > 0 {
+			d.Unchanged = append(d.Unchanged, IssueChange{Base: prev[0], Head: ref})
+			remaining[ref.Key] = prev[1:]
+			continue
+		}
+		d.New = append(d.New, ref)
+	}
+	for _, ref := range baseRefs {
+		for _, left := range remaining[ref.Key] {
+			if left.ID == ref.ID {
+				d.Resolved = append(d.Resolved, ref)
+				break
+			}
+		}
+	}
+
+	d.Nodes = nodeChanges(base, head, topNodes)
+	return d
+}
+
+// IssueKey returns the identity used to match an issue across reports: its
+// type and the sorted labels of its affected nodes.
+func IssueKey(r *aioutput.Report, is issues.Issue) string {
+	labels := map[string]string{}
+	for _, n := range r.Graph.Nodes {
+		labels[n.ID] = n.Label
+	}
+	var affected []string
+	for _, ref := range is.AffectedNodes {
+		if label, ok := labels[ref]; ok {
+			ref = label
+		}
+		affected = append(affected, ref) // cascades already list labels
+	}
+	sort.Strings(affected)
+	return string(is.Type) + ":" + strings.Join(affected, ",")
+}
+
+func issueRefs(r *aioutput.Report) []IssueRef {
+	refs := make([]IssueRef, 0, len(r.Issues))
+	for _, is := range r.Issues {
+		refs = append(refs, IssueRef{
+			Key:         IssueKey(r, is.Issue),
+			ID:          is.ID,
+			Type:        is.Type,
+			Severity:    is.Severity,
+			Title:       is.Title,
+			UpdateCount: is.UpdateCount,
+		})
+	}
+	return refs
+}
+
+func nodeChanges(base, head *aioutput.Report, top int) []NodeChange {
+	type key struct{ label, typ string }
+	counts := map[key]*NodeChange{}
+	var order []key
+	add := func(r *aioutput.Report, head bool) {
+		for _, n := range r.Graph.Nodes {
+			k := key{n.Label, n.Type}
+			c, ok := counts[k]
+			if !ok {
+				c = &NodeChange{Label: n.Label, Type: n.Type}
+				counts[k] = c
+				order = append(order, k)
+			}
+			if head {
+				c.Head += n.UpdateCount
+			} else {
+				c.Base += n.UpdateCount
+			}
+		}
+	}
+	add(base, false)
+	add(head, true)
+
+	var changes []NodeChange
+	for _, k := range order {
+		c := counts[k]
+		c.Delta = c.Head - c.Base
+		if c.Delta != 0 {
+			changes = append(changes, *c)
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return abs(changes[i].Delta) > abs(changes[j].Delta)
+	})
+	if len(changes) > top {
+		changes = changes[:top]
+	}
+	return changes
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Text renders the diff for people
+func (d *Diff) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Performance score: %d → %d (%+d)\n", d.BaseScore, d.HeadScore, d.ScoreDelta)
+	fmt.Fprintf(&b, "Issues: %d resolved, %d new, %d persisting\n", len(d.Resolved), len(d.New), len(d.Unchanged))
+	for _, ref := range d.Resolved {
+		fmt.Fprintf(&b, "  - fixed [%s] %s\n", ref.Severity, ref.Title)
+	}
+	for _, ref := range d.New {
+		fmt.Fprintf(&b, "  + new   [%s] %s\n", ref.Severity, ref.Title)
+	}
+	if len(d.Nodes) > 0 {
+		b.WriteString("Update count changes:\n")
+		for _, n := range d.Nodes {
+			fmt.Fprintf(&b, "  %s (%s): %d → %d (%+d)\n", n.Label, n.Type, n.Base, n.Head, n.Delta)
+		}
+	}
+	return b.String()
+}
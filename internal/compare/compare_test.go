@@ -0,0 +1,65 @@
+package compare
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+func report(t *testing.T, rowCount, timerCount int) *aioutput.Report {
+	t.Helper()
+	gen, err := aioutput.NewGenerator("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "c1", Label: "Timer", Type: graph.NodeCause, Count: timerCount})
+	g.UpsertNode(&graph.Node{ID: "s1", Label: "@State items", Type: graph.NodeState})
+	g.UpsertNode(&graph.Node{ID: "v1", Label: "ItemRow", Type: graph.NodeView, Count: rowCount})
+	g.AddEdge(graph.Edge{From: "c1", To: "s1"})
+	g.AddEdge(graph.Edge{From: "s1", To: "v1"})
+	return gen.Generate(g, aioutput.GenerateOptions{})
+}
+
+func TestReports(t *testing.T) {
+	base := report(t, 80, 40)
+	head := report(t, 5, 40)
+	d := Reports(base, head, 0)
+
+	if d.ScoreDelta != head.Summary.PerformanceScore-base.Summary.PerformanceScore || d.ScoreDelta <= 0 {
+		t.Errorf("expected the score to improve, got %+d", d.ScoreDelta)
+	}
+	if len(d.Resolved) == 0 {
+		t.Fatal("expected the ItemRow re-render issue to be resolved")
+	}
+	for _, ref := range d.Resolved {
+		if !strings.Contains(ref.Key, "ItemRow") {
+			t.Errorf("unexpected resolved issue %s", ref.Key)
+		}
+	}
+	if len(d.New) != 0 {
+		t.Errorf("expected no new issues, got %+v", d.New)
+	}
+	if len(d.Unchanged) == 0 {
+		t.Error("expected the timer issue to persist")
+	}
+	if len(d.Nodes) != 1 || d.Nodes[0].Label != "ItemRow" || d.Nodes[0].Delta != -75 {
+		t.Errorf("unexpected node changes %+v", d.Nodes)
+	}
+
+	text := d.Text()
+	for _, want := range []string{"Performance score:", "fixed", "ItemRow (view): 80 → 5 (-75)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestReports_Identical(t *testing.T) {
+	d := Reports(report(t, 80, 40), report(t, 80, 40), 0)
+	if d.ScoreDelta != 0 || len(d.New) != 0 || len(d.Resolved) != 0 || len(d.Nodes) != 0 {
+		t.Errorf("expected no differences, got %+v", d)
+	}
+}
@@ -0,0 +1,50 @@
+package correlation
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// GroupBy assigns each correlated node to a group derived from its most
+// confident match: "file" groups by relative source path, "module" by the
+// directory under Sources/ (SwiftPM layout) or else the top-level directory.
+// Nodes without a match are left out.
+func GroupBy(matches []SourceMatch, by string) (map[string]string, error) {
+	var key func(rel string) string
+	switch by {
+	case "file":
+		key = func(rel string) string { return rel }
+	case "module":
+		key = moduleOf
+	default:
+		return nil, fmt.Errorf("unknown grouping %q (want file|module)", by)
+	}
+
+	best := map[string]SourceMatch{}
+	for _, m := range matches {
+		if cur, ok := best[m.TraceNodeID]; !ok || m.Confidence > cur.Confidence {
+			best[m.TraceNodeID] = m
+		}
+	}
+	groups := make(map[string]string, len(best))
+	for id, m := range best {
+		if g := key(filepath.ToSlash(m.RelativePath)); g != "" {
+			groups[id] = g
+		}
+	}
+	return groups, nil
+}
+
+func moduleOf(rel string) string {
+	parts := strings.Split(rel, "/")
+	for i, p := range parts[:len(parts)-1] {
+		if p == "Sources" && i+2 < len(parts) {
+			return parts[i+1]
+		}
+	}
+	if len(parts) > 1 {
+		return parts[0]
+	}
+	return "."
+}
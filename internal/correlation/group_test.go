@@ -0,0 +1,35 @@
+package correlation
+
+import "testing"
+
+func TestGroupBy(t *testing.T) {
+	matches := []SourceMatch{
+		{TraceNodeID: "v1", RelativePath: "Sources/Feed/FeedView.swift", Confidence: 0.9},
+		{TraceNodeID: "v1", RelativePath: "Sources/Shared/Row.swift", Confidence: 0.4},
+		{TraceNodeID: "v2", RelativePath: "App/Settings/SettingsView.swift", Confidence: 0.7},
+		{TraceNodeID: "v3", RelativePath: "ContentView.swift", Confidence: 0.7},
+	}
+
+	files, err := GroupBy(matches, "file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files["v1"] != "Sources/Feed/FeedView.swift" {
+		t.Errorf("v1 should use its most confident match, got %q", files["v1"])
+	}
+
+	modules, err := GroupBy(matches, "module")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"v1": "Feed", "v2": "App", "v3": "."}
+	for id, m := range want {
+		if modules[id] != m {
+			t.Errorf("module of %s = %q, want %q", id, modules[id], m)
+		}
+	}
+
+	if _, err := GroupBy(matches, "package"); err == nil {
+		t.Error("expected an error for an unknown grouping")
+	}
+}
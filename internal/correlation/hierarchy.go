@@ -0,0 +1,116 @@
+package correlation
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ViewLink is a parent/child view relationship found in source: the parent
+// view's declaration instantiates the child view type.
+type ViewLink struct {
+	Parent       string `json:"parent"`
+	Child        string `json:"child"`
+	RelativePath string `json:"relative_path"`
+	LineNumber   int    `json:"line_number"`
+}
+
+var reViewDecl = regexp.MustCompile(`\bstruct\s+([A-Z]\w*)\s*(?:<[^>]*>)?\s*:\s*[^{]*\bView\b`)
+
+// ViewHierarchy scans the indexed Swift files for View structs and reports
+// which other View types each one instantiates. It is a lexical scan, so
+// views built through helpers or type erasure are not seen.
+func (c *Correlator) ViewHierarchy() []ViewLink {
+	views := map[string]bool{}
+	for _, path := range c.swiftFiles {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, m := range reViewDecl.FindAllStringSubmatch(string(b), -1) {
+			views[m[1]] = true
+		}
+	}
+	if len(views) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(views))
+	for v := range views {
+		names = append(names, regexp.QuoteMeta(v))
+	}
+	sort.Strings(names)
+	reUse := regexp.MustCompile(`\b(` + strings.Join(names, "|") + `)\s*[({]`)
+
+	var links []ViewLink
+	seen := map[[2]string]bool{}
+	for _, path := range c.swiftFiles {
+		relPath, _ := filepath.Rel(c.sourceRoot, path)
+		if relPath == "" {
+			relPath = path
+		}
+		for _, l := range scanViewUses(path, reUse) {
+			key := [2]string{l.Parent, l.Child}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			l.RelativePath = relPath
+			links = append(links, l)
+		}
+	}
+	return links
+}
+
+// scanViewUses tracks the enclosing View struct by brace depth and records
+// every known view type instantiated inside it.
+func scanViewUses(path string, reUse *regexp.Regexp) []ViewLink {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var links []ViewLink
+	var current string
+	var opened bool
+	depth, start := 0, 0
+	lineNum := 0
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		lineNum++
+		line := s.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+
+		if current == "" {
+			if m := reViewDecl.FindStringSubmatch(line); m != nil {
+				current, start = m[1], depth
+				depth += strings.Count(line, "{") - strings.Count(line, "}")
+				opened = depth > start
+				continue
+			}
+		} else {
+			for _, m := range reUse.FindAllStringSubmatch(line, -1) {
+				if m[1] != current {
+					links = append(links, ViewLink{Parent: current, Child: m[1], LineNumber: lineNum})
+				}
+			}
+		}
+
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		if current == "" {
+			continue
+		}
+		if depth > start {
+			opened = true
+		} else if opened {
+			current = ""
+		}
+	}
+	return links
+}
@@ -0,0 +1,82 @@
+package correlation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestViewHierarchy(t *testing.T) {
+	dir := t.TempDir()
+	src := `import SwiftUI
+
+struct ListScreen: View {
+    @State var items: [Item] = []
+
+    var body: some View {
+        List(items) { item in
+            ItemRow(item: item) // RowBadge() in a comment is ignored
+        }
+        .toolbar { FilterButton() }
+    }
+}
+
+struct ItemRow: View {
+    let item: Item
+    var body: some View {
+        HStack { RowBadge(); Text(item.name) }
+    }
+}
+
+struct RowBadge: View
+{
+    var body: some View { Image(systemName: "star") }
+}
+
+struct FilterButton: View {
+    var body: some View { Button("Filter") {} }
+}
+
+struct Helper {
+    func make() -> some View { ItemRow(item: .init()) }
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "Views.swift"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewCorrelator(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[[2]string]bool{}
+	for _, l := range c.ViewHierarchy() {
+		got[[2]string{l.Parent, l.Child}] = true
+		if l.RelativePath != "Views.swift" || l.LineNumber == 0 {
+			t.Errorf("missing location on %+v", l)
+		}
+	}
+	want := [][2]string{
+		{"ListScreen", "ItemRow"},
+		{"ListScreen", "FilterButton"},
+		{"ItemRow", "RowBadge"},
+	}
+	for _, w := range want {
+		if !got[w] {
+			t.Errorf("missing link %s → %s", w[0], w[1])
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected %d links, got %v", len(want), got)
+	}
+}
+
+func TestViewHierarchy_NoViews(t *testing.T) {
+	c, err := NewCorrelator(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if links := c.ViewHierarchy(); links != nil {
+		t.Errorf("expected no links, got %+v", links)
+	}
+}
@@ -0,0 +1,96 @@
+package correlation
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ViewProperty is a stored property declared with a SwiftUI property
+// wrapper in a View struct
+type ViewProperty struct {
+	Wrapper string `json:"wrapper"` // State, ObservedObject, StateObject, EnvironmentObject, Bindable, Environment
+	Name    string `json:"name"`
+	Type    string `json:"type,omitempty"`
+}
+
+// Model reports whether the property holds an observable model object
+func (p ViewProperty) Model() bool {
+	return p.Wrapper != "State" && p.Type != ""
+}
+
+var (
+	reWrappedVar = regexp.MustCompile(`@(State|ObservedObject|StateObject|EnvironmentObject|Bindable|Environment)\b(?:\(\s*(\w+)\.self\s*\))?\s+(?:(?:public|internal|private|fileprivate)\s+)*var\s+(\w+)\s*(?::\s*(\w+))?(?:\s*=\s*(\w+)\s*\()?`)
+	reStructDecl = regexp.MustCompile(`\bstruct\s+(\w+)`)
+	reImport     = regexp.MustCompile(`^\s*(?:@\w+\s+)*import\s+(?:(?:struct|class|enum|protocol|func|var|let|typealias)\s+)?(\w+)`)
+)
+
+// Imports returns the modules a Swift file imports
+func Imports(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var mods []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if m := reImport.FindStringSubmatch(s.Text()); m != nil {
+			mods = append(mods, m[1])
+		}
+	}
+	return dedupe(mods)
+}
+
+// ViewProperties returns the wrapped properties of the View struct declared
+// in the file at path, in declaration order. Properties of nested types are
+// skipped.
+func ViewProperties(path, view string) []ViewProperty {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var props []ViewProperty
+	inView, opened := false, false
+	depth := 0
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		if !inView {
+			if m := reStructDecl.FindStringSubmatch(line); m != nil && m[1] == view && reViewDecl.MatchString(line) {
+				inView = true
+				depth = strings.Count(line, "{") - strings.Count(line, "}")
+				opened = depth > 0
+			}
+			continue
+		}
+		if depth == 1 {
+			if m := reWrappedVar.FindStringSubmatch(line); m != nil {
+				p := ViewProperty{Wrapper: m[1], Name: m[3], Type: m[4]}
+				if m[2] != "" {
+					p.Type = m[2]
+				} else if p.Type == "" {
+					p.Type = m[5]
+				}
+				if p.Wrapper == "Environment" && m[2] == "" {
+					p.Type = "" // an environment value, not a model
+				}
+				props = append(props, p)
+			}
+		}
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		if depth > 0 {
+			opened = true
+		} else if opened {
+			break
+		}
+	}
+	return props
+}
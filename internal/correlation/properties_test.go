@@ -0,0 +1,63 @@
+package correlation
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestViewProperties(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Cart.swift")
+	src := `import SwiftUI
+
+struct Badge: View {
+    @State private var pulse = false
+    var body: some View { Text("!") }
+}
+
+struct CartView: View {
+    @ObservedObject var cart: CartModel
+    @StateObject private var loader = ImageLoader()
+    @EnvironmentObject var session: Session
+    @Environment(\.dismiss) private var dismiss
+    @Environment(Settings.self) var settings
+    @State private var quantity = 1 // @State var ignored = 0
+
+    struct Row: View {
+        @State var hidden = false
+        var body: some View { EmptyView() }
+    }
+
+    var body: some View {
+        Text("\(quantity)")
+    }
+}
+
+struct After: View {
+    @State var later = 0
+    var body: some View { EmptyView() }
+}
+`
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := ViewProperties(path, "CartView")
+	want := []ViewProperty{
+		{Wrapper: "ObservedObject", Name: "cart", Type: "CartModel"},
+		{Wrapper: "StateObject", Name: "loader", Type: "ImageLoader"},
+		{Wrapper: "EnvironmentObject", Name: "session", Type: "Session"},
+		{Wrapper: "Environment", Name: "settings", Type: "Settings"},
+		{Wrapper: "State", Name: "quantity"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+	if !got[0].Model() || got[4].Model() {
+		t.Error("unexpected Model() result")
+	}
+	if ViewProperties(path, "Missing") != nil {
+		t.Error("expected no properties for an unknown view")
+	}
+}
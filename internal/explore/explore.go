@@ -0,0 +1,487 @@
+// Package explore is an interactive terminal browser over an analysis report.
+package explore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/correlation"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+)
+
+// Key is a decoded keypress
+type Key int
+
+const (
+	KeyNone Key = iota
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyPageUp
+	KeyPageDown
+	KeyHome
+	KeyEnd
+	KeyTab
+	KeyEnter
+	KeyBack
+	KeyHelp
+	KeyQuit
+)
+
+// Pane is the part of the screen that receives navigation keys
+type Pane int
+
+const (
+	PaneIssues Pane = iota
+	PaneNode
+	PaneFixes
+)
+
+// snippetContext is the number of source lines shown around a match
+const snippetContext = 3
+
+var severityTag = map[issues.Severity]string{
+	issues.SeverityCritical: "CRIT",
+	issues.SeverityHigh:     "HIGH",
+	issues.SeverityMedium:   "MED ",
+	issues.SeverityLow:      "LOW ",
+	issues.SeverityInfo:     "INFO",
+}
+
+// Model holds the explorer state. It does no terminal I/O: keys go in
+// through Update and the screen comes out of View, so it can be driven by
+// Run or by tests.
+type Model struct {
+	report  *aioutput.Report
+	issues  []aioutput.IssueWithFixes
+	nodes   map[string]aioutput.NodeData
+	refs    *issues.Resolver
+	preds   map[string][]string
+	succs   map[string][]string
+	matches map[string]correlation.SourceMatch
+
+	// ReadFile loads source files for snippets; it defaults to os.ReadFile
+	ReadFile func(path string) ([]byte, error)
+
+	focus     Pane
+	issue     int
+	node      string   // node shown in the detail pane
+	history   []string // nodes visited before node, for KeyBack
+	neighbor  int      // cursor over the neighbour list
+	fixScroll int
+	help      bool
+}
+
+// New builds a model over a report; issues are listed most severe first.
+func New(r *aioutput.Report) *Model {
+	m := &Model{
+		report:   r,
+		nodes:    map[string]aioutput.NodeData{},
+		refs:     issues.NewResolver(r.Graph.ToGraph()),
+		preds:    map[string][]string{},
+		succs:    map[string][]string{},
+		matches:  map[string]correlation.SourceMatch{},
+		ReadFile: os.ReadFile,
+	}
+	for _, n := range r.Graph.Nodes {
+		m.nodes[n.ID] = n
+	}
+	for _, e := range r.Graph.Edges {
+		m.succs[e.From] = append(m.succs[e.From], e.To)
+		m.preds[e.To] = append(m.preds[e.To], e.From)
+	}
+	for _, sm := range r.SourceCorrelations {
+		if cur, ok := m.matches[sm.TraceNodeID]; !ok || sm.Confidence > cur.Confidence {
+			m.matches[sm.TraceNodeID] = sm
+		}
+	}
+	m.issues = append(m.issues, r.Issues...)
+	issues.SortBySeverity(m.issues, func(is aioutput.IssueWithFixes) (issues.Severity, int) { return is.Severity, is.UpdateCount })
+	m.selectIssue(0)
+	return m
+}
+
+// Focus returns the pane receiving navigation keys
+func (m *Model) Focus() Pane { return m.focus }
+
+// SelectedIssue returns the highlighted issue, or nil when there are none
+func (m *Model) SelectedIssue() *aioutput.IssueWithFixes {
+	if m.issue < 0 || m.issue >= len(m.issues) {
+		return nil
+	}
+	return &m.issues[m.issue]
+}
+
+// SelectedNode returns the ID of the node in the detail pane
+func (m *Model) SelectedNode() string { return m.node }
+
+// Update applies a keypress and reports whether the explorer should exit.
+func (m *Model) Update(k Key) (quit bool) {
+	if m.help {
+		m.help = k != KeyHelp && k != KeyBack && k != KeyQuit && k != KeyEnter
+		return false
+	}
+	switch k {
+	case KeyQuit:
+		return true
+	case KeyHelp:
+		m.help = true
+	case KeyTab:
+		m.focus = (m.focus + 1) % 3
+	case KeyRight:
+		if m.focus < PaneFixes {
+			m.focus++
+		}
+	case KeyLeft:
+		if m.focus > PaneIssues {
+			m.focus--
+		}
+	case KeyUp, KeyDown, KeyPageUp, KeyPageDown, KeyHome, KeyEnd:
+		m.move(k)
+	case KeyEnter:
+		switch m.focus {
+		case PaneIssues:
+			m.focus = PaneNode
+		case PaneNode:
+			if ns := m.neighbors(); m.neighbor < len(ns) {
+				m.history = append(m.history, m.node)
+				m.node = ns[m.neighbor]
+				m.neighbor = 0
+			}
+		}
+	case KeyBack:
+		switch {
+		case m.focus == PaneNode && len(m.history) > 0:
+			m.node = m.history[len(m.history)-1]
+			m.history = m.history[:len(m.history)-1]
+			m.neighbor = 0
+		case m.focus != PaneIssues:
+			m.focus = PaneIssues
+		}
+	}
+	return false
+}
+
+func (m *Model) move(k Key) {
+	step := map[Key]int{KeyUp: -1, KeyDown: 1, KeyPageUp: -10, KeyPageDown: 10, KeyHome: -1 << 20, KeyEnd: 1 << 20}[k]
+	switch m.focus {
+	case PaneIssues:
+		m.selectIssue(clamp(m.issue+step, len(m.issues)))
+	case PaneNode:
+		m.neighbor = clamp(m.neighbor+step, len(m.neighbors()))
+	case PaneFixes:
+		m.fixScroll = clamp(m.fixScroll+step, len(m.fixLines()))
+	}
+}
+
+func clamp(i, n int) int {
+	if i >= n {
+		i = n - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return i
+}
+
+// selectIssue highlights issue i and shows its first resolvable node
+func (m *Model) selectIssue(i int) {
+	m.issue = i
+	m.node, m.history, m.neighbor, m.fixScroll = "", nil, 0, 0
+	issue := m.SelectedIssue()
+	if issue == nil {
+		return
+	}
+	for _, ref := range append(append([]string(nil), issue.AffectedNodes...), issue.CauseChain...) {
+		if id := m.resolve(ref); id != "" {
+			m.node = id
+			return
+		}
+	}
+}
+
+// resolve maps an issue reference (node ID or label) to a node ID
+func (m *Model) resolve(ref string) string {
+	id, _ := m.refs.NodeID(ref)
+	return id
+}
+
+// neighbors lists the selectable nodes of the detail pane: upstream first,
+// then downstream.
+func (m *Model) neighbors() []string {
+	if m.node == "" {
+		return nil
+	}
+	return append(append([]string(nil), m.preds[m.node]...), m.succs[m.node]...)
+}
+
+// View renders the screen at the given size. Lines are separated by "\n"
+// and may carry ANSI styling.
+func (m *Model) View(width, height int) string {
+	if width < 40 {
+		width = 40
+	}
+	if height < 10 {
+		height = 10
+	}
+	title := fmt.Sprintf(" swiftuice explore · %d issues · score %d/100 (%s)", len(m.issues), m.report.Summary.PerformanceScore, m.report.Summary.HealthStatus)
+	footer := " ↑↓ move  tab/←→ pane  enter open  esc back  ? help  q quit"
+
+	body := height - 2
+	var rows []string
+	if m.help {
+		rows = m.helpLines(width, body)
+	} else {
+		leftW := width * 2 / 5
+		rightW := width - leftW - 3
+		left := m.issueLines(leftW, body)
+		nodeH := body / 2
+		right := append(m.nodeLines(rightW, nodeH), m.fixPane(rightW, body-nodeH)...)
+		for i := 0; i < body; i++ {
+			rows = append(rows, left[i]+" │ "+right[i])
+		}
+	}
+
+	out := []string{reverse(pad(title, width))}
+	out = append(out, rows...)
+	out = append(out, dim(pad(footer, width)))
+	return strings.Join(out, "\n")
+}
+
+func (m *Model) issueLines(w, h int) []string {
+	lines := []string{m.header("Issues", PaneIssues, w)}
+	if len(m.issues) == 0 {
+		lines = append(lines, pad("  no issues detected", w))
+	}
+	// keep the cursor in view
+	first := 0
+	if visible := h - 1; m.issue >= visible {
+		first = m.issue - visible + 1
+	}
+	for i := first; i < len(m.issues) && len(lines) < h; i++ {
+		is := m.issues[i]
+		line := pad(fmt.Sprintf(" %s %s", severityTag[is.Severity], is.Title), w)
+		if i == m.issue {
+			if m.focus == PaneIssues {
+				line = reverse(line)
+			} else {
+				line = bold(line)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return fill(lines, w, h)
+}
+
+func (m *Model) nodeLines(w, h int) []string {
+	lines := []string{m.header("Node", PaneNode, w)}
+	n, ok := m.nodes[m.node]
+	if !ok {
+		return fill(append(lines, pad("  no node selected", w)), w, h)
+	}
+	add := func(s string) { lines = append(lines, pad(s, w)) }
+	add(fmt.Sprintf(" %s  [%s]", n.Label, n.Type))
+	facts := []string{}
+	if n.UpdateCount > 0 {
+		facts = append(facts, fmt.Sprintf("updates %d", n.UpdateCount))
+	}
+	if n.InstanceCount > 1 {
+		facts = append(facts, fmt.Sprintf("%d instances", n.InstanceCount))
+	}
+	if len(m.history) > 0 {
+		facts = append(facts, fmt.Sprintf("depth %d, esc to go back", len(m.history)))
+	}
+	if len(facts) > 0 {
+		add("   " + strings.Join(facts, " · "))
+	}
+
+	ns := m.neighbors()
+	up := len(m.preds[m.node])
+	for i, id := range ns {
+		if i == 0 && up > 0 {
+			add(" upstream:")
+		}
+		if i == up {
+			add(" downstream:")
+		}
+		line := pad("   "+m.nodeSummary(id), w)
+		if i == m.neighbor && m.focus == PaneNode {
+			line = reverse(line)
+		}
+		lines = append(lines, line)
+	}
+
+	if snippet := m.snippet(m.node); len(snippet) > 0 {
+		add(" source:")
+		for _, s := range snippet {
+			add("   " + s)
+		}
+	}
+	return fill(lines, w, h)
+}
+
+func (m *Model) nodeSummary(id string) string {
+	n, ok := m.nodes[id]
+	if !ok {
+		return id
+	}
+	if n.UpdateCount > 0 {
+		return fmt.Sprintf("%s (%s, %d)", n.Label, n.Type, n.UpdateCount)
+	}
+	return fmt.Sprintf("%s (%s)", n.Label, n.Type)
+}
+
+// snippet returns the numbered source lines around a node's best match,
+// falling back to the stored one-line snippet when the file is unreadable.
+func (m *Model) snippet(id string) []string {
+	sm, ok := m.matches[id]
+	if !ok {
+		return nil
+	}
+	loc := fmt.Sprintf("%s:%d", sm.RelativePath, sm.LineNumber)
+	path := sm.FilePath
+	if path == "" && sm.RelativePath != "" && m.report.Input.SourceRoot != "" {
+		path = filepath.Join(m.report.Input.SourceRoot, sm.RelativePath)
+	}
+	if path != "" && sm.LineNumber > 0 && m.ReadFile != nil {
+		if data, err := m.ReadFile(path); err == nil {
+			src := strings.Split(strings.ReplaceAll(string(data), "\t", "    "), "\n")
+			out := []string{loc}
+			for ln := sm.LineNumber - snippetContext; ln <= sm.LineNumber+snippetContext; ln++ {
+				if ln < 1 || ln > len(src) {
+					continue
+				}
+				mark := " "
+				if ln == sm.LineNumber {
+					mark = ">"
+				}
+				out = append(out, fmt.Sprintf("%s%4d  %s", mark, ln, src[ln-1]))
+			}
+			return out
+		}
+	}
+	if sm.CodeSnippet != "" {
+		return []string{loc, ">" + fmt.Sprintf("%4d  %s", sm.LineNumber, sm.CodeSnippet)}
+	}
+	return []string{loc}
+}
+
+func (m *Model) fixPane(w, h int) []string {
+	lines := []string{m.header("Fixes", PaneFixes, w)}
+	body := m.fixLines()
+	for i := m.fixScroll; i < len(body) && len(lines) < h; i++ {
+		lines = append(lines, pad(body[i], w))
+	}
+	return fill(lines, w, h)
+}
+
+// fixLines is the unclipped text of the fixes pane for the selected issue
+func (m *Model) fixLines() []string {
+	issue := m.SelectedIssue()
+	if issue == nil {
+		return nil
+	}
+	var out []string
+	if issue.Description != "" {
+		out = append(out, " "+issue.Description)
+	}
+	if issue.SourceFile != "" {
+		out = append(out, fmt.Sprintf(" at %s:%d", issue.SourceFile, issue.LineNumber))
+	}
+	if len(issue.SuggestedFixes) == 0 {
+		return append(out, " no suggested fixes")
+	}
+	for i, f := range issue.SuggestedFixes {
+		out = append(out, "", fmt.Sprintf(" %d. %s  (effort %s, impact %s)", i+1, f.Approach, f.Effort, f.Impact))
+		if f.Description != "" {
+			out = append(out, "    "+f.Description)
+		}
+		if f.Expected != nil {
+			out = append(out, "    expected: "+f.Expected.String())
+		}
+		if a := f.Availability; a != nil && !a.Compatible {
+			out = append(out, "    not available: "+a.Reason)
+		} else if a.Unchecked() {
+			out = append(out, "    requires "+a.Requires)
+		}
+		for j, s := range f.Steps {
+			out = append(out, fmt.Sprintf("    %d) %s", j+1, s))
+		}
+		if f.CodeAfter != "" {
+			for _, l := range strings.Split(strings.TrimRight(f.CodeAfter, "\n"), "\n") {
+				out = append(out, "    │ "+strings.ReplaceAll(l, "\t", "    "))
+			}
+		}
+	}
+	return out
+}
+
+func (m *Model) helpLines(w, h int) []string {
+	text := []string{
+		" Keys",
+		"",
+		"   ↑ ↓ / k j      move in the focused pane",
+		"   PgUp PgDn      move by ten",
+		"   g G / Home End jump to the first or last entry",
+		"   tab, ← →       switch pane: issues, node, fixes",
+		"   enter          issues: inspect the node · node: follow the neighbour",
+		"   esc, ⌫         go back to the previous node, then to the issues",
+		"   ?              toggle this help",
+		"   q, ctrl-c      quit",
+	}
+	var lines []string
+	for _, t := range text {
+		lines = append(lines, pad(t, w))
+	}
+	return fill(lines, w, h)
+}
+
+func (m *Model) header(name string, p Pane, w int) string {
+	line := pad(" "+name, w)
+	if m.focus == p {
+		return bold(line)
+	}
+	return dim(line)
+}
+
+// pad truncates or pads s to exactly w columns. Control characters, which
+// trace labels and source lines may carry, are dropped first (tabs become
+// a space) so they cannot move the cursor or restyle the terminal.
+func pad(s string, w int) string {
+	r := []rune(printable(s))
+	if len(r) > w {
+		if w <= 1 {
+			return string(r[:w])
+		}
+		return string(r[:w-1]) + "…"
+	}
+	return string(r) + strings.Repeat(" ", w-len(r))
+}
+
+func printable(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t':
+			return ' '
+		case unicode.IsControl(r):
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func fill(lines []string, w, h int) []string {
+	for len(lines) < h {
+		lines = append(lines, strings.Repeat(" ", w))
+	}
+	return lines[:h]
+}
+
+func reverse(s string) string { return "\x1b[7m" + s + "\x1b[0m" }
+func bold(s string) string    { return "\x1b[1m" + s + "\x1b[0m" }
+func dim(s string) string     { return "\x1b[2m" + s + "\x1b[0m" }
@@ -0,0 +1,166 @@
+package explore
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/correlation"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/suggestions"
+)
+
+func sampleReport() *aioutput.Report {
+	return &aioutput.Report{
+		Summary: aioutput.Summary{PerformanceScore: 62, HealthStatus: "warning"},
+		Issues: []aioutput.IssueWithFixes{
+			{Issue: issues.Issue{ID: "low-1", Severity: issues.SeverityLow, Title: "Minor churn", AffectedNodes: []string{"clock"}}},
+			{
+				Issue: issues.Issue{ID: "crit-1", Severity: issues.SeverityCritical, Title: "Rows re-render on every keystroke",
+					Description: "ItemRow updates 240 times", AffectedNodes: []string{"ItemRow"}},
+				SuggestedFixes: []suggestions.Fix{{Approach: "Equatable rows", Effort: "low", Impact: "high",
+					Steps: []string{"Conform ItemRow to Equatable"}, CodeAfter: "struct ItemRow: View, Equatable {"}},
+			},
+		},
+		Graph: aioutput.GraphData{
+			Nodes: []aioutput.NodeData{
+				{ID: "tap", Label: "Keystroke", Type: "cause", UpdateCount: 240},
+				{ID: "query", Label: "@State query", Type: "state"},
+				{ID: "row", Label: "ItemRow", Type: "view", UpdateCount: 240, InstanceCount: 12},
+				{ID: "clock", Label: "ClockView", Type: "view", UpdateCount: 3},
+			},
+			Edges: []aioutput.EdgeData{{From: "tap", To: "query"}, {From: "query", To: "row"}},
+		},
+		SourceCorrelations: []correlation.SourceMatch{
+			{TraceNodeID: "row", FilePath: "/src/ItemRow.swift", RelativePath: "ItemRow.swift", LineNumber: 3, Confidence: 0.9, CodeSnippet: "struct ItemRow: View {"},
+		},
+	}
+}
+
+var reANSI = regexp.MustCompile("\x1b\\[[0-9;?]*[A-Za-z]")
+
+func TestNew_SortsBySeverity(t *testing.T) {
+	m := New(sampleReport())
+	if got := m.SelectedIssue().ID; got != "crit-1" {
+		t.Fatalf("expected the critical issue first, got %s", got)
+	}
+	// cascades reference view labels; they resolve to node IDs
+	if m.SelectedNode() != "row" {
+		t.Errorf("expected ItemRow to be selected, got %q", m.SelectedNode())
+	}
+}
+
+func TestUpdate_Navigation(t *testing.T) {
+	m := New(sampleReport())
+
+	m.Update(KeyDown)
+	if m.SelectedIssue().ID != "low-1" || m.SelectedNode() != "clock" {
+		t.Fatalf("down should select the next issue, got %s/%s", m.SelectedIssue().ID, m.SelectedNode())
+	}
+	m.Update(KeyDown) // already at the end
+	m.Update(KeyHome)
+	if m.SelectedIssue().ID != "crit-1" {
+		t.Fatalf("home should select the first issue")
+	}
+
+	m.Update(KeyEnter)
+	if m.Focus() != PaneNode {
+		t.Fatalf("enter should focus the node pane")
+	}
+	m.Update(KeyEnter) // follow the only neighbour: upstream query
+	if m.SelectedNode() != "query" {
+		t.Fatalf("expected to follow to query, got %s", m.SelectedNode())
+	}
+	m.Update(KeyDown) // query: upstream tap, downstream row
+	m.Update(KeyEnter)
+	if m.SelectedNode() != "row" {
+		t.Fatalf("expected to follow downstream to row, got %s", m.SelectedNode())
+	}
+	m.Update(KeyBack)
+	m.Update(KeyBack)
+	if m.SelectedNode() != "row" || m.Focus() != PaneNode {
+		t.Fatalf("back should retrace the visited nodes, got %s", m.SelectedNode())
+	}
+	m.Update(KeyBack)
+	if m.Focus() != PaneIssues {
+		t.Errorf("back with no history should return to the issues")
+	}
+
+	m.Update(KeyTab)
+	m.Update(KeyTab)
+	if m.Focus() != PaneFixes {
+		t.Errorf("tab should cycle to the fixes pane")
+	}
+	if !m.Update(KeyQuit) {
+		t.Error("q should quit")
+	}
+}
+
+func TestView(t *testing.T) {
+	m := New(sampleReport())
+	m.ReadFile = func(path string) ([]byte, error) {
+		if path != "/src/ItemRow.swift" {
+			return nil, errors.New("not found")
+		}
+		return []byte("import SwiftUI\n\nstruct ItemRow: View {\n\tlet item: Item\n}\n"), nil
+	}
+
+	out := m.View(100, 30)
+	lines := strings.Split(out, "\n")
+	if len(lines) != 30 {
+		t.Fatalf("expected 30 lines, got %d", len(lines))
+	}
+	for i, l := range lines {
+		if w := utf8.RuneCountInString(reANSI.ReplaceAllString(l, "")); w != 100 {
+			t.Errorf("line %d is %d columns wide: %q", i, w, l)
+		}
+	}
+	plain := reANSI.ReplaceAllString(out, "")
+	for _, want := range []string{
+		"2 issues · score 62/100 (warning)",
+		"CRIT Rows re-render on every keystroke",
+		"ItemRow  [view]",
+		"updates 240 · 12 instances",
+		"@State query (state)",
+		">   3  struct ItemRow: View {",
+		"    4      let item: Item",
+		"1. Equatable rows  (effort low, impact high)",
+		"│ struct ItemRow: View, Equatable {",
+	} {
+		if !strings.Contains(plain, want) {
+			t.Errorf("expected %q in view:\n%s", want, plain)
+		}
+	}
+
+	m.Update(KeyHelp)
+	if !strings.Contains(reANSI.ReplaceAllString(m.View(100, 30), ""), "toggle this help") {
+		t.Error("? should show the help screen")
+	}
+}
+
+func TestView_NoIssues(t *testing.T) {
+	m := New(&aioutput.Report{})
+	plain := reANSI.ReplaceAllString(m.View(60, 12), "")
+	if !strings.Contains(plain, "no issues detected") {
+		t.Errorf("expected an empty state:\n%s", plain)
+	}
+	m.Update(KeyDown)
+	m.Update(KeyEnter)
+}
+
+func TestView_StripsControlCharacters(t *testing.T) {
+	r := sampleReport()
+	r.Issues[1].Title = "Rows\x1b[2J re-render\a\tagain"
+	m := New(r)
+	out := m.View(100, 30)
+	plain := reANSI.ReplaceAllString(out, "")
+	if strings.ContainsAny(plain, "\x1b\a\t") {
+		t.Errorf("control characters reached the terminal: %q", plain)
+	}
+	if !strings.Contains(plain, "Rows[2J re-render again") {
+		t.Errorf("expected the cleaned title:\n%s", plain)
+	}
+}
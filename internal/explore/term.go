@@ -0,0 +1,177 @@
+package explore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+)
+
+// ErrNotTerminal is returned by Run when stdin is not an interactive terminal
+var ErrNotTerminal = errors.New("explore needs an interactive terminal")
+
+// ParseKeys decodes the bytes of one terminal read into keys. Arrow and
+// paging keys arrive as escape sequences; a lone ESC means back.
+func ParseKeys(b []byte) []Key {
+	var keys []Key
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		if c == 0x1b {
+			if i+2 < len(b) && (b[i+1] == '[' || b[i+1] == 'O') {
+				seq, n := escapeKey(b[i+2:])
+				keys = append(keys, seq)
+				i += 1 + n
+				continue
+			}
+			keys = append(keys, KeyBack)
+			continue
+		}
+		switch c {
+		case 'q', 3: // ctrl-c
+			keys = append(keys, KeyQuit)
+		case 'k':
+			keys = append(keys, KeyUp)
+		case 'j':
+			keys = append(keys, KeyDown)
+		case 'h':
+			keys = append(keys, KeyLeft)
+		case 'l':
+			keys = append(keys, KeyRight)
+		case 'g':
+			keys = append(keys, KeyHome)
+		case 'G':
+			keys = append(keys, KeyEnd)
+		case '\t':
+			keys = append(keys, KeyTab)
+		case '\r', '\n':
+			keys = append(keys, KeyEnter)
+		case 0x7f, 0x08: // backspace
+			keys = append(keys, KeyBack)
+		case '?':
+			keys = append(keys, KeyHelp)
+		}
+	}
+	return keys
+}
+
+// escapeKey decodes the part of a CSI/SS3 sequence after "ESC [" and
+// returns the key and the number of bytes consumed.
+func escapeKey(b []byte) (Key, int) {
+	switch b[0] {
+	case 'A':
+		return KeyUp, 1
+	case 'B':
+		return KeyDown, 1
+	case 'C':
+		return KeyRight, 1
+	case 'D':
+		return KeyLeft, 1
+	case 'H':
+		return KeyHome, 1
+	case 'F':
+		return KeyEnd, 1
+	}
+	// "5~" page up, "6~" page down, "1~"/"7~" home, "4~"/"8~" end
+	end := 0
+	for end < len(b) && b[end] != '~' {
+		end++
+	}
+	if end == len(b) {
+		return KeyNone, len(b)
+	}
+	switch string(b[:end]) {
+	case "5":
+		return KeyPageUp, end + 1
+	case "6":
+		return KeyPageDown, end + 1
+	case "1", "7":
+		return KeyHome, end + 1
+	case "4", "8":
+		return KeyEnd, end + 1
+	}
+	return KeyNone, end + 1
+}
+
+// Run puts the terminal in raw mode on the alternate screen and drives the
+// model until it quits. The terminal is restored on return.
+func Run(m *Model, in *os.File, out io.Writer) error {
+	if info, err := in.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return ErrNotTerminal
+	}
+	saved, err := stty(in, "-g")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotTerminal, err)
+	}
+	if _, err := stty(in, "raw", "-echo"); err != nil {
+		return fmt.Errorf("%w: %v", ErrNotTerminal, err)
+	}
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+		stty(in, strings.TrimSpace(saved))
+	}()
+
+	// Keys are read on their own goroutine so a resize can redraw while
+	// waiting for input; the size is only queried again on SIGWINCH
+	resize := make(chan os.Signal, 1)
+	if sigs := resizeSignals(); len(sigs) > 0 {
+		signal.Notify(resize, sigs...)
+		defer signal.Stop(resize)
+	}
+	input := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := in.Read(buf)
+			if err != nil {
+				readErr <- err
+				return
+			}
+			input <- append([]byte(nil), buf[:n]...)
+		}
+	}()
+
+	w, h := size(in)
+	for {
+		screen := strings.ReplaceAll(m.View(w, h), "\n", "\r\n")
+		fmt.Fprint(out, "\x1b[H\x1b[2J"+screen)
+
+		select {
+		case <-resize:
+			w, h = size(in)
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case b := <-input:
+			for _, k := range ParseKeys(b) {
+				if m.Update(k) {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func stty(in *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = in
+	b, err := cmd.Output()
+	return string(b), err
+}
+
+// size returns the terminal width and height, defaulting to 80x24
+func size(in *os.File) (int, int) {
+	var rows, cols int
+	if s, err := stty(in, "size"); err == nil {
+		if _, err := fmt.Sscan(s, &rows, &cols); err == nil && rows > 0 && cols > 0 {
+			return cols, rows
+		}
+	}
+	return 80, 24
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package explore
+
+import "os"
+
+// resizeSignals is empty where terminals do not signal resizes; the size
+// read at startup is kept
+func resizeSignals() []os.Signal {
+	return nil
+}
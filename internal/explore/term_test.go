@@ -0,0 +1,25 @@
+package explore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []Key
+	}{
+		{"\x1b[A\x1b[B\x1b[C\x1b[D", []Key{KeyUp, KeyDown, KeyRight, KeyLeft}},
+		{"\x1bOA", []Key{KeyUp}},
+		{"\x1b[5~\x1b[6~\x1b[H\x1b[4~", []Key{KeyPageUp, KeyPageDown, KeyHome, KeyEnd}},
+		{"\x1b", []Key{KeyBack}},
+		{"jk\t\r?q\x03\x7f", []Key{KeyDown, KeyUp, KeyTab, KeyEnter, KeyHelp, KeyQuit, KeyQuit, KeyBack}},
+		{"x", nil},
+	}
+	for _, c := range cases {
+		if got := ParseKeys([]byte(c.in)); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("ParseKeys(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
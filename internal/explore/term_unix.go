@@ -0,0 +1,13 @@
+//go:build unix
+
+package explore
+
+import (
+	"os"
+	"syscall"
+)
+
+// resizeSignals are the signals that announce a new terminal size
+func resizeSignals() []os.Signal {
+	return []os.Signal{syscall.SIGWINCH}
+}
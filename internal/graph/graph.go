@@ -14,6 +14,21 @@ type Node struct {
 	Label string
 	Type  NodeType
 	Count int // optional metric (e.g. view updates)
+
+	// SourceCounts holds the count contributed by each input when several
+	// traces are merged into one graph (nil for single-trace graphs).
+	SourceCounts map[string]int
+
+	// Instances lists the original nodes folded into this one when labels
+	// were normalized, keeping their raw labels and counts (nil otherwise).
+	Instances []Instance
+}
+
+// Instance is one original node that was collapsed into a normalized node
+type Instance struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Count int    `json:"count,omitempty"`
 }
 
 type Edge struct {
@@ -25,6 +40,10 @@ type Edge struct {
 type Graph struct {
 	Nodes map[string]*Node
 	Edges []Edge
+
+	// Containment holds parent → child view containment (From contains To).
+	// It is kept apart from Edges, which model invalidation.
+	Containment []Edge
 }
 
 func New() *Graph {
@@ -50,3 +69,16 @@ func (g *Graph) UpsertNode(n *Node) {
 func (g *Graph) AddEdge(e Edge) {
 	g.Edges = append(g.Edges, e)
 }
+
+// AddContainment records that parent contains child, ignoring duplicates
+func (g *Graph) AddContainment(parent, child string) {
+	if parent == "" || child == "" || parent == child {
+		return
+	}
+	for _, e := range g.Containment {
+		if e.From == parent && e.To == child {
+			return
+		}
+	}
+	g.Containment = append(g.Containment, Edge{From: parent, To: child, Label: "contains"})
+}
@@ -135,3 +135,21 @@ func TestNodeTypes(t *testing.T) {
 		}
 	}
 }
+
+func TestAddContainment(t *testing.T) {
+	g := New()
+	g.AddContainment("screen", "row")
+	g.AddContainment("screen", "row") // duplicate
+	g.AddContainment("row", "row")    // self
+	g.AddContainment("", "row")       // empty
+
+	if len(g.Containment) != 1 {
+		t.Fatalf("expected 1 containment edge, got %+v", g.Containment)
+	}
+	if e := g.Containment[0]; e.From != "screen" || e.To != "row" {
+		t.Errorf("unexpected containment edge: %+v", e)
+	}
+	if len(g.Edges) != 0 {
+		t.Error("containment should not add invalidation edges")
+	}
+}
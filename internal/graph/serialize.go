@@ -0,0 +1,386 @@
+package graph
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FormatName identifies the canonical JSON graph format
+const FormatName = "swiftuice-graph"
+
+// FormatVersion is the current canonical JSON graph format version
+const FormatVersion = 1
+
+type jsonGraph struct {
+	Format      string     `json:"format"`
+	Version     int        `json:"version"`
+	Nodes       []jsonNode `json:"nodes"`
+	Edges       []jsonEdge `json:"edges"`
+	Containment []jsonEdge `json:"containment,omitempty"`
+}
+
+type jsonNode struct {
+	ID           string         `json:"id"`
+	Label        string         `json:"label"`
+	Type         NodeType       `json:"type"`
+	Count        int            `json:"count,omitempty"`
+	SourceCounts map[string]int `json:"source_counts,omitempty"`
+	Instances    []Instance     `json:"instances,omitempty"`
+}
+
+type jsonEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label,omitempty"`
+}
+
+// SortedNodes returns the nodes ordered by ID
+func (g *Graph) SortedNodes() []*Node {
+	nodes := make([]*Node, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes
+}
+
+// SortedEdges returns a copy of the edges ordered by from, to and label
+func (g *Graph) SortedEdges() []Edge {
+	return sortEdges(g.Edges)
+}
+
+func sortEdges(in []Edge) []Edge {
+	edges := append([]Edge(nil), in...)
+	sort.SliceStable(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		if edges[i].To != edges[j].To {
+			return edges[i].To < edges[j].To
+		}
+		return edges[i].Label < edges[j].Label
+	})
+	return edges
+}
+
+// MarshalJSON encodes the graph in the canonical, versioned JSON form.
+// Nodes and edges are sorted so the output is stable across runs.
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	out := jsonGraph{
+		Format:  FormatName,
+		Version: FormatVersion,
+		Nodes:   make([]jsonNode, 0, len(g.Nodes)),
+		Edges:   make([]jsonEdge, 0, len(g.Edges)),
+	}
+	for _, n := range g.SortedNodes() {
+		out.Nodes = append(out.Nodes, jsonNode{ID: n.ID, Label: n.Label, Type: n.Type, Count: n.Count, SourceCounts: n.SourceCounts, Instances: n.Instances})
+	}
+	for _, e := range g.SortedEdges() {
+		out.Edges = append(out.Edges, jsonEdge(e))
+	}
+	for _, e := range sortEdges(g.Containment) {
+		out.Containment = append(out.Containment, jsonEdge{From: e.From, To: e.To})
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes the canonical JSON form produced by MarshalJSON
+func (g *Graph) UnmarshalJSON(b []byte) error {
+	var in jsonGraph
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+	if in.Format != FormatName {
+		return fmt.Errorf("not a %s document (format=%q)", FormatName, in.Format)
+	}
+	if in.Version < 1 || in.Version > FormatVersion {
+		return fmt.Errorf("unsupported %s version %d (supported: 1-%d)", FormatName, in.Version, FormatVersion)
+	}
+
+	g.Nodes = make(map[string]*Node, len(in.Nodes))
+	g.Edges = make([]Edge, 0, len(in.Edges))
+	for i, n := range in.Nodes {
+		if n.ID == "" {
+			return fmt.Errorf("node %d has no id", i)
+		}
+		if _, dup := g.Nodes[n.ID]; dup {
+			return fmt.Errorf("duplicate node id %q", n.ID)
+		}
+		t := n.Type
+		if t == "" {
+			t = NodeOther
+		}
+		g.Nodes[n.ID] = &Node{ID: n.ID, Label: n.Label, Type: t, Count: n.Count, SourceCounts: n.SourceCounts, Instances: n.Instances}
+	}
+	for i, e := range in.Edges {
+		if e.From == "" || e.To == "" {
+			return fmt.Errorf("edge %d is missing from/to", i)
+		}
+		if err := g.checkEndpoints(e.From, e.To); err != nil {
+			return fmt.Errorf("edge %d: %w", i, err)
+		}
+		g.Edges = append(g.Edges, Edge(e))
+	}
+	g.Containment = nil
+	for i, e := range in.Containment {
+		if e.From == "" || e.To == "" {
+			return fmt.Errorf("containment %d is missing from/to", i)
+		}
+		g.AddContainment(e.From, e.To)
+	}
+	return nil
+}
+
+// checkEndpoints reports an edge endpoint that is not a node of the graph
+func (g *Graph) checkEndpoints(from, to string) error {
+	for _, id := range []string{from, to} {
+		if _, ok := g.Nodes[id]; !ok {
+			return fmt.Errorf("unknown node %q", id)
+		}
+	}
+	return nil
+}
+
+// GraphML document structure (http://graphml.graphdrawing.org/)
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr,omitempty"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+var graphMLKeys = []graphMLKey{
+	{ID: "label", For: "node", AttrName: "label", AttrType: "string"},
+	{ID: "type", For: "node", AttrName: "type", AttrType: "string"},
+	{ID: "count", For: "node", AttrName: "count", AttrType: "int"},
+	{ID: "sources", For: "node", AttrName: "source_counts", AttrType: "string"},
+	{ID: "instances", For: "node", AttrName: "instances", AttrType: "string"},
+	{ID: "elabel", For: "edge", AttrName: "label", AttrType: "string"},
+	{ID: "kind", For: "edge", AttrName: "kind", AttrType: "string"},
+}
+
+// WriteGraphML writes the graph as GraphML with label/type/count attributes
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  graphMLKeys,
+		Graph: graphMLGraph{ID: "CauseEffect", EdgeDefault: "directed"},
+	}
+	for _, n := range g.SortedNodes() {
+		data := []graphMLData{
+			{Key: "label", Value: n.Label},
+			{Key: "type", Value: string(n.Type)},
+		}
+		if n.Count != 0 {
+			data = append(data, graphMLData{Key: "count", Value: strconv.Itoa(n.Count)})
+		}
+		if len(n.SourceCounts) > 0 {
+			b, err := json.Marshal(n.SourceCounts)
+			if err != nil {
+				return err
+			}
+			data = append(data, graphMLData{Key: "sources", Value: string(b)})
+		}
+		if len(n.Instances) > 0 {
+			b, err := json.Marshal(n.Instances)
+			if err != nil {
+				return err
+			}
+			data = append(data, graphMLData{Key: "instances", Value: string(b)})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: n.ID, Data: data})
+	}
+	for _, e := range g.SortedEdges() {
+		var data []graphMLData
+		if e.Label != "" {
+			data = append(data, graphMLData{Key: "elabel", Value: e.Label})
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: e.From, Target: e.To, Data: data})
+	}
+	for _, e := range sortEdges(g.Containment) {
+		data := []graphMLData{{Key: "kind", Value: "contains"}}
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: e.From, Target: e.To, Data: data})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ReadGraphML reads a GraphML document. Attribute keys are resolved by their
+// attr.name so files produced by other tools (yEd, Gephi) load as well.
+func ReadGraphML(r io.Reader) (*Graph, error) {
+	var doc graphML
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode graphml: %w", err)
+	}
+
+	names := make(map[string]string, len(doc.Keys))
+	for _, k := range doc.Keys {
+		name := k.AttrName
+		if name == "" {
+			name = k.ID
+		}
+		names[k.ID] = strings.ToLower(name)
+	}
+	attr := func(key string) string {
+		if name, ok := names[key]; ok {
+			return name
+		}
+		return strings.ToLower(key)
+	}
+
+	g := New()
+	for i, n := range doc.Graph.Nodes {
+		if n.ID == "" {
+			return nil, fmt.Errorf("graphml node %d has no id", i)
+		}
+		if _, dup := g.Nodes[n.ID]; dup {
+			return nil, fmt.Errorf("graphml: duplicate node id %q", n.ID)
+		}
+		node := &Node{ID: n.ID, Type: NodeOther}
+		for _, d := range n.Data {
+			switch attr(d.Key) {
+			case "label":
+				node.Label = d.Value
+			case "type":
+				if v := strings.TrimSpace(d.Value); v != "" {
+					node.Type = NodeType(v)
+				}
+			case "count":
+				c, err := strconv.Atoi(strings.TrimSpace(d.Value))
+				if err != nil {
+					return nil, fmt.Errorf("graphml node %q: invalid count %q", n.ID, d.Value)
+				}
+				node.Count = c
+			case "source_counts":
+				if err := json.Unmarshal([]byte(d.Value), &node.SourceCounts); err != nil {
+					return nil, fmt.Errorf("graphml node %q: invalid source_counts: %w", n.ID, err)
+				}
+			case "instances":
+				if err := json.Unmarshal([]byte(d.Value), &node.Instances); err != nil {
+					return nil, fmt.Errorf("graphml node %q: invalid instances: %w", n.ID, err)
+				}
+			}
+		}
+		g.UpsertNode(node)
+	}
+	for i, e := range doc.Graph.Edges {
+		if e.Source == "" || e.Target == "" {
+			return nil, fmt.Errorf("graphml edge %d is missing source/target", i)
+		}
+		edge := Edge{From: e.Source, To: e.Target}
+		contains := false
+		for _, d := range e.Data {
+			switch attr(d.Key) {
+			case "label":
+				edge.Label = d.Value
+			case "kind":
+				contains = strings.TrimSpace(d.Value) == "contains"
+			}
+		}
+		if contains {
+			g.AddContainment(edge.From, edge.To)
+			continue
+		}
+		if err := g.checkEndpoints(edge.From, edge.To); err != nil {
+			return nil, fmt.Errorf("graphml edge %d: %w", i, err)
+		}
+		g.AddEdge(edge)
+	}
+	return g, nil
+}
+
+// IsGraphMLPath reports whether a path should be treated as GraphML
+func IsGraphMLPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".graphml" || ext == ".xml"
+}
+
+// Save writes the graph to path, choosing GraphML for .graphml/.xml
+// extensions and canonical JSON otherwise.
+func Save(g *Graph, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if IsGraphMLPath(path) {
+		err = g.WriteGraphML(f)
+	} else {
+		var b []byte
+		b, err = json.MarshalIndent(g, "", "  ")
+		if err == nil {
+			_, err = f.Write(append(b, '\n'))
+		}
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Load reads a graph previously written by Save
+func Load(path string) (*Graph, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if IsGraphMLPath(path) {
+		return ReadGraphML(f)
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	g := New()
+	if err := json.Unmarshal(b, g); err != nil {
+		return nil, fmt.Errorf("load %s: %w", filepath.Base(path), err)
+	}
+	return g, nil
+}
@@ -0,0 +1,213 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func sampleGraph() *Graph {
+	g := New()
+	g.UpsertNode(&Node{ID: "v1", Label: "ItemRow", Type: NodeView, Count: 42, Instances: []Instance{{ID: "v1", Label: "ItemRow 0x6000", Count: 40}, {ID: "v2", Label: "ItemRow 0x7000", Count: 2}}})
+	g.UpsertNode(&Node{ID: "c1", Label: "Button tap", Type: NodeCause, Count: 3, SourceCounts: map[string]int{"run1": 1, "run2": 2}})
+	g.UpsertNode(&Node{ID: "s1", Label: `@State "items"`, Type: NodeState})
+	g.AddEdge(Edge{From: "s1", To: "v1", Label: "updates"})
+	g.AddEdge(Edge{From: "c1", To: "s1"})
+	g.AddContainment("v0", "v1")
+	return g
+}
+
+func assertSameGraph(t *testing.T, want, got *Graph) {
+	t.Helper()
+	if len(got.Nodes) != len(want.Nodes) {
+		t.Fatalf("expected %d nodes, got %d", len(want.Nodes), len(got.Nodes))
+	}
+	for id, w := range want.Nodes {
+		n, ok := got.Nodes[id]
+		if !ok {
+			t.Fatalf("node %s missing", id)
+		}
+		if !reflect.DeepEqual(n, w) {
+			t.Errorf("node %s: expected %+v, got %+v", id, *w, *n)
+		}
+	}
+	wantEdges := want.SortedEdges()
+	gotEdges := got.SortedEdges()
+	if len(gotEdges) != len(wantEdges) {
+		t.Fatalf("expected %d edges, got %d", len(wantEdges), len(gotEdges))
+	}
+	for i := range wantEdges {
+		if gotEdges[i] != wantEdges[i] {
+			t.Errorf("edge %d: expected %+v, got %+v", i, wantEdges[i], gotEdges[i])
+		}
+	}
+	if !reflect.DeepEqual(sortEdges(got.Containment), sortEdges(want.Containment)) {
+		t.Errorf("containment: expected %+v, got %+v", want.Containment, got.Containment)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	g := sampleGraph()
+	b, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if !strings.Contains(string(b), `"format":"swiftuice-graph"`) || !strings.Contains(string(b), `"version":1`) {
+		t.Errorf("missing format header: %s", b)
+	}
+
+	got := New()
+	if err := json.Unmarshal(b, got); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	assertSameGraph(t, g, got)
+}
+
+func TestJSONIsCanonical(t *testing.T) {
+	a := sampleGraph()
+	b := New()
+	// Same content inserted in a different order
+	b.AddEdge(Edge{From: "c1", To: "s1"})
+	b.AddEdge(Edge{From: "s1", To: "v1", Label: "updates"})
+	b.UpsertNode(&Node{ID: "s1", Label: `@State "items"`, Type: NodeState})
+	b.UpsertNode(&Node{ID: "c1", Label: "Button tap", Type: NodeCause, Count: 3, SourceCounts: map[string]int{"run2": 2, "run1": 1}})
+	b.UpsertNode(&Node{ID: "v1", Label: "ItemRow", Type: NodeView, Count: 42, Instances: []Instance{{ID: "v1", Label: "ItemRow 0x6000", Count: 40}, {ID: "v2", Label: "ItemRow 0x7000", Count: 2}}})
+	b.AddContainment("v0", "v1")
+
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	if !bytes.Equal(ja, jb) {
+		t.Errorf("expected identical output:\n%s\n%s", ja, jb)
+	}
+}
+
+func TestUnmarshalJSON_Rejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"wrong format", `{"format":"other","version":1,"nodes":[],"edges":[]}`},
+		{"future version", `{"format":"swiftuice-graph","version":99,"nodes":[],"edges":[]}`},
+		{"missing id", `{"format":"swiftuice-graph","version":1,"nodes":[{"label":"x"}],"edges":[]}`},
+		{"duplicate id", `{"format":"swiftuice-graph","version":1,"nodes":[{"id":"a"},{"id":"a"}],"edges":[]}`},
+		{"dangling edge end", `{"format":"swiftuice-graph","version":1,"nodes":[],"edges":[{"from":"a"}]}`},
+		{"unknown edge end", `{"format":"swiftuice-graph","version":1,"nodes":[{"id":"a"}],"edges":[{"from":"a","to":"b"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := json.Unmarshal([]byte(tt.input), New()); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSON_DefaultsType(t *testing.T) {
+	g := New()
+	in := `{"format":"swiftuice-graph","version":1,"nodes":[{"id":"a","label":"x"}],"edges":[]}`
+	if err := json.Unmarshal([]byte(in), g); err != nil {
+		t.Fatal(err)
+	}
+	if g.Nodes["a"].Type != NodeOther {
+		t.Errorf("expected NodeOther, got %s", g.Nodes["a"].Type)
+	}
+}
+
+func TestGraphMLRoundTrip(t *testing.T) {
+	g := sampleGraph()
+	var buf bytes.Buffer
+	if err := g.WriteGraphML(&buf); err != nil {
+		t.Fatalf("WriteGraphML failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `edgedefault="directed"`) {
+		t.Error("missing edgedefault")
+	}
+
+	got, err := ReadGraphML(&buf)
+	if err != nil {
+		t.Fatalf("ReadGraphML failed: %v", err)
+	}
+	assertSameGraph(t, g, got)
+}
+
+func TestReadGraphML_ForeignKeys(t *testing.T) {
+	// Keys named d0/d1 as written by other tools; resolved via attr.name
+	doc := `<?xml version="1.0"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="d0" for="node" attr.name="label" attr.type="string"/>
+  <key id="d1" for="node" attr.name="type" attr.type="string"/>
+  <key id="d2" for="node" attr.name="count" attr.type="int"/>
+  <graph edgedefault="directed">
+    <node id="a"><data key="d0">Timer</data><data key="d1">cause</data><data key="d2">7</data></node>
+    <node id="b"/>
+    <edge source="a" target="b"/>
+  </graph>
+</graphml>`
+	g, err := ReadGraphML(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := g.Nodes["a"]
+	if a.Label != "Timer" || a.Type != NodeCause || a.Count != 7 {
+		t.Errorf("unexpected node a: %+v", *a)
+	}
+	if g.Nodes["b"].Type != NodeOther {
+		t.Errorf("expected NodeOther for untyped node, got %s", g.Nodes["b"].Type)
+	}
+	if len(g.Edges) != 1 {
+		t.Errorf("expected 1 edge, got %d", len(g.Edges))
+	}
+}
+
+func TestReadGraphML_InvalidCount(t *testing.T) {
+	doc := `<graphml><key id="count" for="node" attr.name="count"/><graph><node id="a"><data key="count">lots</data></node></graph></graphml>`
+	if _, err := ReadGraphML(strings.NewReader(doc)); err == nil {
+		t.Error("expected error for invalid count")
+	}
+}
+
+func TestReadGraphML_Rejects(t *testing.T) {
+	// Same checks as the JSON form
+	tests := []struct {
+		name  string
+		graph string
+	}{
+		{"duplicate id", `<node id="a"/><node id="a"/>`},
+		{"unknown edge end", `<node id="a"/><edge source="a" target="b"/>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := `<graphml><graph edgedefault="directed">` + tt.graph + `</graph></graphml>`
+			if _, err := ReadGraphML(strings.NewReader(doc)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	g := sampleGraph()
+	dir := t.TempDir()
+	for _, name := range []string{"graph.json", "graph.graphml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := Save(g, path); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+			got, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			assertSameGraph(t, g, got)
+		})
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "nope.json")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
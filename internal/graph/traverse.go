@@ -0,0 +1,164 @@
+package graph
+
+// Successors returns the IDs of nodes with an edge from id, in edge order
+func (g *Graph) Successors(id string) []string {
+	var out []string
+	for _, e := range g.Edges {
+		if e.From == id {
+			out = append(out, e.To)
+		}
+	}
+	return out
+}
+
+// Predecessors returns the IDs of nodes with an edge to id, in edge order
+func (g *Graph) Predecessors(id string) []string {
+	var out []string
+	for _, e := range g.Edges {
+		if e.To == id {
+			out = append(out, e.From)
+		}
+	}
+	return out
+}
+
+// Downstream returns every node reachable from startID (excluding startID
+// itself unless it lies on a cycle), in depth-first discovery order.
+func (g *Graph) Downstream(startID string) []string {
+	return g.reach(startID, g.Successors)
+}
+
+// Upstream returns every node that can reach startID, in depth-first
+// discovery order.
+func (g *Graph) Upstream(startID string) []string {
+	return g.reach(startID, g.Predecessors)
+}
+
+func (g *Graph) reach(startID string, next func(string) []string) []string {
+	visited := map[string]bool{startID: true}
+	var out []string
+
+	var dfs func(id string)
+	dfs = func(id string) {
+		for _, n := range next(id) {
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+			out = append(out, n)
+			dfs(n)
+		}
+	}
+	dfs(startID)
+	return out
+}
+
+// ShortestPath returns the shortest directed path from one node to another
+// (inclusive), or nil if there is none. allow, when non-nil, restricts which
+// intermediate nodes may be traversed.
+func (g *Graph) ShortestPath(from, to string, allow func(*Node) bool) []string {
+	if from == to {
+		return []string{from}
+	}
+	prev := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, n := range g.Successors(id) {
+			if _, seen := prev[n]; seen {
+				continue
+			}
+			prev[n] = id
+			if n == to {
+				path := []string{to}
+				for p := id; p != ""; p = prev[p] {
+					path = append([]string{p}, path...)
+				}
+				return path
+			}
+			if !g.allowed(allow, n) {
+				continue
+			}
+			queue = append(queue, n)
+		}
+	}
+	return nil
+}
+
+// AllPaths returns every simple directed path from one node to another.
+// maxDepth limits the number of edges per path (0 = unlimited); allow has the
+// same meaning as in ShortestPath.
+func (g *Graph) AllPaths(from, to string, maxDepth int, allow func(*Node) bool) [][]string {
+	var paths [][]string
+	onPath := map[string]bool{}
+	var path []string
+
+	var dfs func(id string)
+	dfs = func(id string) {
+		path = append(path, id)
+		onPath[id] = true
+		defer func() {
+			path = path[:len(path)-1]
+			onPath[id] = false
+		}()
+
+		if id == to {
+			paths = append(paths, append([]string(nil), path...))
+			return
+		}
+		if maxDepth > 0 && len(path)-1 >= maxDepth {
+			return
+		}
+		if id != from && !g.allowed(allow, id) {
+			return
+		}
+		for _, n := range g.Successors(id) {
+			if !onPath[n] {
+				dfs(n)
+			}
+		}
+	}
+	dfs(from)
+	return paths
+}
+
+// allowed applies a traversal filter; edges may point at IDs without a node,
+// which are presented to the filter as untyped placeholders.
+func (g *Graph) allowed(allow func(*Node) bool, id string) bool {
+	if allow == nil {
+		return true
+	}
+	n, ok := g.Nodes[id]
+	if !ok {
+		n = &Node{ID: id, Type: NodeOther}
+	}
+	return allow(n)
+}
+
+// Subgraph returns a new graph containing only the given nodes and the edges
+// between them. Nodes are copied so the result can be modified freely.
+func (g *Graph) Subgraph(ids []string) *Graph {
+	keep := make(map[string]bool, len(ids))
+	sub := New()
+	for _, id := range ids {
+		n, ok := g.Nodes[id]
+		if !ok || keep[id] {
+			continue
+		}
+		keep[id] = true
+		cp := *n
+		sub.Nodes[id] = &cp
+	}
+	for _, e := range g.Edges {
+		if keep[e.From] && keep[e.To] {
+			sub.AddEdge(e)
+		}
+	}
+	for _, e := range g.Containment {
+		if keep[e.From] && keep[e.To] {
+			sub.AddContainment(e.From, e.To)
+		}
+	}
+	return sub
+}
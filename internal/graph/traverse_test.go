@@ -0,0 +1,96 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+// diamond: c1 -> s1 -> v1, c1 -> s2 -> v1, s2 -> v2
+func diamondGraph() *Graph {
+	g := New()
+	g.UpsertNode(&Node{ID: "c1", Label: "Tap", Type: NodeCause})
+	g.UpsertNode(&Node{ID: "s1", Label: "items", Type: NodeState})
+	g.UpsertNode(&Node{ID: "s2", Label: "filter", Type: NodeState})
+	g.UpsertNode(&Node{ID: "v1", Label: "ListView", Type: NodeView, Count: 20})
+	g.UpsertNode(&Node{ID: "v2", Label: "Header", Type: NodeView, Count: 2})
+	g.AddEdge(Edge{From: "c1", To: "s1"})
+	g.AddEdge(Edge{From: "c1", To: "s2"})
+	g.AddEdge(Edge{From: "s1", To: "v1"})
+	g.AddEdge(Edge{From: "s2", To: "v1"})
+	g.AddEdge(Edge{From: "s2", To: "v2"})
+	return g
+}
+
+func TestSuccessorsPredecessors(t *testing.T) {
+	g := diamondGraph()
+	if got := g.Successors("c1"); !reflect.DeepEqual(got, []string{"s1", "s2"}) {
+		t.Errorf("Successors(c1) = %v", got)
+	}
+	if got := g.Predecessors("v1"); !reflect.DeepEqual(got, []string{"s1", "s2"}) {
+		t.Errorf("Predecessors(v1) = %v", got)
+	}
+}
+
+func TestDownstreamUpstream(t *testing.T) {
+	g := diamondGraph()
+	if got := g.Downstream("c1"); !reflect.DeepEqual(got, []string{"s1", "v1", "s2", "v2"}) {
+		t.Errorf("Downstream(c1) = %v", got)
+	}
+	if got := g.Upstream("v1"); !reflect.DeepEqual(got, []string{"s1", "c1", "s2"}) {
+		t.Errorf("Upstream(v1) = %v", got)
+	}
+}
+
+func TestDownstream_Cycle(t *testing.T) {
+	g := New()
+	g.AddEdge(Edge{From: "a", To: "b"})
+	g.AddEdge(Edge{From: "b", To: "a"})
+	if got := g.Downstream("a"); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("Downstream on cycle = %v", got)
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	g := diamondGraph()
+	if got := g.ShortestPath("c1", "v2", nil); !reflect.DeepEqual(got, []string{"c1", "s2", "v2"}) {
+		t.Errorf("ShortestPath(c1, v2) = %v", got)
+	}
+	if got := g.ShortestPath("v2", "c1", nil); got != nil {
+		t.Errorf("expected no path against edge direction, got %v", got)
+	}
+	// Forbid s2: v2 becomes unreachable
+	noS2 := func(n *Node) bool { return n.ID != "s2" }
+	if got := g.ShortestPath("c1", "v2", noS2); got != nil {
+		t.Errorf("expected no path avoiding s2, got %v", got)
+	}
+}
+
+func TestAllPaths(t *testing.T) {
+	g := diamondGraph()
+	paths := g.AllPaths("c1", "v1", 0, nil)
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 paths, got %v", paths)
+	}
+	if got := g.AllPaths("c1", "v1", 1, nil); len(got) != 0 {
+		t.Errorf("expected no paths within 1 edge, got %v", got)
+	}
+	onlyS1 := func(n *Node) bool { return n.ID != "s2" }
+	if got := g.AllPaths("c1", "v1", 0, onlyS1); len(got) != 1 {
+		t.Errorf("expected 1 path avoiding s2, got %v", got)
+	}
+}
+
+func TestSubgraph(t *testing.T) {
+	g := diamondGraph()
+	sub := g.Subgraph([]string{"c1", "s2", "v2", "missing"})
+	if len(sub.Nodes) != 3 {
+		t.Errorf("expected 3 nodes, got %d", len(sub.Nodes))
+	}
+	if len(sub.Edges) != 2 {
+		t.Errorf("expected 2 edges, got %v", sub.Edges)
+	}
+	sub.Nodes["v2"].Count = 99
+	if g.Nodes["v2"].Count == 99 {
+		t.Error("Subgraph should copy nodes")
+	}
+}
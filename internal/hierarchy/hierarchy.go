@@ -0,0 +1,165 @@
+// Package hierarchy rolls view update counts up the view containment tree.
+package hierarchy
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+// Link is a containment relationship between two view type names
+type Link struct {
+	Parent string
+	Child  string
+}
+
+// Rollup is one view type in the containment tree with its own update
+// count and the total for everything nested beneath it.
+type Rollup struct {
+	View           string    `json:"view"`
+	NodeIDs        []string  `json:"node_ids,omitempty"`
+	SelfUpdates    int       `json:"self_updates"`
+	TotalUpdates   int       `json:"total_updates"`
+	FlaggedUpdates int       `json:"flagged_updates,omitempty"` // updates on nodes named in issues
+	Repeat         bool      `json:"repeat,omitempty"`          // children listed where the view first appears
+	Children       []*Rollup `json:"children,omitempty"`
+}
+
+var reTypeName = regexp.MustCompile(`\b[A-Z][A-Za-z0-9_]*\b`)
+
+// wrapperNames are capitalized words in labels that name SwiftUI itself or
+// a property wrapper rather than the view type
+var wrapperNames = map[string]bool{
+	"View": true, "Body": true, "State": true, "Binding": true, "Environment": true,
+	"EnvironmentObject": true, "ObservedObject": true, "StateObject": true,
+	"Published": true, "Observable": true, "Bindable": true,
+}
+
+// ViewName returns the view type a node label refers to: the last
+// capitalized identifier that is not a SwiftUI keyword (so "View body
+// update RowView" and "ForEach<[Item], RowView>" give RowView), or the
+// trimmed label when there is none.
+func ViewName(label string) string {
+	matches := reTypeName.FindAllString(label, -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		if !wrapperNames[matches[i]] {
+			return matches[i]
+		}
+	}
+	return strings.TrimSpace(label)
+}
+
+// Build assembles the containment tree from the graph's own containment
+// edges plus links found elsewhere (e.g. in source), and returns the roots
+// ordered by total updates. Views are grouped by type name, so every
+// instance of a row counts toward one entry. names gives the type a node
+// was matched to in source, preferred over ViewName of its label so the
+// graph's views line up with the source links. A child shared by several
+// parents is counted under each of them, but its own children are listed
+// only where it first appears; later entries are marked Repeat. Nodes in
+// flagged contribute to FlaggedUpdates. Views that only appear inside a
+// containment cycle have no root and are left out. Build returns nil when
+// no containment is known.
+func Build(g *graph.Graph, links []Link, flagged map[string]bool, names map[string]string) []*Rollup {
+	nameOf := func(id string) string {
+		if name := names[id]; name != "" {
+			return name
+		}
+		if n, ok := g.Nodes[id]; ok && n.Label != "" {
+			return ViewName(n.Label)
+		}
+		return id
+	}
+
+	children := map[string]map[string]bool{}
+	hasParent := map[string]bool{}
+	add := func(parent, child string) {
+		if parent == "" || child == "" || parent == child {
+			return
+		}
+		if children[parent] == nil {
+			children[parent] = map[string]bool{}
+		}
+		children[parent][child] = true
+		hasParent[child] = true
+	}
+	for _, e := range g.Containment {
+		add(nameOf(e.From), nameOf(e.To))
+	}
+	for _, l := range links {
+		add(l.Parent, l.Child)
+	}
+	if len(children) == 0 {
+		return nil
+	}
+
+	self := map[string]int{}
+	flaggedSelf := map[string]int{}
+	ids := map[string][]string{}
+	for _, n := range g.SortedNodes() {
+		if n.Type != graph.NodeView {
+			continue
+		}
+		name := nameOf(n.ID)
+		self[name] += n.Count
+		ids[name] = append(ids[name], n.ID)
+		if flagged[n.ID] {
+			flaggedSelf[name] += n.Count
+		}
+	}
+
+	built := map[string]*Rollup{}
+	visiting := map[string]bool{}
+	var build func(name string) *Rollup
+	build = func(name string) *Rollup {
+		if done := built[name]; done != nil {
+			return &Rollup{View: name, NodeIDs: done.NodeIDs, SelfUpdates: done.SelfUpdates,
+				TotalUpdates: done.TotalUpdates, FlaggedUpdates: done.FlaggedUpdates, Repeat: len(done.Children) > 0}
+		}
+		r := &Rollup{View: name, NodeIDs: ids[name], SelfUpdates: self[name], FlaggedUpdates: flaggedSelf[name]}
+		r.TotalUpdates = r.SelfUpdates
+		visiting[name] = true
+		for _, child := range sortedKeys(children[name]) {
+			if visiting[child] {
+				continue // containment cycle; stop here
+			}
+			c := build(child)
+			r.TotalUpdates += c.TotalUpdates
+			r.FlaggedUpdates += c.FlaggedUpdates
+			r.Children = append(r.Children, c)
+		}
+		visiting[name] = false
+		built[name] = r
+		sortRollups(r.Children)
+		return r
+	}
+
+	var roots []*Rollup
+	for _, name := range sortedKeys(children) {
+		if !hasParent[name] {
+			roots = append(roots, build(name))
+		}
+	}
+	sortRollups(roots)
+	return roots
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortRollups(rs []*Rollup) {
+	sort.Slice(rs, func(i, j int) bool {
+		if rs[i].TotalUpdates != rs[j].TotalUpdates {
+			return rs[i].TotalUpdates > rs[j].TotalUpdates
+		}
+		return rs[i].View < rs[j].View
+	})
+}
@@ -0,0 +1,140 @@
+package hierarchy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+func TestViewName(t *testing.T) {
+	tests := []struct {
+		label    string
+		expected string
+	}{
+		{"RowView", "RowView"},
+		{"ContentView body() called", "ContentView"},
+		{"View body update RowView", "RowView"},
+		{"ForEach<Array<Item>, Int, RowView>", "RowView"},
+		{"RowView: 0x600003A1C000", "RowView"},
+		{"@State items", "@State items"},
+		{"  row  ", "row"},
+	}
+	for _, tt := range tests {
+		if got := ViewName(tt.label); got != tt.expected {
+			t.Errorf("ViewName(%q) = %q, want %q", tt.label, got, tt.expected)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "screen", Label: "ListScreen", Type: graph.NodeView, Count: 1})
+	g.UpsertNode(&graph.Node{ID: "r1", Label: "RowView", Type: graph.NodeView, Count: 20})
+	g.UpsertNode(&graph.Node{ID: "r2", Label: "RowView", Type: graph.NodeView, Count: 20})
+	g.UpsertNode(&graph.Node{ID: "settings", Label: "SettingsScreen", Type: graph.NodeView, Count: 3})
+	g.AddContainment("screen", "r1")
+	g.AddContainment("screen", "r2")
+
+	// Source says rows contain a badge that the trace never saw update
+	links := []Link{{Parent: "RowView", Child: "Badge"}, {Parent: "SettingsScreen", Child: "Toggle"}}
+	roots := Build(g, links, map[string]bool{"r1": true}, nil)
+
+	if len(roots) != 2 {
+		t.Fatalf("expected 2 roots, got %d", len(roots))
+	}
+	list := roots[0]
+	if list.View != "ListScreen" || list.SelfUpdates != 1 || list.TotalUpdates != 41 || list.FlaggedUpdates != 20 {
+		t.Errorf("unexpected list rollup: %+v", list)
+	}
+	if len(list.Children) != 1 {
+		t.Fatalf("expected rows grouped under one child, got %+v", list.Children)
+	}
+	row := list.Children[0]
+	if row.View != "RowView" || row.TotalUpdates != 40 || len(row.NodeIDs) != 2 {
+		t.Errorf("unexpected row rollup: %+v", row)
+	}
+	if len(row.Children) != 1 || row.Children[0].View != "Badge" || row.Children[0].TotalUpdates != 0 {
+		t.Errorf("expected source-only Badge child, got %+v", row.Children)
+	}
+	if roots[1].View != "SettingsScreen" || roots[1].TotalUpdates != 3 {
+		t.Errorf("unexpected second root: %+v", roots[1])
+	}
+}
+
+func TestBuild_Cycle(t *testing.T) {
+	g := graph.New()
+	links := []Link{{Parent: "Root", Child: "A"}, {Parent: "A", Child: "B"}, {Parent: "B", Child: "A"}}
+	roots := Build(g, links, nil, nil)
+	if len(roots) != 1 || roots[0].View != "Root" {
+		t.Fatalf("unexpected roots: %+v", roots)
+	}
+	a := roots[0].Children[0]
+	if len(a.Children) != 1 || len(a.Children[0].Children) != 0 {
+		t.Errorf("cycle should stop at the repeated view: %+v", a)
+	}
+}
+
+func TestBuild_SharedChildren(t *testing.T) {
+	// Each level holds two views that both contain the next two: fully
+	// expanded, the tree would have 2^40 leaves
+	g := graph.New()
+	var links []Link
+	for i := 0; i < 40; i++ {
+		for _, p := range []string{"L", "R"} {
+			for _, c := range []string{"L", "R"} {
+				links = append(links, Link{Parent: fmt.Sprintf("%s%d", p, i), Child: fmt.Sprintf("%s%d", c, i+1)})
+			}
+		}
+	}
+	links = append(links, Link{Parent: "Root", Child: "L0"}, Link{Parent: "Root", Child: "R0"})
+	g.UpsertNode(&graph.Node{ID: "leaf", Label: "L40", Type: graph.NodeView, Count: 1})
+
+	roots := Build(g, links, nil, nil)
+	if len(roots) != 1 || roots[0].TotalUpdates != 1<<40 {
+		t.Fatalf("expected the leaf counted under every path, got %+v", roots)
+	}
+	entries, repeats := 0, 0
+	var walk func(rs []*Rollup)
+	walk = func(rs []*Rollup) {
+		for _, r := range rs {
+			entries++
+			if r.Repeat {
+				repeats++
+				if len(r.Children) > 0 || r.TotalUpdates == 0 {
+					t.Errorf("repeat %s should keep its totals but not its children: %+v", r.View, r)
+				}
+			}
+			walk(r.Children)
+		}
+	}
+	walk(roots)
+	if entries > 200 || repeats == 0 {
+		t.Errorf("expected each view expanded once, got %d entries, %d repeats", entries, repeats)
+	}
+}
+
+func TestBuild_NoContainment(t *testing.T) {
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "v", Label: "RowView", Type: graph.NodeView, Count: 5})
+	if roots := Build(g, nil, nil, nil); roots != nil {
+		t.Errorf("expected nil, got %+v", roots)
+	}
+}
+
+func TestBuild_SourceNames(t *testing.T) {
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "list", Label: "View body update ListScreen", Type: graph.NodeView, Count: 2})
+	g.UpsertNode(&graph.Node{ID: "row", Label: "ModifiedContent<Row, _PaddingLayout>", Type: graph.NodeView, Count: 30})
+
+	// the label says Row, but source matched the node to RowView, which
+	// the source links nest under ListScreen
+	links := []Link{{Parent: "ListScreen", Child: "RowView"}}
+	roots := Build(g, links, nil, map[string]string{"row": "RowView"})
+	if len(roots) != 1 || roots[0].View != "ListScreen" || roots[0].TotalUpdates != 32 {
+		t.Fatalf("unexpected roots: %+v", roots)
+	}
+	if c := roots[0].Children; len(c) != 1 || c[0].View != "RowView" || c[0].SelfUpdates != 30 {
+		t.Errorf("unexpected children: %+v", c)
+	}
+}
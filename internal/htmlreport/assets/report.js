@@ -0,0 +1,298 @@
+// Interactive graph and issue filters for the swiftuice HTML report.
+// GRAPH, ISSUES and LINKS are defined by the page before this script runs.
+(function () {
+  "use strict";
+
+  var SVG_NS = "http://www.w3.org/2000/svg";
+  var NODE_H = 34, LAYER_GAP = 90, ROW_GAP = 22, MAX_LABEL = 28;
+  var TYPE_RANK = { cause: 0, state: 1, view: 2, other: 3 };
+
+  var nodes = (GRAPH && GRAPH.nodes) || [];
+  var index = {};
+  nodes.forEach(function (n) { index[n.id] = n; });
+  function byId(id) { return index[id]; }
+  var edges = ((GRAPH && GRAPH.edges) || []).filter(function (e) {
+    return byId(e.from) && byId(e.to);
+  });
+
+  var svg = document.getElementById("graph");
+  var details = document.getElementById("node-details");
+  var viewport = el("g", {});
+  svg.appendChild(viewport);
+
+  function el(name, attrs) {
+    var e = document.createElementNS(SVG_NS, name);
+    Object.keys(attrs).forEach(function (k) { e.setAttribute(k, attrs[k]); });
+    return e;
+  }
+
+  function truncate(s) {
+    return s.length > MAX_LABEL ? s.slice(0, MAX_LABEL - 1) + "…" : s;
+  }
+
+  // Heat color from pale blue (few updates) through amber to red (many)
+  var maxCount = nodes.reduce(function (m, n) { return Math.max(m, n.update_count || 0); }, 0);
+  function heat(count) {
+    if (!maxCount || !count) return "hsl(210, 70%, 92%)";
+    var t = Math.log(1 + count) / Math.log(1 + maxCount);
+    if (t < 0.5) {
+      var u = t / 0.5;
+      return "hsl(" + (210 - 170 * u) + ", " + (70 + 20 * u) + "%, " + (92 - 27 * u) + "%)";
+    }
+    var v = (t - 0.5) / 0.5;
+    return "hsl(" + (40 - 40 * v) + ", " + (90 - 15 * v) + "%, " + (65 - 10 * v) + "%)";
+  }
+
+  // Layered layout: each node sits one layer right of its deepest
+  // predecessor (bounded so cycles terminate), then each layer is ordered
+  // by the average position of its predecessors to reduce crossings.
+  function layout() {
+    var layer = {};
+    nodes.forEach(function (n) { layer[n.id] = 0; });
+    for (var pass = 0; pass < nodes.length; pass++) {
+      var changed = false;
+      edges.forEach(function (e) {
+        if (layer[e.to] < layer[e.from] + 1 && layer[e.from] + 1 < nodes.length) {
+          layer[e.to] = layer[e.from] + 1;
+          changed = true;
+        }
+      });
+      if (!changed) break;
+    }
+
+    var layers = [];
+    nodes.slice().sort(function (a, b) {
+      return (TYPE_RANK[a.type] || 0) - (TYPE_RANK[b.type] || 0) || (b.update_count || 0) - (a.update_count || 0);
+    }).forEach(function (n) {
+      (layers[layer[n.id]] = layers[layer[n.id]] || []).push(n);
+    });
+
+    var preds = {};
+    edges.forEach(function (e) { (preds[e.to] = preds[e.to] || []).push(e.from); });
+    var row = {};
+    layers.forEach(function (ls) {
+      if (!ls) return;
+      ls.forEach(function (n, i) {
+        var ps = (preds[n.id] || []).filter(function (p) { return p in row; });
+        n._order = ps.length ? ps.reduce(function (s, p) { return s + row[p]; }, 0) / ps.length : i;
+      });
+      ls.sort(function (a, b) { return a._order - b._order; });
+      ls.forEach(function (n, i) { row[n.id] = i; });
+    });
+
+    var x = 20;
+    layers.forEach(function (ls) {
+      if (!ls) return;
+      var width = ls.reduce(function (w, n) { return Math.max(w, 7 * truncate(n.label || n.id).length + 24); }, 80);
+      ls.forEach(function (n, i) {
+        n._w = width;
+        n._x = x;
+        n._y = 20 + i * (NODE_H + ROW_GAP);
+      });
+      x += width + LAYER_GAP;
+    });
+  }
+
+  function shapeFor(n) {
+    var w = n._w, h = NODE_H, fill = heat(n.update_count);
+    if (n.type === "cause") return el("ellipse", { cx: w / 2, cy: h / 2, rx: w / 2, ry: h / 2, fill: fill });
+    if (n.type === "state") {
+      var pts = [[12, 0], [w - 12, 0], [w, h / 2], [w - 12, h], [12, h], [0, h / 2]];
+      return el("polygon", { points: pts.map(function (p) { return p.join(","); }).join(" "), fill: fill });
+    }
+    return el("rect", { width: w, height: h, rx: 4, fill: fill });
+  }
+
+  var nodeEls = {}, edgeEls = [];
+  function draw() {
+    edges.forEach(function (e) {
+      var a = byId(e.from), b = byId(e.to);
+      var x1 = a._x + a._w, y1 = a._y + NODE_H / 2, x2 = b._x, y2 = b._y + NODE_H / 2;
+      if (x2 <= x1) { x1 = a._x + a._w / 2; x2 = b._x + b._w / 2; } // back edge
+      var mx = (x1 + x2) / 2;
+      var path = el("path", { "class": "edge", d: "M" + x1 + "," + y1 + " C" + mx + "," + y1 + " " + mx + "," + y2 + " " + x2 + "," + y2, "marker-end": "url(#arrow)" });
+      var title = el("title", {});
+      title.textContent = e.label || "";
+      path.appendChild(title);
+      path._edge = e;
+      viewport.appendChild(path);
+      edgeEls.push(path);
+    });
+    nodes.forEach(function (n) {
+      var g = el("g", { "class": "node", transform: "translate(" + n._x + "," + n._y + ")" });
+      g.appendChild(shapeFor(n));
+      var text = el("text", { x: n._w / 2, y: NODE_H / 2 + 4, "text-anchor": "middle" });
+      var label = truncate(n.label || n.id);
+      if (n.update_count) label += " ×" + n.update_count;
+      text.textContent = label;
+      var title = el("title", {});
+      title.textContent = n.label || n.id;
+      g.appendChild(title);
+      g.appendChild(text);
+      g.addEventListener("click", function (ev) { ev.stopPropagation(); select(n.id); });
+      viewport.appendChild(g);
+      nodeEls[n.id] = g;
+    });
+  }
+
+  function defs() {
+    var d = el("defs", {});
+    var m = el("marker", { id: "arrow", viewBox: "0 0 10 10", refX: 10, refY: 5, markerWidth: 7, markerHeight: 7, orient: "auto-start-reverse" });
+    m.appendChild(el("path", { d: "M0,0 L10,5 L0,10 z", fill: "#8e8e93" }));
+    d.appendChild(m);
+    svg.insertBefore(d, viewport);
+  }
+
+  // Pan and zoom by rewriting the viewport transform
+  var view = { x: 0, y: 0, k: 1 };
+  function apply() {
+    viewport.setAttribute("transform", "translate(" + view.x + "," + view.y + ") scale(" + view.k + ")");
+  }
+  function zoomAt(factor, cx, cy) {
+    var k = Math.min(4, Math.max(0.1, view.k * factor));
+    view.x = cx - (cx - view.x) * (k / view.k);
+    view.y = cy - (cy - view.y) * (k / view.k);
+    view.k = k;
+    apply();
+  }
+  function fit() {
+    var box = viewport.getBBox(), r = svg.getBoundingClientRect();
+    if (!box.width || !box.height) return;
+    var k = Math.min(2, Math.min((r.width - 40) / box.width, (r.height - 40) / box.height));
+    view.k = k;
+    view.x = (r.width - box.width * k) / 2 - box.x * k;
+    view.y = (r.height - box.height * k) / 2 - box.y * k;
+    apply();
+  }
+
+  svg.addEventListener("wheel", function (ev) {
+    ev.preventDefault();
+    var r = svg.getBoundingClientRect();
+    zoomAt(ev.deltaY < 0 ? 1.15 : 1 / 1.15, ev.clientX - r.left, ev.clientY - r.top);
+  }, { passive: false });
+
+  var drag = null;
+  svg.addEventListener("mousedown", function (ev) {
+    drag = { x: ev.clientX - view.x, y: ev.clientY - view.y };
+    svg.classList.add("panning");
+  });
+  window.addEventListener("mousemove", function (ev) {
+    if (!drag) return;
+    view.x = ev.clientX - drag.x;
+    view.y = ev.clientY - drag.y;
+    apply();
+  });
+  window.addEventListener("mouseup", function () {
+    drag = null;
+    svg.classList.remove("panning");
+  });
+  svg.addEventListener("click", function () { select(null); });
+
+  function center() {
+    var r = svg.getBoundingClientRect();
+    return [r.width / 2, r.height / 2];
+  }
+  document.getElementById("zoom-in").addEventListener("click", function () { var c = center(); zoomAt(1.25, c[0], c[1]); });
+  document.getElementById("zoom-out").addEventListener("click", function () { var c = center(); zoomAt(0.8, c[0], c[1]); });
+  document.getElementById("zoom-reset").addEventListener("click", fit);
+
+  // Selection: highlight a node's direct edges and show its details
+  function select(id) {
+    Object.keys(nodeEls).forEach(function (k) { nodeEls[k].classList.toggle("selected", k === id); });
+    edgeEls.forEach(function (p) { p.classList.toggle("hot", !!id && (p._edge.from === id || p._edge.to === id)); });
+    if (!id) {
+      details.hidden = true;
+      return;
+    }
+    var n = byId(id);
+    details.textContent = "";
+    var h = document.createElement("h3");
+    h.textContent = n.label || n.id;
+    details.appendChild(h);
+    line("Type", n.type);
+    line("ID", n.id);
+    if (n.update_count) line("Updates", n.update_count);
+    if (n.instance_count > 1) line("Instances", n.instance_count);
+    if (n.source_file) {
+      var p = document.createElement("p");
+      var loc = n.source_file + ":" + n.line_number;
+      if (LINKS[n.id]) {
+        var a = document.createElement("a");
+        a.href = LINKS[n.id];
+        a.textContent = loc;
+        p.appendChild(a);
+      } else {
+        p.textContent = loc;
+      }
+      details.appendChild(p);
+    }
+    list("Caused by", edges.filter(function (e) { return e.to === id; }).map(function (e) { return e.from; }));
+    list("Updates", edges.filter(function (e) { return e.from === id; }).map(function (e) { return e.to; }));
+    if (n.instances && n.instances.length > 1) {
+      list("Raw labels", n.instances.map(function (i) { return i.label + (i.count ? " ×" + i.count : ""); }), true);
+    }
+    details.hidden = false;
+
+    function line(k, v) {
+      var p = document.createElement("p");
+      p.textContent = k + ": " + v;
+      details.appendChild(p);
+    }
+    function list(title, items, raw) {
+      if (!items.length) return;
+      line(title, "");
+      var ul = document.createElement("ul");
+      items.forEach(function (it) {
+        var li = document.createElement("li");
+        li.textContent = raw ? it : ((byId(it) || {}).label || it);
+        ul.appendChild(li);
+      });
+      details.appendChild(ul);
+    }
+  }
+
+  // Search dims nodes whose labels do not match
+  document.getElementById("node-search").addEventListener("input", function (ev) {
+    var q = ev.target.value.trim().toLowerCase();
+    nodes.forEach(function (n) {
+      var hit = !q || (n.label || "").toLowerCase().indexOf(q) >= 0 || n.id.toLowerCase() === q;
+      nodeEls[n.id].classList.toggle("dim", !hit);
+    });
+  });
+
+  // Issue filters and hover highlighting
+  var rows = Array.prototype.slice.call(document.querySelectorAll("tr.issue"));
+  var sevBoxes = Array.prototype.slice.call(document.querySelectorAll("#severity-filter input"));
+  var typeSelect = document.getElementById("type-filter");
+  function filter() {
+    var sev = {};
+    sevBoxes.forEach(function (b) { sev[b.value] = b.checked; });
+    var type = typeSelect ? typeSelect.value : "";
+    var shown = 0;
+    rows.forEach(function (r) {
+      var ok = sev[r.dataset.severity] !== false && (!type || r.dataset.type === type);
+      r.hidden = !ok;
+      if (ok) shown++;
+    });
+    var none = document.getElementById("no-issues-match");
+    if (none) none.hidden = shown > 0;
+    flag(rows.filter(function (r) { return !r.hidden; }));
+  }
+  function flag(visible) {
+    var ids = {};
+    visible.forEach(function (r) { (ISSUES[r.dataset.id] || []).forEach(function (id) { ids[id] = true; }); });
+    Object.keys(nodeEls).forEach(function (k) { nodeEls[k].classList.toggle("flagged", !!ids[k]); });
+  }
+  sevBoxes.forEach(function (b) { b.addEventListener("change", filter); });
+  if (typeSelect) typeSelect.addEventListener("change", filter);
+  rows.forEach(function (r) {
+    r.addEventListener("mouseenter", function () { flag([r]); });
+    r.addEventListener("mouseleave", filter);
+  });
+
+  layout();
+  defs();
+  draw();
+  fit();
+  filter();
+})();
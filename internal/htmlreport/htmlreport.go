@@ -0,0 +1,146 @@
+// Package htmlreport renders an analysis report as a single offline HTML page.
+package htmlreport
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+)
+
+var (
+	//go:embed assets/report.html.tmpl
+	pageTemplate string
+	//go:embed assets/report.css
+	pageCSS string
+	//go:embed assets/report.js
+	pageJS string
+)
+
+var tmpl = template.Must(template.New("report").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(pageTemplate))
+
+// page is the data handed to the template
+type page struct {
+	Report     *aioutput.Report
+	Issues     []issueRow
+	Severities []string
+	Types      []string
+	IssueNodes map[string][]string // issue ID → affected node IDs
+	Links      map[string]string   // node ID → source URL
+	CSS        template.CSS
+	JS         template.JS
+}
+
+type issueRow struct {
+	aioutput.IssueWithFixes
+	Nodes []nodeRef
+}
+
+type nodeRef struct {
+	ID     string
+	Label  string
+	Source string // "path:line" when correlated
+	URL    string
+}
+
+// Render writes the report as one self-contained HTML document: styles,
+// script and data are inlined so the file works offline and can be
+// attached to a ticket as is.
+func Render(w io.Writer, r *aioutput.Report) error {
+	p := page{
+		Report:     r,
+		IssueNodes: map[string][]string{},
+		Links:      map[string]string{},
+		CSS:        template.CSS(pageCSS),
+		JS:         template.JS(pageJS),
+	}
+
+	refs := issues.NewResolver(r.Graph.ToGraph())
+	nodes := map[string]aioutput.NodeData{}
+	for _, n := range r.Graph.Nodes {
+		nodes[n.ID] = n
+		if u := sourceURL(r.Input.SourceRoot, n.SourceFile); u != "" {
+			p.Links[n.ID] = u
+		}
+	}
+
+	seenSeverity := map[string]bool{}
+	seenType := map[string]bool{}
+	for _, issue := range r.Issues {
+		row := issueRow{IssueWithFixes: issue}
+		for _, ref := range issue.AffectedNodes {
+			id, ok := refs.NodeID(ref)
+			if !ok {
+				row.Nodes = append(row.Nodes, nodeRef{ID: ref, Label: ref})
+				continue
+			}
+			n := nodes[id]
+			p.IssueNodes[issue.ID] = append(p.IssueNodes[issue.ID], n.ID)
+			nr := nodeRef{ID: n.ID, Label: n.Label, URL: p.Links[n.ID]}
+			if n.SourceFile != "" {
+				nr.Source = fmt.Sprintf("%s:%d", n.SourceFile, n.LineNumber)
+			}
+			row.Nodes = append(row.Nodes, nr)
+		}
+		p.Issues = append(p.Issues, row)
+		seenSeverity[string(issue.Severity)] = true
+		seenType[string(issue.Type)] = true
+	}
+	issues.SortBySeverity(p.Issues, func(row issueRow) (issues.Severity, int) { return row.Severity, row.UpdateCount })
+
+	for _, s := range []issues.Severity{issues.SeverityCritical, issues.SeverityHigh, issues.SeverityMedium, issues.SeverityLow, issues.SeverityInfo} {
+		if seenSeverity[string(s)] {
+			p.Severities = append(p.Severities, string(s))
+		}
+	}
+	for t := range seenType {
+		p.Types = append(p.Types, t)
+	}
+	sort.Strings(p.Types)
+
+	return tmpl.Execute(w, p)
+}
+
+// Write renders the report to path
+func Write(r *aioutput.Report, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Render(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// sourceURL builds a file:// link for a correlated source file. Relative
+// paths are resolved against the source root; without one no link is made.
+func sourceURL(root, rel string) string {
+	if rel == "" {
+		return ""
+	}
+	path := rel
+	if !filepath.IsAbs(path) {
+		if root == "" {
+			return ""
+		}
+		abs, err := filepath.Abs(filepath.Join(root, rel))
+		if err != nil {
+			return ""
+		}
+		path = abs
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
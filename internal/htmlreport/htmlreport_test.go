@@ -0,0 +1,87 @@
+package htmlreport
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+func sampleReport(t *testing.T) *aioutput.Report {
+	t.Helper()
+	gen, err := aioutput.NewGenerator("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "c1", Label: "Timer fired", Type: graph.NodeCause, Count: 60})
+	g.UpsertNode(&graph.Node{ID: "s1", Label: "@Published now", Type: graph.NodeState})
+	g.UpsertNode(&graph.Node{ID: "v1", Label: "ClockView</script><b>", Type: graph.NodeView, Count: 60})
+	g.AddEdge(graph.Edge{From: "c1", To: "s1"})
+	g.AddEdge(graph.Edge{From: "s1", To: "v1"})
+	r := gen.Generate(g, aioutput.GenerateOptions{TracePath: "run.trace"})
+	if len(r.Issues) == 0 {
+		t.Fatal("sample report should contain issues")
+	}
+	return r
+}
+
+func TestRender(t *testing.T) {
+	r := sampleReport(t)
+	var buf bytes.Buffer
+	if err := Render(&buf, r); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{"<svg id=\"graph\"", "const GRAPH =", "run.trace", "severity-filter", "type-filter"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+	fixes := 0
+	for _, issue := range r.Issues {
+		if !strings.Contains(out, `data-id="`+issue.ID+`"`) {
+			t.Errorf("issue %s missing from table", issue.ID)
+		}
+		fixes += len(issue.SuggestedFixes)
+	}
+	if got := strings.Count(out, `<div class="fix">`); got != fixes {
+		t.Errorf("expected %d fixes rendered, got %d", fixes, got)
+	}
+}
+
+func TestRender_SelfContained(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, sampleReport(t)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, ref := range []string{"<script src", "<link ", "https://", "@import"} {
+		if strings.Contains(out, ref) {
+			t.Errorf("output should not reference external resources, found %q", ref)
+		}
+	}
+	// Labels must not be able to close the data script early
+	if strings.Count(out, "</script>") != 2 {
+		t.Errorf("expected exactly 2 closing script tags, got %d", strings.Count(out, "</script>"))
+	}
+}
+
+func TestSourceURL(t *testing.T) {
+	tests := []struct {
+		root, rel, expected string
+	}{
+		{"", "", ""},
+		{"", "Views/Row.swift", ""},
+		{"/src/App", "Views/Row View.swift", "file:///src/App/Views/Row%20View.swift"},
+		{"", "/abs/Row.swift", "file:///abs/Row.swift"},
+	}
+	for _, tt := range tests {
+		if got := sourceURL(tt.root, tt.rel); got != tt.expected {
+			t.Errorf("sourceURL(%q, %q) = %q, want %q", tt.root, tt.rel, got, tt.expected)
+		}
+	}
+}
@@ -34,6 +34,17 @@ const (
 	IssueUnnecessaryBinding  IssueType = "unnecessary_binding"
 )
 
+// DetectorTypes lists the issue types Detect checks for, in the order the
+// detectors run.
+var DetectorTypes = []IssueType{
+	IssueExcessiveRerender,
+	IssueCascadingUpdate,
+	IssueFrequentTrigger,
+	IssueDeepDependencyChain,
+	IssueTimerCascade,
+	IssueWholeObjectPassing,
+}
+
 // Issue represents a detected performance problem
 type Issue struct {
 	ID          string    `json:"id"`
@@ -93,6 +104,26 @@ func NewDetectorWithThresholds(t Thresholds) *Detector {
 
 // Detect analyzes a graph and returns all detected issues
 func (d *Detector) Detect(g *graph.Graph) []Issue {
+	return d.detect(g, g.SortedNodes())
+}
+
+// DetectAt returns the issues Detect raises on the given nodes. Every issue
+// is anchored at its first affected node, so after an edit only the nodes
+// it touched need to be checked again. Issue IDs are numbered afresh.
+func (d *Detector) DetectAt(g *graph.Graph, ids []string) []Issue {
+	var nodes []*graph.Node
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if n, ok := g.Nodes[id]; ok && !seen[id] {
+			seen[id] = true
+			nodes = append(nodes, n)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return d.detect(g, nodes)
+}
+
+func (d *Detector) detect(g *graph.Graph, nodes []*graph.Node) []Issue {
 	var issues []Issue
 	issueID := 0
 
@@ -102,31 +133,72 @@ func (d *Detector) Detect(g *graph.Graph) []Issue {
 	}
 
 	// Detect excessive re-renders
-	issues = append(issues, d.detectExcessiveRerenders(g, nextID)...)
+	issues = append(issues, d.detectExcessiveRerenders(g, nodes, nextID)...)
 
 	// Detect cascading updates
-	issues = append(issues, d.detectCascadingUpdates(g, nextID)...)
+	issues = append(issues, d.detectCascadingUpdates(g, nodes, nextID)...)
 
 	// Detect frequent triggers
-	issues = append(issues, d.detectFrequentTriggers(g, nextID)...)
+	issues = append(issues, d.detectFrequentTriggers(g, nodes, nextID)...)
 
 	// Detect deep dependency chains
-	issues = append(issues, d.detectDeepChains(g, nextID)...)
+	issues = append(issues, d.detectDeepChains(g, nodes, nextID)...)
 
 	// Detect timer cascades
-	issues = append(issues, d.detectTimerCascades(g, nextID)...)
+	issues = append(issues, d.detectTimerCascades(g, nodes, nextID)...)
 
 	// Detect potential whole-object passing
-	issues = append(issues, d.detectWholeObjectPassing(g, nextID)...)
+	issues = append(issues, d.detectWholeObjectPassing(g, nodes, nextID)...)
 
 	// Sort by severity
-	sort.Slice(issues, func(i, j int) bool {
+	sort.SliceStable(issues, func(i, j int) bool {
 		return severityRank(issues[i].Severity) > severityRank(issues[j].Severity)
 	})
 
 	return issues
 }
 
+// ParseSeverity parses a severity name as used in reports and flags
+func ParseSeverity(s string) (Severity, error) {
+	sev := Severity(strings.ToLower(strings.TrimSpace(s)))
+	if severityRank(sev) == 0 {
+		return "", fmt.Errorf("unknown severity %q (want critical|high|medium|low|info)", s)
+	}
+	return sev, nil
+}
+
+// AtLeast reports whether s is as severe as min or more
+func (s Severity) AtLeast(min Severity) bool {
+	return severityRank(s) >= severityRank(min)
+}
+
+// Points deducted from the performance score per issue
+const (
+	CriticalPenalty = 25
+	HighPenalty     = 10
+	OtherPenalty    = 3
+)
+
+// Score is the performance score of a set of issues: 100 less the
+// penalty of each issue by severity, floored at 0
+func Score(detected []Issue) int {
+	score := 100
+	for _, issue := range detected {
+		switch issue.Severity {
+		case SeverityCritical:
+			score -= CriticalPenalty
+		case SeverityHigh:
+			score -= HighPenalty
+		default:
+			score -= OtherPenalty
+		}
+	}
+	if score < 0 {
+		score = 0
+	}
+	return score
+}
+
 func severityRank(s Severity) int {
 	switch s {
 	case SeverityCritical:
@@ -143,10 +215,24 @@ func severityRank(s Severity) int {
 	return 0
 }
 
-func (d *Detector) detectExcessiveRerenders(g *graph.Graph, nextID func() string) []Issue {
+// SortBySeverity orders a list of issues, or of anything describing one,
+// most severe first and busiest first within a severity. key returns an
+// item's severity and update count; ties keep their order.
+func SortBySeverity[T any](list []T, key func(T) (Severity, int)) {
+	sort.SliceStable(list, func(i, j int) bool {
+		si, ci := key(list[i])
+		sj, cj := key(list[j])
+		if si != sj {
+			return severityRank(si) > severityRank(sj)
+		}
+		return ci > cj
+	})
+}
+
+func (d *Detector) detectExcessiveRerenders(g *graph.Graph, nodes []*graph.Node, nextID func() string) []Issue {
 	var issues []Issue
 
-	for _, node := range g.Nodes {
+	for _, node := range nodes {
 		if node.Type != graph.NodeView {
 			continue
 		}
@@ -183,11 +269,11 @@ func (d *Detector) detectExcessiveRerenders(g *graph.Graph, nextID func() string
 	return issues
 }
 
-func (d *Detector) detectCascadingUpdates(g *graph.Graph, nextID func() string) []Issue {
+func (d *Detector) detectCascadingUpdates(g *graph.Graph, nodes []*graph.Node, nextID func() string) []Issue {
 	var issues []Issue
 
 	// Find state nodes that trigger multiple views
-	for _, node := range g.Nodes {
+	for _, node := range nodes {
 		if node.Type != graph.NodeState {
 			continue
 		}
@@ -232,10 +318,10 @@ func (d *Detector) detectCascadingUpdates(g *graph.Graph, nextID func() string)
 	return issues
 }
 
-func (d *Detector) detectFrequentTriggers(g *graph.Graph, nextID func() string) []Issue {
+func (d *Detector) detectFrequentTriggers(g *graph.Graph, nodes []*graph.Node, nextID func() string) []Issue {
 	var issues []Issue
 
-	for _, node := range g.Nodes {
+	for _, node := range nodes {
 		if node.Type != graph.NodeCause {
 			continue
 		}
@@ -268,11 +354,11 @@ func (d *Detector) detectFrequentTriggers(g *graph.Graph, nextID func() string)
 	return issues
 }
 
-func (d *Detector) detectDeepChains(g *graph.Graph, nextID func() string) []Issue {
+func (d *Detector) detectDeepChains(g *graph.Graph, nodes []*graph.Node, nextID func() string) []Issue {
 	var issues []Issue
 
 	// Find longest path from any cause to any view
-	for _, startNode := range g.Nodes {
+	for _, startNode := range nodes {
 		if startNode.Type != graph.NodeCause {
 			continue
 		}
@@ -335,10 +421,10 @@ func (d *Detector) findLongestChain(g *graph.Graph, nodeID string, visited map[s
 	return longest
 }
 
-func (d *Detector) detectTimerCascades(g *graph.Graph, nextID func() string) []Issue {
+func (d *Detector) detectTimerCascades(g *graph.Graph, nodes []*graph.Node, nextID func() string) []Issue {
 	var issues []Issue
 
-	for _, node := range g.Nodes {
+	for _, node := range nodes {
 		if node.Type != graph.NodeCause {
 			continue
 		}
@@ -371,11 +457,11 @@ func (d *Detector) detectTimerCascades(g *graph.Graph, nextID func() string) []I
 	return issues
 }
 
-func (d *Detector) detectWholeObjectPassing(g *graph.Graph, nextID func() string) []Issue {
+func (d *Detector) detectWholeObjectPassing(g *graph.Graph, nodes []*graph.Node, nextID func() string) []Issue {
 	var issues []Issue
 
 	// Heuristic: if a state node has a generic name and affects many views
-	for _, node := range g.Nodes {
+	for _, node := range nodes {
 		if node.Type != graph.NodeState {
 			continue
 		}
@@ -414,28 +500,12 @@ func (d *Detector) detectWholeObjectPassing(g *graph.Graph, nextID func() string
 }
 
 func (d *Detector) findReachableViews(g *graph.Graph, startID string) []string {
-	visited := make(map[string]bool)
 	var views []string
-
-	var dfs func(id string)
-	dfs = func(id string) {
-		if visited[id] {
-			return
-		}
-		visited[id] = true
-
+	for _, id := range g.Downstream(startID) {
 		if node, ok := g.Nodes[id]; ok && node.Type == graph.NodeView {
 			views = append(views, id)
 		}
-
-		for _, edge := range g.Edges {
-			if edge.From == id {
-				dfs(edge.To)
-			}
-		}
 	}
-
-	dfs(startID)
 	return views
 }
 
@@ -451,3 +521,61 @@ func (d *Detector) countAffectedViews(g *graph.Graph, nodeID string) int {
 	}
 	return count
 }
+
+// Resolver maps issue node references, node IDs or labels (cascades list
+// view labels), to the IDs of one graph. Build one per graph and reuse it.
+type Resolver struct {
+	g       *graph.Graph
+	byLabel map[string]string
+}
+
+// NewResolver indexes g's node labels; where nodes share a label, the one
+// with the lowest ID is taken
+func NewResolver(g *graph.Graph) *Resolver {
+	byLabel := make(map[string]string, len(g.Nodes))
+	for id, n := range g.Nodes {
+		if cur, ok := byLabel[n.Label]; !ok || id < cur {
+			byLabel[n.Label] = id
+		}
+	}
+	return &Resolver{g: g, byLabel: byLabel}
+}
+
+// NodeID resolves one reference
+func (r *Resolver) NodeID(ref string) (string, bool) {
+	if _, ok := r.g.Nodes[ref]; ok {
+		return ref, true
+	}
+	id, ok := r.byLabel[ref]
+	return id, ok
+}
+
+// AffectedNodeIDs resolves the nodes named by a set of issues, through their
+// affected nodes and cause chains, to graph node IDs. Unknown references
+// are dropped. The result is sorted and free of duplicates.
+func (r *Resolver) AffectedNodeIDs(detected []Issue) []string {
+	seen := map[string]bool{}
+	var ids []string
+	add := func(ref string) {
+		if id, ok := r.NodeID(ref); ok && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, issue := range detected {
+		for _, ref := range issue.AffectedNodes {
+			add(ref)
+		}
+		for _, ref := range issue.CauseChain {
+			add(ref)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// AffectedNodeIDs resolves the nodes named by a set of issues in g, as
+// Resolver.AffectedNodeIDs does
+func AffectedNodeIDs(g *graph.Graph, detected []Issue) []string {
+	return NewResolver(g).AffectedNodeIDs(detected)
+}
@@ -132,3 +132,56 @@ func TestSeverityRank(t *testing.T) {
 		t.Error("Low should rank higher than Info")
 	}
 }
+
+func TestParseSeverity(t *testing.T) {
+	s, err := ParseSeverity(" High")
+	if err != nil || s != SeverityHigh {
+		t.Errorf("ParseSeverity(High) = %q, %v", s, err)
+	}
+	if _, err := ParseSeverity("urgent"); err == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+	if !SeverityCritical.AtLeast(SeverityHigh) || !SeverityHigh.AtLeast(SeverityHigh) || SeverityMedium.AtLeast(SeverityHigh) {
+		t.Error("AtLeast does not follow severity order")
+	}
+}
+
+func TestAffectedNodeIDs(t *testing.T) {
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "s1", Label: "@State items", Type: graph.NodeState})
+	g.UpsertNode(&graph.Node{ID: "v1", Label: "RowView", Type: graph.NodeView})
+	g.UpsertNode(&graph.Node{ID: "v2", Label: "HeaderView", Type: graph.NodeView})
+
+	detected := []Issue{
+		{AffectedNodes: []string{"s1", "RowView", "HeaderView"}}, // cascade: ID plus view labels
+		{AffectedNodes: []string{"v1"}, CauseChain: []string{"s1", "v1"}},
+		{AffectedNodes: []string{"missing"}},
+	}
+	got := AffectedNodeIDs(g, detected)
+	want := []string{"s1", "v1", "v2"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestResolver_NodeID(t *testing.T) {
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "v2", Label: "RowView", Type: graph.NodeView})
+	g.UpsertNode(&graph.Node{ID: "v1", Label: "RowView", Type: graph.NodeView})
+	g.UpsertNode(&graph.Node{ID: "s1", Label: "v2", Type: graph.NodeState})
+	r := NewResolver(g)
+
+	for ref, want := range map[string]string{"v2": "v2", "RowView": "v1", "s1": "s1"} {
+		if got, ok := r.NodeID(ref); !ok || got != want {
+			t.Errorf("NodeID(%q) = %q, %v; want %q", ref, got, ok, want)
+		}
+	}
+	if id, ok := r.NodeID("missing"); ok {
+		t.Errorf("expected no node for an unknown reference, got %q", id)
+	}
+}
@@ -0,0 +1,174 @@
+// Package jsonschema derives JSON Schemas (draft 2020-12) from Go types as
+// encoding/json marshals them, and validates documents against the subset of
+// the specification those schemas use.
+package jsonschema
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Draft is the JSON Schema dialect of generated schemas
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema document or subschema, as decoded from JSON
+type Schema = map[string]any
+
+// Reflector builds schemas from Go types
+type Reflector struct {
+	// Enums lists the allowed values of named string types
+	Enums map[reflect.Type][]string
+
+	defs  map[string]Schema
+	names map[reflect.Type]string
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Reflect returns the schema of t with the structs it uses under $defs.
+// Fields follow their json tags: omitempty fields are optional, the rest
+// required, and slices, maps and pointers that are not omitempty may be
+// null. Structs allow no other properties.
+func (r *Reflector) Reflect(t reflect.Type) Schema {
+	r.defs = map[string]Schema{}
+	r.names = map[reflect.Type]string{}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	root := r.object(t)
+	root["$schema"] = Draft
+	if len(r.defs) > 0 {
+		defs := Schema{}
+		for name, s := range r.defs {
+			defs[name] = s
+		}
+		root["$defs"] = defs
+	}
+	return root
+}
+
+func (r *Reflector) schema(t reflect.Type) Schema {
+	if t == timeType {
+		return Schema{"type": "string", "format": "date-time"}
+	}
+	if values, ok := r.Enums[t]; ok {
+		enum := make([]any, len(values))
+		for i, v := range values {
+			enum[i] = v
+		}
+		return Schema{"type": "string", "enum": enum}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return r.schema(t.Elem())
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return Schema{"type": "array", "items": r.schema(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": r.schema(t.Elem())}
+	case reflect.Struct:
+		return Schema{"$ref": "#/$defs/" + r.define(t)}
+	}
+	return Schema{} // interfaces and the like: anything goes
+}
+
+// define adds a struct to $defs once, named after its type, or qualified
+// by its package when another package has a type of the same name
+func (r *Reflector) define(t reflect.Type) string {
+	if name, ok := r.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	for other := range r.names {
+		if r.names[other] == name {
+			name = path.Base(t.PkgPath()) + "." + t.Name()
+			break
+		}
+	}
+	r.names[t] = name
+	r.defs[name] = nil // recursive types refer to it while it is built
+	r.defs[name] = r.object(t)
+	return name
+}
+
+func (r *Reflector) object(t reflect.Type) Schema {
+	props := Schema{}
+	var required []any
+	r.fields(t, props, &required)
+	s := Schema{"type": "object", "properties": props, "additionalProperties": false}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// fields adds the JSON properties of a struct, inlining embedded structs
+// as encoding/json does
+func (r *Reflector) fields(t reflect.Type, props Schema, required *[]any) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				r.fields(ft, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s := r.schema(f.Type)
+		omitempty := strings.Contains(","+opts+",", ",omitempty,")
+		if !omitempty {
+			*required = append(*required, name)
+			switch f.Type.Kind() {
+			case reflect.Slice, reflect.Map, reflect.Pointer:
+				s = nullable(s)
+			}
+		}
+		props[name] = s
+	}
+}
+
+func nullable(s Schema) Schema {
+	if t, ok := s["type"].(string); ok {
+		out := Schema{}
+		for k, v := range s {
+			out[k] = v
+		}
+		out["type"] = []any{t, "null"}
+		return out
+	}
+	return Schema{"anyOf": []any{s, Schema{"type": "null"}}}
+}
+
+// Marshal encodes a schema as indented JSON, keys sorted
+func Marshal(s Schema) ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
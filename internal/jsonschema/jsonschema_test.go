@@ -0,0 +1,125 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type level string
+
+type base struct {
+	ID string `json:"id"`
+}
+
+type item struct {
+	base
+	Level level          `json:"level"`
+	Tags  []string       `json:"tags"`
+	Note  string         `json:"note,omitempty"`
+	Score float64        `json:"score"`
+	Meta  map[string]int `json:"meta,omitempty"`
+	Next  *item          `json:"next,omitempty"`
+	When  time.Time      `json:"when"`
+	Skip  string         `json:"-"`
+	local int
+}
+
+type doc struct {
+	Items []item `json:"items"`
+	Count int    `json:"count"`
+}
+
+func reflectDoc(t *testing.T) Schema {
+	t.Helper()
+	r := Reflector{Enums: map[reflect.Type][]string{reflect.TypeOf(level("")): {"low", "high"}}}
+	// round-trip so the schema looks as it does when read back from disk
+	data, err := Marshal(r.Reflect(reflect.TypeOf(&doc{})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestReflect(t *testing.T) {
+	s := reflectDoc(t)
+	if s["$schema"] != Draft {
+		t.Errorf("missing $schema: %v", s["$schema"])
+	}
+	def := s["$defs"].(Schema)["item"].(Schema)
+	props := def["properties"].(Schema)
+
+	var names []string
+	for name := range props {
+		names = append(names, name)
+	}
+	for _, want := range []string{"id", "level", "tags", "note", "score", "meta", "next", "when"} {
+		if props[want] == nil {
+			t.Errorf("missing property %s in %v", want, names)
+		}
+	}
+	if len(props) != 8 {
+		t.Errorf("unexpected properties %v", names)
+	}
+	if got := def["required"]; !reflect.DeepEqual(got, []any{"id", "level", "tags", "score", "when"}) {
+		t.Errorf("required = %v", got)
+	}
+	if got := props["tags"].(Schema)["type"]; !reflect.DeepEqual(got, []any{"array", "null"}) {
+		t.Errorf("non-omitempty slice should be nullable, got %v", got)
+	}
+	if got := props["level"].(Schema)["enum"]; !reflect.DeepEqual(got, []any{"low", "high"}) {
+		t.Errorf("enum = %v", got)
+	}
+	if got := props["next"].(Schema)["$ref"]; got != "#/$defs/item" {
+		t.Errorf("recursive pointer should refer to its def, got %v", got)
+	}
+	if got := props["when"].(Schema)["format"]; got != "date-time" {
+		t.Errorf("time format = %v", got)
+	}
+	if def["additionalProperties"] != false {
+		t.Error("structs should not allow unknown properties")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s := reflectDoc(t)
+	valid := `{"count": 1, "items": [{"id": "a", "level": "low", "tags": null, "score": 1.5,
+		"when": "2026-01-02T03:04:05Z", "next": {"id": "b", "level": "high", "tags": ["x"], "score": 2, "when": "2026-01-02T03:04:05Z"}}]}`
+	errs, err := Validate(s, []byte(valid))
+	if err != nil || len(errs) != 0 {
+		t.Fatalf("valid document: %v %v", err, errs)
+	}
+
+	invalid := `{"count": 1.5, "items": [{"id": 3, "level": "mid", "tags": [], "score": 1,
+		"when": "yesterday", "extra": true, "next": {"id": "b", "level": "low", "tags": [], "score": 1}}], "other": 1}`
+	errs, err = Validate(s, []byte(invalid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.Error())
+	}
+	want := []string{
+		"$.count: want integer, got number",
+		"$.items[0].extra: unknown property",
+		"$.items[0].id: want string, got integer",
+		"$.items[0].level: mid is not one of [low high]",
+		`$.items[0].next: missing required property "when"`,
+		`$.items[0].when: "yesterday" is not an RFC 3339 date-time`,
+		"$.other: unknown property",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if _, err := Validate(s, []byte("{")); err == nil {
+		t.Error("expected a parse error")
+	}
+}
@@ -0,0 +1,225 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Error is a place where a document does not match its schema
+type Error struct {
+	Path    string `json:"path"` // e.g. $.issues[0].severity
+	Message string `json:"message"`
+}
+
+func (e Error) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// maxErrors caps the errors Validate reports; a document of the wrong shape
+// fails the same way in every element
+const maxErrors = 50
+
+// Validate checks a JSON document against the schema. It supports the
+// keywords Reflect emits: type, properties, required,
+// additionalProperties, items, enum, anyOf, format date-time and $ref into
+// $defs.
+func Validate(schema Schema, data []byte) ([]Error, error) {
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse document: %w", err)
+	}
+	v := &validator{root: schema}
+	v.check(schema, doc, "$")
+	return v.errs, nil
+}
+
+type validator struct {
+	root Schema
+	errs []Error
+}
+
+func (v *validator) fail(path, format string, args ...any) {
+	if len(v.errs) < maxErrors {
+		v.errs = append(v.errs, Error{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+func (v *validator) check(s Schema, doc any, path string) {
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := v.resolve(ref)
+		if err != nil {
+			v.fail(path, "%v", err)
+			return
+		}
+		v.check(target, doc, path)
+		return
+	}
+	if anyOf, ok := s["anyOf"].([]any); ok {
+		for _, alt := range anyOf {
+			sub := &validator{root: v.root}
+			if sub.check(alt.(Schema), doc, path); len(sub.errs) == 0 {
+				return
+			}
+		}
+		v.fail(path, "matches none of the allowed forms")
+		return
+	}
+	if t, ok := s["type"]; ok && !typeMatches(t, doc) {
+		v.fail(path, "want %s, got %s", typeNames(t), typeOf(doc))
+		return
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			found = found || e == doc
+		}
+		if !found {
+			v.fail(path, "%v is not one of %v", doc, enum)
+		}
+	}
+	if s["format"] == "date-time" {
+		if str, ok := doc.(string); ok {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				v.fail(path, "%q is not an RFC 3339 date-time", str)
+			}
+		}
+	}
+
+	switch d := doc.(type) {
+	case map[string]any:
+		v.object(s, d, path)
+	case []any:
+		if items, ok := s["items"].(Schema); ok {
+			for i, e := range d {
+				v.check(items, e, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+}
+
+func (v *validator) object(s Schema, d map[string]any, path string) {
+	props, _ := s["properties"].(Schema)
+	if req, ok := s["required"].([]any); ok {
+		for _, name := range req {
+			if _, ok := d[name.(string)]; !ok {
+				v.fail(path, "missing required property %q", name)
+			}
+		}
+	}
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sub := path + "." + k
+		if ps, ok := props[k].(Schema); ok {
+			v.check(ps, d[k], sub)
+			continue
+		}
+		switch extra := s["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				v.fail(sub, "unknown property")
+			}
+		case Schema:
+			v.check(extra, d[k], sub)
+		}
+	}
+}
+
+func (v *validator) resolve(ref string) (Schema, error) {
+	name, ok := strings.CutPrefix(ref, "#/$defs/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+	defs, _ := v.root["$defs"].(Schema)
+	target, ok := defs[name].(Schema)
+	if !ok {
+		return nil, fmt.Errorf("undefined $ref %q", ref)
+	}
+	return target, nil
+}
+
+func typeMatches(t any, doc any) bool {
+	switch t := t.(type) {
+	case string:
+		return isType(t, doc)
+	case []any:
+		for _, name := range t {
+			if s, ok := name.(string); ok && isType(s, doc) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isType(name string, doc any) bool {
+	switch name {
+	case "null":
+		return doc == nil
+	case "boolean":
+		_, ok := doc.(bool)
+		return ok
+	case "string":
+		_, ok := doc.(string)
+		return ok
+	case "number":
+		_, ok := doc.(json.Number)
+		return ok
+	case "integer":
+		n, ok := doc.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "array":
+		_, ok := doc.([]any)
+		return ok
+	case "object":
+		_, ok := doc.(map[string]any)
+		return ok
+	}
+	return false
+}
+
+func typeNames(t any) string {
+	if list, ok := t.([]any); ok {
+		names := make([]string, len(list))
+		for i, n := range list {
+			names[i] = fmt.Sprint(n)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func typeOf(doc any) string {
+	switch d := doc.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if isType("integer", d) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", doc)
+}
@@ -0,0 +1,370 @@
+// Package layout computes a layered, left-to-right drawing of a graph.
+package layout
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+// Options sets the geometry of the drawing
+type Options struct {
+	NodeHeight float64
+	CharWidth  float64 // approximate width of one label character
+	MinWidth   float64
+	MaxLabel   int // labels are measured (and should be drawn) up to this many runes
+	LayerGap   float64
+	RowGap     float64
+	Margin     float64
+	Sweeps     int // barycenter passes used to reduce crossings
+}
+
+// DefaultOptions returns geometry that suits 12px sans-serif labels
+func DefaultOptions() Options {
+	return Options{
+		NodeHeight: 36,
+		CharWidth:  7,
+		MinWidth:   80,
+		MaxLabel:   40,
+		LayerGap:   70,
+		RowGap:     18,
+		Margin:     20,
+		Sweeps:     8,
+	}
+}
+
+// Point is a position in drawing coordinates
+type Point struct {
+	X, Y float64
+}
+
+// Box is a placed node; X and Y are its top-left corner
+type Box struct {
+	ID    string
+	Layer int
+	Order int
+	X, Y  float64
+	W, H  float64
+}
+
+// Route is the polyline an edge follows, from its source to its target.
+// Long edges bend through the slots reserved for them in the layers they
+// cross; edges that had to point backwards run right to left.
+type Route struct {
+	From, To string
+	Label    string
+	Points   []Point
+	SelfLoop bool
+}
+
+// Layout is the computed drawing
+type Layout struct {
+	Boxes  map[string]*Box
+	Layers [][]string // node IDs per layer, top to bottom (slots for long edges omitted)
+	Routes []Route
+	Width  float64
+	Height float64
+}
+
+// typeRank orders the node types into bands of columns
+var typeRank = map[graph.NodeType]int{
+	graph.NodeCause: 0,
+	graph.NodeState: 1,
+	graph.NodeView:  2,
+	graph.NodeOther: 3,
+}
+
+// Compute lays g out Sugiyama-style. Causes, state and views occupy
+// successive bands of columns; inside a band, nodes sit one column right of
+// their deepest predecessor of the same type. Cycles and edges pointing
+// against the band order are reversed for layering only. Long edges get a
+// slot in every column they cross, and rows are ordered by repeated
+// barycenter sweeps to reduce crossings. The result is deterministic.
+func Compute(g *graph.Graph, opts Options) *Layout {
+	if opts.Sweeps <= 0 {
+		opts.Sweeps = DefaultOptions().Sweeps
+	}
+	nodes := g.SortedNodes()
+	edges := g.SortedEdges()
+
+	rank := func(id string) int {
+		if n, ok := g.Nodes[id]; ok {
+			if r, ok := typeRank[n.Type]; ok {
+				return r
+			}
+		}
+		return typeRank[graph.NodeOther]
+	}
+
+	// Orient every edge from lower to higher band; within a band, reverse
+	// the back edges found by a depth-first search.
+	type link struct {
+		from, to string
+		edge     int
+	}
+	same := map[string][]string{}
+	for _, e := range edges {
+		if e.From != e.To && rank(e.From) == rank(e.To) {
+			same[e.From] = append(same[e.From], e.To)
+		}
+	}
+	back := map[[2]string]bool{}
+	state := map[string]int{} // 1 on stack, 2 done
+	var dfs func(id string)
+	dfs = func(id string) {
+		state[id] = 1
+		for _, next := range same[id] {
+			switch state[next] {
+			case 0:
+				dfs(next)
+			case 1:
+				back[[2]string{id, next}] = true
+			}
+		}
+		state[id] = 2
+	}
+	for _, n := range nodes {
+		if state[n.ID] == 0 {
+			dfs(n.ID)
+		}
+	}
+
+	var links []link
+	for i, e := range edges {
+		if e.From == e.To {
+			continue
+		}
+		if _, ok := g.Nodes[e.From]; !ok {
+			continue
+		}
+		if _, ok := g.Nodes[e.To]; !ok {
+			continue
+		}
+		rf, rt := rank(e.From), rank(e.To)
+		if rf > rt || rf == rt && back[[2]string{e.From, e.To}] {
+			links = append(links, link{from: e.To, to: e.From, edge: i})
+		} else {
+			links = append(links, link{from: e.From, to: e.To, edge: i})
+		}
+	}
+
+	// Longest-path layering, band by band
+	preds := map[string][]string{}
+	for _, l := range links {
+		preds[l.to] = append(preds[l.to], l.from)
+	}
+	layer := map[string]int{}
+	var depth func(id string) int
+	depth = func(id string) int {
+		if d, ok := layer[id]; ok {
+			return d
+		}
+		d := 0
+		for _, p := range preds[id] {
+			if rank(p) == rank(id) {
+				if pd := depth(p) + 1; pd > d {
+					d = pd
+				}
+			}
+		}
+		layer[id] = d
+		return d
+	}
+	bands := map[int][]string{}
+	for _, n := range nodes {
+		bands[rank(n.ID)] = append(bands[rank(n.ID)], n.ID)
+	}
+	var ranks []int
+	for r := range bands {
+		ranks = append(ranks, r)
+	}
+	sort.Ints(ranks)
+	base := 0
+	final := map[string]int{}
+	for _, r := range ranks {
+		width := 0
+		for _, id := range bands[r] {
+			d := depth(id)
+			final[id] = base + d
+			if d+1 > width {
+				width = d + 1
+			}
+		}
+		base += width
+	}
+	layer = final
+
+	// Slots for long edges; chains[edge] lists the slot IDs in column order
+	columns := make([][]string, base)
+	for _, n := range nodes {
+		columns[layer[n.ID]] = append(columns[layer[n.ID]], n.ID)
+	}
+	isSlot := map[string]bool{}
+	chains := map[int][]string{}
+	adjPrev := map[string][]string{} // neighbours one column to the left
+	adjNext := map[string][]string{}
+	connect := func(a, b string) {
+		adjNext[a] = append(adjNext[a], b)
+		adjPrev[b] = append(adjPrev[b], a)
+	}
+	for _, l := range links {
+		prev := l.from
+		for c := layer[l.from] + 1; c < layer[l.to]; c++ {
+			id := slotID(l.edge, c)
+			isSlot[id] = true
+			columns[c] = append(columns[c], id)
+			chains[l.edge] = append(chains[l.edge], id)
+			connect(prev, id)
+			prev = id
+		}
+		connect(prev, l.to)
+	}
+
+	// Crossing reduction: alternate left-to-right and right-to-left sweeps,
+	// placing each node at the mean row of its neighbours in the column just
+	// visited; nodes without such neighbours keep their row.
+	pos := map[string]float64{}
+	for _, col := range columns {
+		for i, id := range col {
+			pos[id] = float64(i)
+		}
+	}
+	reorder := func(col []string, adj map[string][]string) {
+		bary := map[string]float64{}
+		for _, id := range col {
+			bary[id] = pos[id]
+			if ns := adj[id]; len(ns) > 0 {
+				sum := 0.0
+				for _, n := range ns {
+					sum += pos[n]
+				}
+				bary[id] = sum / float64(len(ns))
+			}
+		}
+		sort.SliceStable(col, func(i, j int) bool { return bary[col[i]] < bary[col[j]] })
+		for i, id := range col {
+			pos[id] = float64(i)
+		}
+	}
+	for s := 0; s < opts.Sweeps; s++ {
+		if s%2 == 0 {
+			for c := 1; c < len(columns); c++ {
+				reorder(columns[c], adjPrev)
+			}
+		} else {
+			for c := len(columns) - 2; c >= 0; c-- {
+				reorder(columns[c], adjNext)
+			}
+		}
+	}
+
+	// Coordinates: columns as wide as their widest label, each column
+	// centred vertically against the tallest one.
+	out := &Layout{Boxes: map[string]*Box{}}
+	rowH := opts.NodeHeight + opts.RowGap
+	tallest := 0
+	for _, col := range columns {
+		if len(col) > tallest {
+			tallest = len(col)
+		}
+	}
+	slotPos := map[string]Point{}
+	x := opts.Margin
+	for c, col := range columns {
+		w := 0.0
+		for _, id := range col {
+			if !isSlot[id] {
+				if nw := opts.width(g.Nodes[id]); nw > w {
+					w = nw
+				}
+			}
+		}
+		top := opts.Margin + float64(tallest-len(col))*rowH/2
+		var ids []string
+		for i, id := range col {
+			y := top + float64(i)*rowH
+			if isSlot[id] {
+				slotPos[id] = Point{X: x + w/2, Y: y + opts.NodeHeight/2}
+				continue
+			}
+			out.Boxes[id] = &Box{ID: id, Layer: c, Order: i, X: x, Y: y, W: w, H: opts.NodeHeight}
+			ids = append(ids, id)
+		}
+		out.Layers = append(out.Layers, ids)
+		x += w + opts.LayerGap
+	}
+	out.Width = x - opts.LayerGap + opts.Margin
+	if len(columns) == 0 {
+		out.Width = 2 * opts.Margin
+	}
+	out.Height = 2*opts.Margin + float64(tallest)*rowH - opts.RowGap
+	if tallest == 0 {
+		out.Height = 2 * opts.Margin
+	}
+
+	// Routes in edge order
+	for i, e := range edges {
+		a, okA := out.Boxes[e.From]
+		b, okB := out.Boxes[e.To]
+		if !okA || !okB {
+			continue
+		}
+		r := Route{From: e.From, To: e.To, Label: e.Label}
+		if e.From == e.To {
+			r.SelfLoop = true
+			r.Points = []Point{{a.X + a.W, a.Y + a.H/2}}
+			out.Routes = append(out.Routes, r)
+			continue
+		}
+		forward := a.Layer < b.Layer
+		if forward {
+			r.Points = append(r.Points, Point{a.X + a.W, a.Y + a.H/2})
+		} else {
+			r.Points = append(r.Points, Point{a.X, a.Y + a.H/2})
+		}
+		chain := chains[i]
+		if !forward {
+			// the chain was built from the target side
+			for j := len(chain) - 1; j >= 0; j-- {
+				r.Points = append(r.Points, slotPos[chain[j]])
+			}
+		} else {
+			for _, id := range chain {
+				r.Points = append(r.Points, slotPos[id])
+			}
+		}
+		if forward {
+			r.Points = append(r.Points, Point{b.X, b.Y + b.H/2})
+		} else {
+			r.Points = append(r.Points, Point{b.X + b.W, b.Y + b.H/2})
+		}
+		out.Routes = append(out.Routes, r)
+	}
+	return out
+}
+
+// Truncate shortens a label to the measured length
+func (o Options) Truncate(s string) string {
+	r := []rune(s)
+	if o.MaxLabel <= 0 || len(r) <= o.MaxLabel {
+		return s
+	}
+	return string(r[:o.MaxLabel-1]) + "…"
+}
+
+func (o Options) width(n *graph.Node) float64 {
+	label := n.Label
+	if label == "" {
+		label = n.ID
+	}
+	w := float64(len([]rune(o.Truncate(label))))*o.CharWidth + 28
+	if w < o.MinWidth {
+		w = o.MinWidth
+	}
+	return w
+}
+
+func slotID(edge, column int) string {
+	return fmt.Sprintf("\x00%d/%d", edge, column)
+}
@@ -0,0 +1,113 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+func node(g *graph.Graph, id string, t graph.NodeType) {
+	g.UpsertNode(&graph.Node{ID: id, Label: id, Type: t})
+}
+
+func TestCompute_Bands(t *testing.T) {
+	g := graph.New()
+	node(g, "tap", graph.NodeCause)
+	node(g, "items", graph.NodeState)
+	node(g, "filtered", graph.NodeState)
+	node(g, "list", graph.NodeView)
+	node(g, "row", graph.NodeView)
+	g.AddEdge(graph.Edge{From: "tap", To: "items"})
+	g.AddEdge(graph.Edge{From: "items", To: "filtered"})
+	g.AddEdge(graph.Edge{From: "filtered", To: "list"})
+	g.AddEdge(graph.Edge{From: "list", To: "row"})
+	g.AddEdge(graph.Edge{From: "row", To: "items"}) // against the band order
+
+	l := Compute(g, DefaultOptions())
+
+	want := map[string]int{"tap": 0, "items": 1, "filtered": 2, "list": 3, "row": 4}
+	for id, layer := range want {
+		if got := l.Boxes[id].Layer; got != layer {
+			t.Errorf("%s in layer %d, want %d", id, got, layer)
+		}
+	}
+	for i := 1; i < len(l.Layers); i++ {
+		prev, cur := l.Boxes[l.Layers[i-1][0]], l.Boxes[l.Layers[i][0]]
+		if cur.X <= prev.X+prev.W {
+			t.Errorf("layer %d overlaps layer %d", i, i-1)
+		}
+	}
+	if len(l.Routes) != 5 {
+		t.Fatalf("expected 5 routes, got %d", len(l.Routes))
+	}
+	for _, r := range l.Routes {
+		if r.From == "row" && r.To == "items" {
+			// row (layer 4) back to items (layer 1) bends through 2 slots
+			if len(r.Points) != 4 {
+				t.Errorf("expected 4 points on the back edge, got %v", r.Points)
+			}
+			if r.Points[0].X <= r.Points[len(r.Points)-1].X {
+				t.Errorf("back edge should run right to left: %v", r.Points)
+			}
+		}
+	}
+}
+
+func TestCompute_Cycle(t *testing.T) {
+	g := graph.New()
+	node(g, "a", graph.NodeState)
+	node(g, "b", graph.NodeState)
+	node(g, "c", graph.NodeState)
+	g.AddEdge(graph.Edge{From: "a", To: "b"})
+	g.AddEdge(graph.Edge{From: "b", To: "c"})
+	g.AddEdge(graph.Edge{From: "c", To: "a"})
+	g.AddEdge(graph.Edge{From: "a", To: "a"})
+
+	l := Compute(g, DefaultOptions())
+	if len(l.Layers) != 3 {
+		t.Fatalf("expected the cycle to span 3 layers, got %v", l.Layers)
+	}
+	self := 0
+	for _, r := range l.Routes {
+		if r.SelfLoop {
+			self++
+		}
+	}
+	if self != 1 {
+		t.Errorf("expected one self loop, got %d", self)
+	}
+}
+
+func TestCompute_ReducesCrossings(t *testing.T) {
+	g := graph.New()
+	node(g, "a", graph.NodeCause)
+	node(g, "b", graph.NodeCause)
+	node(g, "x", graph.NodeView)
+	node(g, "y", graph.NodeView)
+	g.AddEdge(graph.Edge{From: "a", To: "y"})
+	g.AddEdge(graph.Edge{From: "b", To: "x"})
+
+	l := Compute(g, DefaultOptions())
+	// a sits above b, so y should sit above x
+	if l.Boxes["a"].Y >= l.Boxes["b"].Y || l.Boxes["y"].Y >= l.Boxes["x"].Y {
+		t.Errorf("edges cross: a=%v b=%v x=%v y=%v", l.Boxes["a"].Y, l.Boxes["b"].Y, l.Boxes["x"].Y, l.Boxes["y"].Y)
+	}
+}
+
+func TestCompute_Empty(t *testing.T) {
+	l := Compute(graph.New(), DefaultOptions())
+	if len(l.Boxes) != 0 || l.Width <= 0 || l.Height <= 0 {
+		t.Errorf("unexpected empty layout: %+v", l)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	o := DefaultOptions()
+	o.MaxLabel = 5
+	if got := o.Truncate("ForEach<RowView>"); got != "ForE…" {
+		t.Errorf("got %q", got)
+	}
+	if got := o.Truncate("Row"); got != "Row" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,232 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+// testLoader analyzes a small graph whose ItemRow count is the input name
+func testLoader(loads *int) Loader {
+	return func(input, sourceRoot string) (*aioutput.Report, error) {
+		*loads++
+		var rows int
+		if _, err := fmt.Sscan(input, &rows); err != nil {
+			return nil, fmt.Errorf("cannot load %s", input)
+		}
+		g := graph.New()
+		g.UpsertNode(&graph.Node{ID: "c1", Label: "Timer", Type: graph.NodeCause, Count: 40})
+		g.UpsertNode(&graph.Node{ID: "s1", Label: "@State items", Type: graph.NodeState})
+		g.UpsertNode(&graph.Node{ID: "v1", Label: "ItemRow", Type: graph.NodeView, Count: rows})
+		g.AddEdge(graph.Edge{From: "c1", To: "s1"})
+		g.AddEdge(graph.Edge{From: "s1", To: "v1"})
+		gen, err := aioutput.NewGenerator("")
+		if err != nil {
+			return nil, err
+		}
+		return gen.Generate(g, aioutput.GenerateOptions{TracePath: input}), nil
+	}
+}
+
+type rpcReply struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// serve runs the server over the given lines and returns its replies
+func serve(t *testing.T, s *Server, lines ...string) []rpcReply {
+	t.Helper()
+	var out strings.Builder
+	if err := s.Serve(strings.NewReader(strings.Join(lines, "\n")+"\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	var replies []rpcReply
+	sc := bufio.NewScanner(strings.NewReader(out.String()))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var r rpcReply
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("invalid reply %q: %v", sc.Text(), err)
+		}
+		replies = append(replies, r)
+	}
+	return replies
+}
+
+func call(id int, tool string, args any) string {
+	data, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0", "id": id, "method": "tools/call",
+		"params": map[string]any{"name": tool, "arguments": args},
+	})
+	return string(data)
+}
+
+// toolText returns the text of a tool result and whether it is an error
+func toolText(t *testing.T, r rpcReply) (string, bool) {
+	t.Helper()
+	if r.Error != nil {
+		t.Fatalf("unexpected protocol error: %+v", r.Error)
+	}
+	var res callResult
+	if err := json.Unmarshal(r.Result, &res); err != nil || len(res.Content) != 1 {
+		t.Fatalf("unexpected tool result %s", r.Result)
+	}
+	return res.Content[0].Text, res.IsError
+}
+
+func TestServe_Protocol(t *testing.T) {
+	var loads int
+	s := NewServer("swiftuice", "test", NewSession(testLoader(&loads)).Tools())
+	replies := serve(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"t","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/list"}`,
+		`not json`,
+		`{"jsonrpc":"2.0","id":4,"method":"ping"}`,
+	)
+	if len(replies) != 5 {
+		t.Fatalf("expected 5 replies (none for the notification), got %d", len(replies))
+	}
+
+	var init struct {
+		ProtocolVersion string            `json:"protocolVersion"`
+		ServerInfo      map[string]string `json:"serverInfo"`
+	}
+	json.Unmarshal(replies[0].Result, &init)
+	if init.ProtocolVersion != "2025-03-26" || init.ServerInfo["name"] != "swiftuice" {
+		t.Errorf("unexpected initialize result %s", replies[0].Result)
+	}
+
+	var list struct {
+		Tools []Tool `json:"tools"`
+	}
+	json.Unmarshal(replies[1].Result, &list)
+	var names []string
+	for _, tool := range list.Tools {
+		names = append(names, tool.Name)
+		if tool.InputSchema["type"] != "object" {
+			t.Errorf("%s: input schema is not an object", tool.Name)
+		}
+	}
+	if got := strings.Join(names, ","); got != "analyze_trace,list_issues,get_issue_detail,query_graph_paths,find_source_for_node,compare_reports" {
+		t.Errorf("unexpected tools %s", got)
+	}
+
+	if replies[2].Error == nil || replies[2].Error.Code != codeMethodNotFound {
+		t.Errorf("expected method not found, got %+v", replies[2])
+	}
+	if replies[3].Error == nil || replies[3].Error.Code != codeParseError {
+		t.Errorf("expected parse error, got %+v", replies[3])
+	}
+	if string(replies[4].ID) != "4" || replies[4].Error != nil {
+		t.Errorf("unexpected ping reply %+v", replies[4])
+	}
+}
+
+func TestServe_Tools(t *testing.T) {
+	var loads int
+	s := NewServer("swiftuice", "test", NewSession(testLoader(&loads)).Tools())
+	replies := serve(t, s,
+		call(1, "list_issues", nil),
+		call(2, "analyze_trace", map[string]any{"input": "80"}),
+		call(3, "list_issues", map[string]any{"min_severity": "high", "node": "itemrow"}),
+		call(4, "query_graph_paths", map[string]any{"from": "Timer", "to": "ItemRow"}),
+		call(5, "find_source_for_node", map[string]any{"node": "ItemRow"}),
+		call(6, "compare_reports", map[string]any{"base": "80", "head": "5"}),
+		call(7, "list_issues", map[string]any{"bogus": true}),
+		`{"jsonrpc":"2.0","id":8,"method":"tools/call","params":{"name":"nope"}}`,
+	)
+	if len(replies) != 8 {
+		t.Fatalf("expected 8 replies, got %d", len(replies))
+	}
+
+	if text, isErr := toolText(t, replies[0]); !isErr || !strings.Contains(text, "analyze_trace first") {
+		t.Errorf("expected a nothing-analyzed error, got %q", text)
+	}
+
+	text, _ := toolText(t, replies[1])
+	var analyzed struct {
+		Issues []IssueSummary `json:"issues"`
+	}
+	if err := json.Unmarshal([]byte(text), &analyzed); err != nil || len(analyzed.Issues) == 0 {
+		t.Fatalf("unexpected analyze_trace result %s", text)
+	}
+
+	text, _ = toolText(t, replies[2])
+	var listed struct {
+		Total  int            `json:"total"`
+		Issues []IssueSummary `json:"issues"`
+	}
+	json.Unmarshal([]byte(text), &listed)
+	if listed.Total == 0 {
+		t.Fatalf("expected ItemRow issues, got %s", text)
+	}
+	for _, is := range listed.Issues {
+		if !is.Severity.AtLeast("high") {
+			t.Errorf("issue below min_severity listed: %+v", is)
+		}
+	}
+
+	text, _ = toolText(t, replies[3])
+	if !strings.Contains(text, `"shortest_path"`) || !strings.Contains(text, `"ItemRow"`) {
+		t.Errorf("unexpected path result %s", text)
+	}
+
+	if text, isErr := toolText(t, replies[4]); !isErr || !strings.Contains(text, "no source root") {
+		t.Errorf("expected a missing source root error, got %q", text)
+	}
+
+	text, _ = toolText(t, replies[5])
+	var diff struct {
+		ScoreDelta int `json:"score_delta"`
+	}
+	json.Unmarshal([]byte(text), &diff)
+	if diff.ScoreDelta <= 0 {
+		t.Errorf("expected the score to improve, got %s", text)
+	}
+	if loads != 2 {
+		t.Errorf("expected each input analyzed once, got %d loads", loads)
+	}
+
+	if _, isErr := toolText(t, replies[6]); !isErr {
+		t.Error("expected unknown arguments to fail the call")
+	}
+	if replies[7].Error == nil || replies[7].Error.Code != codeInvalidParams {
+		t.Errorf("expected invalid params for an unknown tool, got %+v", replies[7])
+	}
+}
+
+func TestIssueDetail(t *testing.T) {
+	var loads int
+	session := NewSession(testLoader(&loads))
+	if _, err := session.analyzeTrace(json.RawMessage(`{"input":"80"}`)); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := session.get("", "", false)
+	id := a.report.Issues[0].ID
+
+	v, err := session.issueDetail(json.RawMessage(fmt.Sprintf(`{"issue_id":%q}`, id)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := v.(IssueDetail)
+	if d.ID != id || len(d.SuggestedFixes) == 0 {
+		t.Errorf("expected the issue with its fixes, got %+v", d)
+	}
+	for _, label := range d.CauseChainLabels {
+		if label == "v1" || label == "c1" {
+			t.Errorf("cause chain not resolved to labels: %v", d.CauseChainLabels)
+		}
+	}
+
+	if _, err := session.issueDetail(json.RawMessage(`{"issue_id":"issue-999"}`)); err == nil {
+		t.Error("expected an error for an unknown issue")
+	}
+}
@@ -0,0 +1,468 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/compare"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/correlation"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/query"
+)
+
+// defaultListLimit caps list_issues when the client gives no limit
+const defaultListLimit = 20
+
+// Loader analyzes an input (export directory, .trace, saved graph or
+// analysis report) with an optional Swift source root
+type Loader func(input, sourceRoot string) (*aioutput.Report, error)
+
+// analysis is a cached report with its graph
+type analysis struct {
+	input      string
+	sourceRoot string
+	report     *aioutput.Report
+	graph      *graph.Graph
+}
+
+// Session keeps analyses between tool calls, so an agent can analyze a
+// trace once and then ask about it repeatedly. The most recent analysis is
+// used when a call names no input.
+type Session struct {
+	load Loader
+
+	mu      sync.Mutex
+	cache   map[string]*analysis
+	current *analysis
+}
+
+// NewSession creates a session that analyzes inputs with load
+func NewSession(load Loader) *Session {
+	return &Session{load: load, cache: map[string]*analysis{}}
+}
+
+// get returns the analysis of input, running it on first use. An empty
+// input means the most recent analysis.
+func (s *Session) get(input, sourceRoot string, refresh bool) (*analysis, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if input == "" {
+		if s.current == nil {
+			return nil, fmt.Errorf("nothing analyzed yet: call analyze_trace first or pass an input")
+		}
+		return s.current, nil
+	}
+	key := input + "\x00" + sourceRoot
+	if a, ok := s.cache[key]; ok && !refresh {
+		s.current = a
+		return a, nil
+	}
+	report, err := s.load(input, sourceRoot)
+	if err != nil {
+		return nil, err
+	}
+	a := &analysis{input: input, sourceRoot: sourceRoot, report: report, graph: report.Graph.ToGraph()}
+	s.cache[key] = a
+	s.current = a
+	return a, nil
+}
+
+// Tools returns the session's tools
+func (s *Session) Tools() []Tool {
+	inputProp := prop("string", "Export directory, .trace, saved graph or analysis.json; defaults to the most recent analysis")
+	sourceProp := prop("string", "Swift source root for code correlation (optional)")
+	return []Tool{
+		{
+			Name:        "analyze_trace",
+			Description: "Analyze a SwiftUI trace, export, saved graph or analysis report and cache the result for the other tools. Returns the score, totals and a one-line list of issues.",
+			InputSchema: schema(map[string]any{
+				"input":   prop("string", "Export directory, .trace, saved graph (.json/.graphml) or analysis.json"),
+				"source":  sourceProp,
+				"refresh": prop("boolean", "Re-analyze even if the input is cached"),
+			}, "input"),
+			Handler: s.analyzeTrace,
+		},
+		{
+			Name:        "list_issues",
+			Description: "List detected issues, most severe first, optionally filtered by minimum severity, type or affected node label.",
+			InputSchema: schema(map[string]any{
+				"input":        inputProp,
+				"min_severity": enumProp("Only issues at or above this severity", "critical", "high", "medium", "low", "info"),
+				"type":         enumProp("Only issues of this type", issueTypeNames()...),
+				"node":         prop("string", "Only issues affecting a node whose label matches this case-insensitive regex"),
+				"limit":        prop("integer", fmt.Sprintf("Maximum issues returned (default %d)", defaultListLimit)),
+			}),
+			Handler: s.listIssues,
+		},
+		{
+			Name:        "get_issue_detail",
+			Description: "Full detail of one issue: description, cause chain with labels, source locations with snippets and every suggested fix with code.",
+			InputSchema: schema(map[string]any{
+				"input":    inputProp,
+				"issue_id": prop("string", "Issue ID from list_issues"),
+			}, "issue_id"),
+			Handler: s.issueDetail,
+		},
+		{
+			Name:        "query_graph_paths",
+			Description: "Find paths between two nodes (from/to), or walk the graph up (towards causes) or down (towards views) from one node. Nodes are IDs or case-insensitive label regexes.",
+			InputSchema: schema(map[string]any{
+				"input":     inputProp,
+				"from":      prop("string", "Path start node"),
+				"to":        prop("string", "Path end node"),
+				"all":       prop("boolean", "All simple paths instead of only the shortest"),
+				"node":      prop("string", "Traversal start node (used when from/to are not given)"),
+				"direction": enumProp("Traversal direction from node (default down)", "up", "down"),
+				"depth":     prop("integer", "Maximum path or traversal length in edges (0 = unlimited)"),
+				"min_count": prop("integer", "Only report nodes with at least this update count"),
+			}),
+			Handler: s.queryPaths,
+		},
+		{
+			Name:        "find_source_for_node",
+			Description: "Source locations (file, line, snippet, confidence) that correlate with a graph node. Needs a source root on the analysis.",
+			InputSchema: schema(map[string]any{
+				"input":  inputProp,
+				"source": prop("string", "Swift source root; re-analyzes the input with it if the cached analysis has none"),
+				"node":   prop("string", "Node ID or case-insensitive label regex"),
+			}, "node"),
+			Handler: s.findSource,
+		},
+		{
+			Name:        "compare_reports",
+			Description: "Compare two analyses (for example before and after a fix): score change, resolved, new and persisting issues, and the largest update count changes.",
+			InputSchema: schema(map[string]any{
+				"base":   prop("string", "Input analyzed as the baseline"),
+				"head":   prop("string", "Input analyzed as the new version; defaults to the most recent analysis"),
+				"source": sourceProp,
+				"top":    prop("integer", fmt.Sprintf("Node count changes returned (default %d)", compare.DefaultTopNodes)),
+			}, "base"),
+			Handler: s.compareReports,
+		},
+	}
+}
+
+// IssueSummary is an issue as listed by analyze_trace and list_issues
+type IssueSummary struct {
+	ID          string           `json:"id"`
+	Type        issues.IssueType `json:"type"`
+	Severity    issues.Severity  `json:"severity"`
+	Title       string           `json:"title"`
+	UpdateCount int              `json:"update_count,omitempty"`
+	Location    string           `json:"location,omitempty"`
+	Fixes       int              `json:"fixes"`
+}
+
+func summarizeIssue(is aioutput.IssueWithFixes) IssueSummary {
+	s := IssueSummary{ID: is.ID, Type: is.Type, Severity: is.Severity, Title: is.Title, UpdateCount: is.UpdateCount, Fixes: len(is.SuggestedFixes)}
+	if is.SourceFile != "" {
+		s.Location = fmt.Sprintf("%s:%d", is.SourceFile, is.LineNumber)
+	}
+	return s
+}
+
+func (s *Session) analyzeTrace(args json.RawMessage) (any, error) {
+	var p struct {
+		Input   string `json:"input"`
+		Source  string `json:"source"`
+		Refresh bool   `json:"refresh"`
+	}
+	if err := decode(args, &p); err != nil {
+		return nil, err
+	}
+	if p.Input == "" {
+		return nil, fmt.Errorf("input is required")
+	}
+	a, err := s.get(p.Input, p.Source, p.Refresh)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]IssueSummary, 0, len(a.report.Issues))
+	for _, is := range a.report.Issues {
+		list = append(list, summarizeIssue(is))
+	}
+	sortIssues(list)
+	return map[string]any{
+		"input":           a.input,
+		"source_root":     a.sourceRoot,
+		"summary":         a.report.Summary,
+		"nodes":           len(a.report.Graph.Nodes),
+		"edges":           len(a.report.Graph.Edges),
+		"correlations":    len(a.report.SourceCorrelations),
+		"issues":          list,
+		"recommendations": a.report.Recommendations,
+	}, nil
+}
+
+func (s *Session) listIssues(args json.RawMessage) (any, error) {
+	var p struct {
+		Input       string `json:"input"`
+		MinSeverity string `json:"min_severity"`
+		Type        string `json:"type"`
+		Node        string `json:"node"`
+		Limit       int    `json:"limit"`
+	}
+	if err := decode(args, &p); err != nil {
+		return nil, err
+	}
+	a, err := s.get(p.Input, "", false)
+	if err != nil {
+		return nil, err
+	}
+	min := issues.SeverityInfo
+	if p.MinSeverity != "" {
+		if min, err = issues.ParseSeverity(p.MinSeverity); err != nil {
+			return nil, err
+		}
+	}
+	var affected map[string]bool
+	if p.Node != "" {
+		nodes, err := query.Match(a.graph, p.Node)
+		if err != nil {
+			return nil, err
+		}
+		affected = map[string]bool{}
+		for _, n := range nodes {
+			affected[n.ID] = true
+			affected[n.Label] = true // cascades list labels
+		}
+	}
+	if p.Limit <= 0 {
+		p.Limit = defaultListLimit
+	}
+
+	list := []IssueSummary{}
+	for _, is := range a.report.Issues {
+		if !is.Severity.AtLeast(min) || (p.Type != "" && string(is.Type) != p.Type) || !affects(is.Issue, affected) {
+			continue
+		}
+		list = append(list, summarizeIssue(is))
+	}
+	sortIssues(list)
+	total := len(list)
+	if len(list) > p.Limit {
+		list = list[:p.Limit]
+	}
+	return map[string]any{"total": total, "issues": list}, nil
+}
+
+func affects(is issues.Issue, nodes map[string]bool) bool {
+	if nodes == nil {
+		return true
+	}
+	for _, ref := range is.AffectedNodes {
+		if nodes[ref] {
+			return true
+		}
+	}
+	return false
+}
+
+// IssueDetail is the answer to get_issue_detail
+type IssueDetail struct {
+	aioutput.IssueWithFixes
+	CauseChainLabels []string                  `json:"cause_chain_labels,omitempty"`
+	Sources          []correlation.SourceMatch `json:"sources,omitempty"`
+}
+
+func (s *Session) issueDetail(args json.RawMessage) (any, error) {
+	var p struct {
+		Input   string `json:"input"`
+		IssueID string `json:"issue_id"`
+	}
+	if err := decode(args, &p); err != nil {
+		return nil, err
+	}
+	a, err := s.get(p.Input, "", false)
+	if err != nil {
+		return nil, err
+	}
+	for _, is := range a.report.Issues {
+		if is.ID != p.IssueID {
+			continue
+		}
+		d := IssueDetail{IssueWithFixes: is}
+		for _, ref := range is.CauseChain {
+			d.CauseChainLabels = append(d.CauseChainLabels, a.label(ref))
+		}
+		for _, ref := range is.AffectedNodes {
+			if m := a.bestMatch(ref); m != nil {
+				d.Sources = append(d.Sources, *m)
+			}
+		}
+		return d, nil
+	}
+	return nil, fmt.Errorf("no issue %q; list_issues shows the IDs", p.IssueID)
+}
+
+func (s *Session) queryPaths(args json.RawMessage) (any, error) {
+	var p struct {
+		Input     string `json:"input"`
+		From      string `json:"from"`
+		To        string `json:"to"`
+		All       bool   `json:"all"`
+		Node      string `json:"node"`
+		Direction string `json:"direction"`
+		Depth     int    `json:"depth"`
+		MinCount  int    `json:"min_count"`
+	}
+	if err := decode(args, &p); err != nil {
+		return nil, err
+	}
+	a, err := s.get(p.Input, "", false)
+	if err != nil {
+		return nil, err
+	}
+	if p.Direction == "" {
+		p.Direction = string(query.Downstream)
+	}
+	res, err := query.Run(a.graph, query.Query{
+		Node:      p.Node,
+		Direction: query.Direction(p.Direction),
+		From:      p.From,
+		To:        p.To,
+		AllPaths:  p.All,
+		MinCount:  p.MinCount,
+		MaxDepth:  p.Depth,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *Session) findSource(args json.RawMessage) (any, error) {
+	var p struct {
+		Input  string `json:"input"`
+		Source string `json:"source"`
+		Node   string `json:"node"`
+	}
+	if err := decode(args, &p); err != nil {
+		return nil, err
+	}
+	a, err := s.get(p.Input, "", false)
+	if err != nil {
+		return nil, err
+	}
+	if a.sourceRoot == "" && a.report.Input.SourceRoot == "" {
+		if p.Source == "" {
+			return nil, fmt.Errorf("the analysis has no source root: pass source, or analyze_trace with one")
+		}
+		if a, err = s.get(a.input, p.Source, false); err != nil {
+			return nil, err
+		}
+	}
+	nodes, err := query.Match(a.graph, p.Node)
+	if err != nil {
+		return nil, err
+	}
+	type nodeSources struct {
+		ID      string                    `json:"id"`
+		Label   string                    `json:"label"`
+		Type    string                    `json:"type"`
+		Matches []correlation.SourceMatch `json:"matches"`
+	}
+	out := []nodeSources{}
+	for _, n := range nodes {
+		ns := nodeSources{ID: n.ID, Label: n.Label, Type: string(n.Type), Matches: []correlation.SourceMatch{}}
+		for _, m := range a.report.SourceCorrelations {
+			if m.TraceNodeID == n.ID {
+				ns.Matches = append(ns.Matches, m)
+			}
+		}
+		sort.SliceStable(ns.Matches, func(i, j int) bool { return ns.Matches[i].Confidence > ns.Matches[j].Confidence })
+		out = append(out, ns)
+	}
+	return out, nil
+}
+
+func (s *Session) compareReports(args json.RawMessage) (any, error) {
+	var p struct {
+		Base   string `json:"base"`
+		Head   string `json:"head"`
+		Source string `json:"source"`
+		Top    int    `json:"top"`
+	}
+	if err := decode(args, &p); err != nil {
+		return nil, err
+	}
+	if p.Base == "" {
+		return nil, fmt.Errorf("base is required")
+	}
+	head, err := s.get(p.Head, p.Source, false)
+	if err != nil {
+		return nil, err
+	}
+	base, err := s.get(p.Base, p.Source, false)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.current = head // keep follow-up calls on the new version
+	s.mu.Unlock()
+	return compare.Reports(base.report, head.report, p.Top), nil
+}
+
+// label returns the label of a node ID, or ref itself
+func (a *analysis) label(ref string) string {
+	if n, ok := a.graph.Nodes[ref]; ok {
+		return n.Label
+	}
+	return ref
+}
+
+// bestMatch returns the most confident source match of a node ID or label
+func (a *analysis) bestMatch(ref string) *correlation.SourceMatch {
+	var best *correlation.SourceMatch
+	for i, m := range a.report.SourceCorrelations {
+		if m.TraceNodeID != ref && m.TraceLabel != ref {
+			continue
+		}
+		if best == nil || m.Confidence > best.Confidence {
+			best = &a.report.SourceCorrelations[i]
+		}
+	}
+	return best
+}
+
+func sortIssues(list []IssueSummary) {
+	issues.SortBySeverity(list, func(s IssueSummary) (issues.Severity, int) { return s.Severity, s.UpdateCount })
+}
+
+func decode(args json.RawMessage, v any) error {
+	dec := json.NewDecoder(strings.NewReader(string(args)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+func issueTypeNames() []string {
+	var names []string
+	for _, t := range issues.DetectorTypes {
+		names = append(names, string(t))
+	}
+	return names
+}
+
+func schema(props map[string]any, required ...string) map[string]any {
+	s := map[string]any{"type": "object", "properties": props, "additionalProperties": false}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func prop(typ, description string) map[string]any {
+	return map[string]any{"type": typ, "description": description}
+}
+
+func enumProp(description string, values ...string) map[string]any {
+	return map[string]any{"type": "string", "description": description, "enum": values}
+}
@@ -0,0 +1,189 @@
+// Package merge combines cause-effect graphs from several traces or runs.
+package merge
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/normalize"
+)
+
+// Aggregate selects which value becomes a merged node's Count
+type Aggregate string
+
+const (
+	AggregateSum    Aggregate = "sum"
+	AggregateMedian Aggregate = "median"
+	AggregateMax    Aggregate = "max"
+)
+
+// ParseAggregate validates an aggregate name
+func ParseAggregate(s string) (Aggregate, error) {
+	switch a := Aggregate(strings.ToLower(strings.TrimSpace(s))); a {
+	case AggregateSum, AggregateMedian, AggregateMax:
+		return a, nil
+	case "":
+		return AggregateSum, nil
+	}
+	return "", fmt.Errorf("unknown aggregate %q (want sum, median or max)", s)
+}
+
+// Source is one input graph with a display name
+type Source struct {
+	Name  string
+	Graph *graph.Graph
+}
+
+// CountStats summarizes a node's counts across sources
+type CountStats struct {
+	Min    int     `json:"min"`
+	Median float64 `json:"median"`
+	Max    int     `json:"max"`
+}
+
+// Key returns the identity used to align nodes across sources: the node
+// type plus its normalized label (see normalize.Label) with case folded.
+func Key(n *graph.Node) string {
+	label := strings.ToLower(normalize.Label(n.Label))
+	if label == "" {
+		label = "#" + n.ID
+	}
+	return string(n.Type) + "|" + label
+}
+
+// Graphs merges the sources into one graph. Nodes with the same Key become
+// one node that keeps the ID it had in the first source containing it, or
+// that ID with a ~N suffix if an earlier source took it; per-source counts
+// are recorded in SourceCounts and Count is aggregated with agg. Duplicate
+// edges between merged nodes are collapsed.
+func Graphs(sources []Source, agg Aggregate) *graph.Graph {
+	merged := graph.New()
+	byKey := map[string]*graph.Node{}
+	usedIDs := map[string]bool{}
+
+	for _, src := range sources {
+		idMap := map[string]string{}
+		for _, n := range src.Graph.SortedNodes() {
+			key := Key(n)
+			target, ok := byKey[key]
+			if !ok {
+				id := uniqueID(n.ID, usedIDs)
+				target = &graph.Node{ID: id, Label: n.Label, Type: n.Type, SourceCounts: map[string]int{}}
+				byKey[key] = target
+				merged.Nodes[id] = target
+			}
+			target.SourceCounts[src.Name] += n.Count
+			target.Instances = append(target.Instances, n.Instances...)
+			idMap[n.ID] = target.ID
+		}
+
+		// An endpoint that is not a node of this source is renamed the same
+		// way, so it cannot attach to another source's node
+		endpoint := func(id string) string {
+			if to, ok := idMap[id]; ok {
+				return to
+			}
+			to := uniqueID(id, usedIDs)
+			idMap[id] = to
+			return to
+		}
+		for _, e := range src.Graph.Edges {
+			merged.AddEdge(graph.Edge{From: endpoint(e.From), To: endpoint(e.To), Label: e.Label})
+		}
+		for _, e := range src.Graph.Containment {
+			merged.AddContainment(endpoint(e.From), endpoint(e.To))
+		}
+	}
+
+	merged.Edges = dedupeEdges(merged.Edges)
+
+	names := Names(sources)
+	for _, n := range merged.Nodes {
+		n.Count = aggregate(n.SourceCounts, names, agg)
+	}
+	return merged
+}
+
+// uniqueID returns id, or id~N if it is taken, and marks the result taken
+func uniqueID(id string, used map[string]bool) string {
+	out := id
+	for i := 2; used[out]; i++ {
+		out = fmt.Sprintf("%s~%d", id, i)
+	}
+	used[out] = true
+	return out
+}
+
+// Names returns the source names in input order
+func Names(sources []Source) []string {
+	names := make([]string, len(sources))
+	for i, s := range sources {
+		names[i] = s.Name
+	}
+	return names
+}
+
+func dedupeEdges(edges []graph.Edge) []graph.Edge {
+	seen := map[graph.Edge]bool{}
+	out := edges[:0]
+	for _, e := range edges {
+		if seen[e] {
+			continue
+		}
+		seen[e] = true
+		out = append(out, e)
+	}
+	return out
+}
+
+func aggregate(counts map[string]int, sources []string, agg Aggregate) int {
+	switch agg {
+	case AggregateMedian:
+		return int(Stats(counts, sources).Median + 0.5)
+	case AggregateMax:
+		return Stats(counts, sources).Max
+	}
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	return total
+}
+
+// Stats computes min/median/max over all sources; a source that did not
+// contain the node counts as zero.
+func Stats(counts map[string]int, sources []string) CountStats {
+	if len(sources) == 0 {
+		return CountStats{}
+	}
+	values := make([]int, len(sources))
+	for i, s := range sources {
+		values[i] = counts[s]
+	}
+	sort.Ints(values)
+
+	mid := len(values) / 2
+	median := float64(values[mid])
+	if len(values)%2 == 0 {
+		median = float64(values[mid-1]+values[mid]) / 2
+	}
+	return CountStats{Min: values[0], Median: median, Max: values[len(values)-1]}
+}
+
+// UniqueNames derives distinct display names for inputs, appending #2, #3...
+// when several inputs share a base name.
+func UniqueNames(names []string) []string {
+	out := make([]string, len(names))
+	seen := map[string]int{}
+	for i, n := range names {
+		seen[n]++
+		if seen[n] > 1 {
+			out[i] = fmt.Sprintf("%s#%d", n, seen[n])
+		} else {
+			out[i] = n
+		}
+	}
+	return out
+}
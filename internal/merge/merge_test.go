@@ -0,0 +1,140 @@
+package merge
+
+import (
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+func run(rowCount int, extraID string) *graph.Graph {
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "c" + extraID, Label: "Button tap", Type: graph.NodeCause, Count: 1})
+	g.UpsertNode(&graph.Node{ID: "v" + extraID, Label: "RowView", Type: graph.NodeView, Count: rowCount})
+	g.AddEdge(graph.Edge{From: "c" + extraID, To: "v" + extraID, Label: "updates"})
+	return g
+}
+
+func TestParseAggregate(t *testing.T) {
+	if a, err := ParseAggregate(""); err != nil || a != AggregateSum {
+		t.Errorf("empty aggregate: got %q, %v", a, err)
+	}
+	if a, err := ParseAggregate("Median"); err != nil || a != AggregateMedian {
+		t.Errorf("Median: got %q, %v", a, err)
+	}
+	if _, err := ParseAggregate("mean"); err == nil {
+		t.Error("expected error for unknown aggregate")
+	}
+}
+
+func TestKey(t *testing.T) {
+	a := &graph.Node{ID: "1", Label: "Row  View", Type: graph.NodeView}
+	b := &graph.Node{ID: "2", Label: "row view", Type: graph.NodeView}
+	c := &graph.Node{ID: "3", Label: "row view", Type: graph.NodeState}
+	if Key(a) != Key(b) {
+		t.Errorf("expected equal keys: %q vs %q", Key(a), Key(b))
+	}
+	if Key(a) == Key(c) {
+		t.Error("nodes of different types should not align")
+	}
+
+	// Addresses and generic parameters differ between runs
+	d := &graph.Node{ID: "4", Label: "ForEach<Array<Item>, UUID, RowView>: 0x600001", Type: graph.NodeView}
+	e := &graph.Node{ID: "5", Label: "ForEach<Array<Item>, Int, RowView>: 0x700002", Type: graph.NodeView}
+	if Key(d) != Key(e) {
+		t.Errorf("expected normalized labels to align: %q vs %q", Key(d), Key(e))
+	}
+}
+
+func TestGraphs_AlignsAndSums(t *testing.T) {
+	merged := Graphs([]Source{
+		{Name: "iphone", Graph: run(10, "1")},
+		{Name: "ipad", Graph: run(30, "9")},
+	}, AggregateSum)
+
+	if len(merged.Nodes) != 2 {
+		t.Fatalf("expected 2 aligned nodes, got %d", len(merged.Nodes))
+	}
+	if len(merged.Edges) != 1 {
+		t.Errorf("expected duplicate edges collapsed, got %v", merged.Edges)
+	}
+	row, ok := merged.Nodes["v1"]
+	if !ok {
+		t.Fatal("merged node should keep the first source's ID")
+	}
+	if row.Count != 40 {
+		t.Errorf("expected summed count 40, got %d", row.Count)
+	}
+	if row.SourceCounts["iphone"] != 10 || row.SourceCounts["ipad"] != 30 {
+		t.Errorf("unexpected per-source counts: %v", row.SourceCounts)
+	}
+}
+
+func TestGraphs_MedianDampensOutlier(t *testing.T) {
+	merged := Graphs([]Source{
+		{Name: "a", Graph: run(10, "1")},
+		{Name: "b", Graph: run(12, "1")},
+		{Name: "c", Graph: run(500, "1")},
+	}, AggregateMedian)
+	if got := merged.Nodes["v1"].Count; got != 12 {
+		t.Errorf("expected median 12, got %d", got)
+	}
+}
+
+func TestGraphs_IDCollision(t *testing.T) {
+	a := graph.New()
+	a.UpsertNode(&graph.Node{ID: "n1", Label: "RowView", Type: graph.NodeView})
+	b := graph.New()
+	b.UpsertNode(&graph.Node{ID: "n1", Label: "HeaderView", Type: graph.NodeView})
+
+	merged := Graphs([]Source{{Name: "a", Graph: a}, {Name: "b", Graph: b}}, AggregateSum)
+	if len(merged.Nodes) != 2 {
+		t.Fatalf("expected 2 distinct nodes, got %d", len(merged.Nodes))
+	}
+	if _, ok := merged.Nodes["n1~2"]; !ok {
+		t.Errorf("expected renamed ID for colliding node, got %v", merged.Nodes)
+	}
+}
+
+func TestGraphs_IDCollisionEdges(t *testing.T) {
+	a := graph.New()
+	a.UpsertNode(&graph.Node{ID: "n1", Label: "Timer", Type: graph.NodeCause})
+	a.UpsertNode(&graph.Node{ID: "n2", Label: "RowView", Type: graph.NodeView})
+	a.AddEdge(graph.Edge{From: "n1", To: "n2"})
+	b := graph.New()
+	b.UpsertNode(&graph.Node{ID: "n2", Label: "HeaderView", Type: graph.NodeView})
+	b.AddEdge(graph.Edge{From: "n1", To: "n2"}) // n1 is not a node of b
+	b.AddContainment("n2", "n1")
+
+	merged := Graphs([]Source{{Name: "a", Graph: a}, {Name: "b", Graph: b}}, AggregateSum)
+	want := map[graph.Edge]bool{{From: "n1", To: "n2"}: true, {From: "n1~2", To: "n2~2"}: true}
+	if len(merged.Edges) != len(want) {
+		t.Fatalf("expected %d edges, got %v", len(want), merged.Edges)
+	}
+	for _, e := range merged.Edges {
+		if !want[e] {
+			t.Errorf("edge %s -> %s attached to the wrong node", e.From, e.To)
+		}
+	}
+	if len(merged.Containment) != 1 || merged.Containment[0].From != "n2~2" || merged.Containment[0].To != "n1~2" {
+		t.Errorf("containment not renamed with its source: %v", merged.Containment)
+	}
+}
+
+func TestStats(t *testing.T) {
+	sources := []string{"a", "b", "c", "d"}
+	s := Stats(map[string]int{"a": 4, "b": 10, "c": 6}, sources)
+	// d is missing and counts as 0: values 0,4,6,10
+	if s.Min != 0 || s.Max != 10 || s.Median != 5 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+	if (Stats(nil, nil) != CountStats{}) {
+		t.Error("expected zero stats without sources")
+	}
+}
+
+func TestUniqueNames(t *testing.T) {
+	got := UniqueNames([]string{"run", "other", "run"})
+	if got[0] != "run" || got[1] != "other" || got[2] != "run#2" {
+		t.Errorf("unexpected names: %v", got)
+	}
+}
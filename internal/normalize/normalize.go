@@ -0,0 +1,267 @@
+// Package normalize cleans up trace labels and collapses per-instance nodes.
+package normalize
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+var (
+	// "RowView: 0x600003a1c000", "RowView @ 0x6000...", "at 0x6000..."
+	reAddress = regexp.MustCompile(`(?:\s*(?::|@|\bat\b)\s*)?\b0x[0-9a-fA-F]{4,}\b`)
+	// Trailing instance ordinals such as "RowView #3"
+	reOrdinal = regexp.MustCompile(`\s*#\d+\s*$`)
+	// Mangled Swift symbols (Swift 5+ and Swift 4)
+	reMangled = regexp.MustCompile(`_?\$[sS][0-9A-Za-z_]+|\b_T0[0-9A-Za-z_]+`)
+	// Brackets emptied by the rewrites above, e.g. "(0x6000...)"
+	reEmptyBrackets = regexp.MustCompile(`<\s*>|\(\s*\)|\[\s*\]`)
+)
+
+// viewSuffixes mark generic arguments worth keeping when simplifying
+var viewSuffixes = []string{"View", "Screen", "Row", "Cell", "Page", "Content", "Button", "Label"}
+
+// Label returns the normalized form of a trace label: memory addresses and
+// instance ordinals are removed, mangled Swift names are demangled and
+// generic parameters are reduced to the view types they carry, so
+// "ForEach<Array<Item>, UUID, RowView>" becomes "ForEach<RowView>".
+func Label(raw string) string {
+	s := reMangled.ReplaceAllStringFunc(raw, Demangle)
+	s = reAddress.ReplaceAllString(s, "")
+	s = reOrdinal.ReplaceAllString(s, "")
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "<") && matchingBracket(s, 0) == len(s)-1 {
+		s = s[1 : len(s)-1] // object descriptions: "<RowView: 0x6000...>"
+	}
+	s = SimplifyGenerics(s)
+	s = reEmptyBrackets.ReplaceAllString(s, "")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Demangle decodes the type path of a mangled Swift symbol, dropping the
+// module name: "$s5MyApp7RowViewV" becomes "RowView" and nested types are
+// joined with dots. Only length-prefixed identifiers are understood;
+// symbols using substitutions or operators are returned unchanged.
+func Demangle(sym string) string {
+	s := strings.TrimPrefix(sym, "_")
+	switch {
+	case strings.HasPrefix(s, "$s"), strings.HasPrefix(s, "$S"):
+		s = s[2:]
+	case strings.HasPrefix(s, "T0"):
+		s = s[2:]
+	default:
+		return sym
+	}
+
+	var idents []string
+	for len(s) > 0 {
+		// Nested types follow their parent's kind marker: 10ListScreenV4CellV
+		if len(idents) > 0 && len(s) > 1 && strings.IndexByte("VCOP", s[0]) >= 0 {
+			s = s[1:]
+		}
+		if s[0] < '1' || s[0] > '9' {
+			break
+		}
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		n, err := strconv.Atoi(s[:i])
+		if err != nil || i+n > len(s) {
+			break
+		}
+		idents = append(idents, s[i:i+n])
+		s = s[i+n:]
+	}
+	switch len(idents) {
+	case 0:
+		return sym
+	case 1:
+		return idents[0]
+	}
+	return strings.Join(idents[1:], ".")
+}
+
+// SimplifyGenerics rewrites every generic argument list to keep only the
+// view-like arguments; a list with none is dropped, so "Array<Item>"
+// becomes "Array" and "ModifiedContent<RowView, _PaddingLayout>" becomes
+// "ModifiedContent<RowView>".
+func SimplifyGenerics(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '<' || i == 0 || !isIdentByte(s[i-1]) {
+			b.WriteByte(s[i])
+			continue
+		}
+		end := matchingBracket(s, i)
+		if end < 0 {
+			b.WriteString(s[i:])
+			break
+		}
+		var kept []string
+		for _, arg := range splitTopLevel(s[i+1 : end]) {
+			arg = SimplifyGenerics(strings.TrimSpace(arg))
+			if isViewLike(arg) {
+				kept = append(kept, arg)
+			}
+		}
+		if len(kept) > 0 {
+			b.WriteString("<" + strings.Join(kept, ", ") + ">")
+		}
+		i = end
+	}
+	return b.String()
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func matchingBracket(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '<', '(', '[':
+			depth++
+		case '>', ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// isViewLike reports whether a (simplified) type names a view, judging by
+// its base name or the view it wraps.
+func isViewLike(t string) bool {
+	if i := strings.IndexByte(t, '<'); i >= 0 {
+		return true // still carries a view argument after simplification
+	}
+	base := t
+	if i := strings.LastIndexByte(base, '.'); i >= 0 {
+		base = base[i+1:]
+	}
+	for _, suffix := range viewSuffixes {
+		if strings.HasSuffix(base, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Collapse normalizes every label in g and folds nodes of the same type
+// whose normalized labels match into a single node. The folded node keeps
+// the lowest ID of its group, sums counts (and per-source counts) and lists
+// the originals in Instances; a lone node whose label changed records its
+// raw label the same way. Edges and containment are rewired, duplicates
+// and self-loops introduced by folding are dropped. It returns the number
+// of nodes removed.
+func Collapse(g *graph.Graph) int {
+	type group struct {
+		target  *graph.Node
+		members []*graph.Node
+	}
+	groups := map[string]*group{}
+	var order []*group
+	for _, n := range g.SortedNodes() {
+		label := Label(n.Label)
+		key := string(n.Type) + "|" + label
+		if label == "" {
+			key = "#" + n.ID // nothing left to align on
+		}
+		grp, ok := groups[key]
+		if !ok {
+			grp = &group{target: &graph.Node{ID: n.ID, Label: label, Type: n.Type}}
+			if label == "" {
+				grp.target.Label = n.Label
+			}
+			groups[key] = grp
+			order = append(order, grp)
+		}
+		grp.members = append(grp.members, n)
+	}
+
+	idMap := map[string]string{}
+	nodes := make(map[string]*graph.Node, len(order))
+	for _, grp := range order {
+		t := grp.target
+		for _, m := range grp.members {
+			idMap[m.ID] = t.ID
+			t.Count += m.Count
+			for name, c := range m.SourceCounts {
+				if t.SourceCounts == nil {
+					t.SourceCounts = map[string]int{}
+				}
+				t.SourceCounts[name] += c
+			}
+			if len(m.Instances) > 0 {
+				t.Instances = append(t.Instances, m.Instances...)
+			} else {
+				t.Instances = append(t.Instances, graph.Instance{ID: m.ID, Label: m.Label, Count: m.Count})
+			}
+		}
+		if len(grp.members) == 1 && len(grp.members[0].Instances) == 0 && grp.members[0].Label == t.Label {
+			t.Instances = nil // untouched node
+		}
+		nodes[t.ID] = t
+	}
+	removed := len(g.Nodes) - len(nodes)
+	g.Nodes = nodes
+
+	remap := func(id string) string {
+		if to, ok := idMap[id]; ok {
+			return to
+		}
+		return id
+	}
+	// Edges folding left alone are kept as they are, repeats included; a
+	// rewired edge is kept only if no other edge already has its ends
+	seen := map[graph.Edge]bool{}
+	for _, e := range g.Edges {
+		if remap(e.From) == e.From && remap(e.To) == e.To {
+			seen[e] = true
+		}
+	}
+	edges := make([]graph.Edge, 0, len(g.Edges))
+	for _, e := range g.Edges {
+		re := graph.Edge{From: remap(e.From), To: remap(e.To), Label: e.Label}
+		if re != e {
+			if (re.From == re.To && e.From != e.To) || seen[re] {
+				continue
+			}
+			seen[re] = true
+		}
+		edges = append(edges, re)
+	}
+	g.Edges = edges
+
+	containment := g.Containment
+	g.Containment = nil
+	for _, e := range containment {
+		g.AddContainment(remap(e.From), remap(e.To))
+	}
+	return removed
+}
@@ -0,0 +1,136 @@
+package normalize
+
+import (
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+func TestLabel(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{"RowView", "RowView"},
+		{"RowView: 0x600003a1c000", "RowView"},
+		{"<RowView: 0x600003a1c000>", "RowView"},
+		{"RowView at 0x600003a1c000 body", "RowView body"},
+		{"RowView #12", "RowView"},
+		{"ForEach<Array<Item>, UUID, RowView>", "ForEach<RowView>"},
+		{"ModifiedContent<RowView, _PaddingLayout>", "ModifiedContent<RowView>"},
+		{"Array<Item>", "Array"},
+		{"List<Never, ForEach<Array<Item>, UUID, RowView>>", "List<ForEach<RowView>>"},
+		{"$s5MyApp7RowViewV", "RowView"},
+		{"body of $s5MyApp10ListScreenV4CellV", "body of ListScreen.Cell"},
+		{"@State items", "@State items"},
+		{"count < 5", "count < 5"},
+	}
+	for _, tt := range tests {
+		if got := Label(tt.raw); got != tt.expected {
+			t.Errorf("Label(%q) = %q, want %q", tt.raw, got, tt.expected)
+		}
+	}
+}
+
+func TestDemangle(t *testing.T) {
+	tests := []struct {
+		sym      string
+		expected string
+	}{
+		{"$s5MyApp7RowViewV", "RowView"},
+		{"_$s5MyApp7RowViewVMn", "RowView"},
+		{"_T05MyApp7RowViewV", "RowView"},
+		{"$s5MyAppV", "MyApp"},
+		{"$sSo8NSObjectC", "$sSo8NSObjectC"}, // substitutions are not decoded
+		{"RowView", "RowView"},
+	}
+	for _, tt := range tests {
+		if got := Demangle(tt.sym); got != tt.expected {
+			t.Errorf("Demangle(%q) = %q, want %q", tt.sym, got, tt.expected)
+		}
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "s1", Label: "@State items", Type: graph.NodeState})
+	g.UpsertNode(&graph.Node{ID: "r1", Label: "RowView: 0x6000aaaa", Type: graph.NodeView, Count: 30})
+	g.UpsertNode(&graph.Node{ID: "r2", Label: "RowView: 0x6000bbbb", Type: graph.NodeView, Count: 10, SourceCounts: map[string]int{"a": 10}})
+	g.UpsertNode(&graph.Node{ID: "f1", Label: "ForEach<Array<Item>, UUID, RowView>", Type: graph.NodeView, Count: 2})
+	g.AddEdge(graph.Edge{From: "s1", To: "r1", Label: "updates"})
+	g.AddEdge(graph.Edge{From: "s1", To: "r2", Label: "updates"})
+	g.AddEdge(graph.Edge{From: "r1", To: "r2"}) // becomes a self-loop
+	g.AddContainment("f1", "r1")
+	g.AddContainment("f1", "r2")
+
+	if removed := Collapse(g); removed != 1 {
+		t.Errorf("expected 1 node removed, got %d", removed)
+	}
+
+	row, ok := g.Nodes["r1"]
+	if !ok {
+		t.Fatalf("expected collapsed node to keep the lowest ID, got %v", g.Nodes)
+	}
+	if row.Label != "RowView" || row.Count != 40 || len(row.Instances) != 2 {
+		t.Errorf("unexpected collapsed node: %+v", row)
+	}
+	if row.Instances[1].Label != "RowView: 0x6000bbbb" || row.Instances[1].Count != 10 {
+		t.Errorf("raw instance not kept: %+v", row.Instances)
+	}
+	if row.SourceCounts["a"] != 10 {
+		t.Errorf("expected source counts carried over, got %v", row.SourceCounts)
+	}
+
+	if f := g.Nodes["f1"]; f.Label != "ForEach<RowView>" || len(f.Instances) != 1 {
+		t.Errorf("expected renamed node to keep its raw label, got %+v", f)
+	}
+	if s := g.Nodes["s1"]; s.Instances != nil {
+		t.Errorf("untouched node should have no instances, got %+v", s.Instances)
+	}
+
+	if len(g.Edges) != 1 || g.Edges[0] != (graph.Edge{From: "s1", To: "r1", Label: "updates"}) {
+		t.Errorf("unexpected edges: %+v", g.Edges)
+	}
+	if len(g.Containment) != 1 {
+		t.Errorf("unexpected containment: %+v", g.Containment)
+	}
+}
+
+func TestCollapse_KeepsRepeatedEdges(t *testing.T) {
+	// Repeats in the trace are kept as parsed; only folding's are dropped
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "c1", Label: "Timer", Type: graph.NodeCause})
+	g.UpsertNode(&graph.Node{ID: "r1", Label: "RowView: 0x6000aaaa", Type: graph.NodeView})
+	g.UpsertNode(&graph.Node{ID: "r2", Label: "RowView: 0x6000bbbb", Type: graph.NodeView})
+	g.AddEdge(graph.Edge{From: "c1", To: "r2"})
+	g.AddEdge(graph.Edge{From: "c1", To: "r1"})
+	g.AddEdge(graph.Edge{From: "c1", To: "r1"})
+	g.AddEdge(graph.Edge{From: "c1", To: "r2"})
+	Collapse(g)
+	if len(g.Edges) != 2 {
+		t.Errorf("expected the two parsed edges, got %+v", g.Edges)
+	}
+
+	plain := graph.New()
+	plain.UpsertNode(&graph.Node{ID: "c1", Label: "Timer", Type: graph.NodeCause})
+	plain.UpsertNode(&graph.Node{ID: "v1", Label: "RowView", Type: graph.NodeView})
+	plain.AddEdge(graph.Edge{From: "c1", To: "v1"})
+	plain.AddEdge(graph.Edge{From: "c1", To: "v1"})
+	Collapse(plain)
+	if len(plain.Edges) != 2 {
+		t.Errorf("nothing was folded, expected both edges kept, got %+v", plain.Edges)
+	}
+}
+
+func TestCollapse_Idempotent(t *testing.T) {
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "r1", Label: "RowView: 0x6000aaaa", Type: graph.NodeView, Count: 30})
+	g.UpsertNode(&graph.Node{ID: "r2", Label: "RowView: 0x6000bbbb", Type: graph.NodeView, Count: 10})
+	Collapse(g)
+	if removed := Collapse(g); removed != 0 {
+		t.Errorf("second collapse removed %d nodes", removed)
+	}
+	if n := g.Nodes["r1"]; n.Count != 40 || len(n.Instances) != 2 {
+		t.Errorf("second collapse changed the node: %+v", n)
+	}
+}
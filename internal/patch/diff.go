@@ -0,0 +1,185 @@
+package patch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines around each hunk
+const contextLines = 3
+
+// maxLCSCells bounds the line-matching table; larger changed regions are
+// shown as one replacement
+const maxLCSCells = 16 << 20
+
+type opKind byte
+
+const (
+	opEqual opKind = ' '
+	opDel   opKind = '-'
+	opAdd   opKind = '+'
+)
+
+type op struct {
+	kind opKind
+	text string
+	a, b int // line numbers (0-based) in the old and new file
+}
+
+// Unified returns a unified diff (git style, three lines of context) from
+// before to after for path, or "" if they are equal.
+func Unified(path, before, after string) string {
+	if before == after {
+		return ""
+	}
+	a, b := splitLines(before), splitLines(after)
+	ops := diffLines(a, b)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", path, path)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == opEqual {
+			i++
+			continue
+		}
+		// Grow the hunk while changes are within two contexts of each other
+		start := i - contextLines
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == opEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*contextLines {
+				end += min(contextLines, run-end)
+				break
+			}
+			end = run
+		}
+		writeHunk(&out, ops[start:end])
+		i = end
+	}
+	return out.String()
+}
+
+func writeHunk(out *strings.Builder, ops []op) {
+	aStart, bStart, aLen, bLen := -1, -1, 0, 0
+	for _, o := range ops {
+		if o.kind != opAdd {
+			if aStart < 0 {
+				aStart = o.a
+			}
+			aLen++
+		}
+		if o.kind != opDel {
+			if bStart < 0 {
+				bStart = o.b
+			}
+			bLen++
+		}
+	}
+	// An empty range starts at the line before it
+	if aStart < 0 {
+		aStart = ops[0].a - 1
+	}
+	if bStart < 0 {
+		bStart = ops[0].b - 1
+	}
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(aStart, aLen), hunkRange(bStart, bLen))
+	for _, o := range ops {
+		out.WriteByte(byte(o.kind))
+		out.WriteString(o.text)
+		out.WriteByte('\n')
+	}
+}
+
+func hunkRange(start, n int) string {
+	if n == 1 {
+		return fmt.Sprint(start + 1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+// noNewline marks a last line without a trailing newline
+const noNewline = "\n\\ No newline at end of file"
+
+// splitLines splits text into lines, marking a missing final newline
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(s, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += noNewline
+	return lines
+}
+
+// diffLines returns the edit script from a to b, matching the longest
+// common subsequence of lines after trimming the common prefix and suffix.
+func diffLines(a, b []string) []op {
+	var ops []op
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		ops = append(ops, op{opEqual, a[pre], pre, pre})
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+
+	if len(ma)*len(mb) > maxLCSCells {
+		for i, s := range ma {
+			ops = append(ops, op{opDel, s, pre + i, pre})
+		}
+		for j, s := range mb {
+			ops = append(ops, op{opAdd, s, pre + len(ma), pre + j})
+		}
+	} else {
+		// lcs[i][j] is the LCS length of ma[i:] and mb[j:]
+		lcs := make([][]int32, len(ma)+1)
+		for i := range lcs {
+			lcs[i] = make([]int32, len(mb)+1)
+		}
+		for i := len(ma) - 1; i >= 0; i-- {
+			for j := len(mb) - 1; j >= 0; j-- {
+				if ma[i] == mb[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(ma) || j < len(mb) {
+			switch {
+			case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+				ops = append(ops, op{opEqual, ma[i], pre + i, pre + j})
+				i++
+				j++
+			case i < len(ma) && (j == len(mb) || lcs[i+1][j] >= lcs[i][j+1]):
+				ops = append(ops, op{opDel, ma[i], pre + i, pre + j})
+				i++
+			default:
+				ops = append(ops, op{opAdd, mb[j], pre + i, pre + j})
+				j++
+			}
+		}
+	}
+
+	for k := 0; k < suf; k++ {
+		ia, ib := len(a)-suf+k, len(b)-suf+k
+		ops = append(ops, op{opEqual, a[ia], ia, ib})
+	}
+	return ops
+}
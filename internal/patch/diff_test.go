@@ -0,0 +1,49 @@
+package patch
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	after := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+	want := `--- a/x.swift
++++ b/x.swift
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -11,3 +11,4 @@
+ k
+ l
+ m
++n
+`
+	if got := Unified("x.swift", before, after); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnified_MergedHunksAndNoNewline(t *testing.T) {
+	got := Unified("y", "1\n2\n3\n4\n5", "1\n2x\n3\n4\n5x")
+	want := `--- a/y
++++ b/y
+@@ -1,5 +1,5 @@
+ 1
+-2
++2x
+ 3
+ 4
+-5
+\ No newline at end of file
++5x
+\ No newline at end of file
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if Unified("z", "same\n", "same\n") != "" {
+		t.Error("expected no diff for equal content")
+	}
+}
@@ -0,0 +1,279 @@
+package patch
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	classDecl     = regexp.MustCompile(`^(\s*)((?:(?:public|internal|private|fileprivate|open|final)\s+)*class\s+(\w+))\s*:\s*([^{]+?)\s*\{(.*)$`)
+	publishedAttr = regexp.MustCompile(`@Published\s+`)
+	publishedVar  = regexp.MustCompile(`@Published\s+(?:(?:public|internal|private|fileprivate)(?:\(set\))?\s+)*var\s+(\w+)`)
+	importLine    = regexp.MustCompile(`^\s*(?:@\w+\s+)?import\s+(\w+)`)
+
+	observedObject    = regexp.MustCompile(`^(\s*)@ObservedObject\s+((?:(?:public|internal|private|fileprivate)\s+)*)var\s+(\w+)\s*:\s*(\w+)(.*)$`)
+	stateObject       = regexp.MustCompile(`^(\s*)@StateObject\s+((?:(?:public|internal|private|fileprivate)\s+)*var\s+(\w+)\s*(?::\s*(\w+))?(?:\s*=\s*(\w+)\s*\(.*)?)$`)
+	environmentObject = regexp.MustCompile(`^(\s*)@EnvironmentObject\s+((?:(?:public|internal|private|fileprivate)\s+)*)var\s+(\w+)\s*:\s*(\w+)(.*)$`)
+	typeDecl          = regexp.MustCompile(`^\s*(?:(?:public|internal|private|fileprivate|final)\s+)*(?:struct|class|extension)\s+\w+`)
+	envModifier       = regexp.MustCompile(`\.environmentObject\((\w+)(\(\))?\)`)
+)
+
+// migrateObservable rewrites ObservableObject classes to @Observable: those
+// declared in the correlated files and those the correlated views observe,
+// wherever they are declared. It then updates every view in the project
+// that observes a migrated class: @ObservedObject becomes a plain property
+// (or @Bindable when $bindings are used), @StateObject becomes @State and
+// @EnvironmentObject becomes @Environment(Type.self).
+func migrateObservable(w *workspace, files []string) []string {
+	var notes []string
+	candidate := map[string]bool{}
+	observed := map[string]bool{}
+	for _, path := range files {
+		candidate[path] = true
+		lines, err := w.lines(path)
+		if err != nil {
+			notes = append(notes, err.Error())
+			continue
+		}
+		for t := range observedTypes(lines) {
+			observed[t] = true
+		}
+	}
+	classFiles := files
+	if len(observed) > 0 {
+		for _, path := range w.swiftFiles() {
+			if candidate[path] {
+				continue
+			}
+			if lines, err := w.lines(path); err == nil && declaresClass(lines, observed) {
+				classFiles = append(classFiles, path)
+			}
+		}
+	}
+
+	migrated := map[string]bool{}
+	for _, path := range classFiles {
+		lines, err := w.lines(path)
+		if err != nil {
+			continue
+		}
+		changed := false
+		for i := 0; i < len(lines); i++ {
+			m := classDecl.FindStringSubmatch(lines[i])
+			if m == nil || !conformsTo(m[4], "ObservableObject") {
+				continue
+			}
+			name := m[3]
+			if !candidate[path] && !observed[name] {
+				continue
+			}
+			end := blockEnd(lines, i)
+			if end < 0 {
+				notes = append(notes, fmt.Sprintf("%s: could not find the end of class %s", path, name))
+				continue
+			}
+			if reason := combineUse(lines[i : end+1]); reason != "" {
+				notes = append(notes, fmt.Sprintf("%s: %s uses %s; migrate it by hand", path, name, reason))
+				continue
+			}
+			for j := i + 1; j < end; j++ {
+				lines[j] = publishedAttr.ReplaceAllString(lines[j], "")
+			}
+			lines[i] = rebuildClassDecl(m)
+			lines = insert(lines, i, m[1]+"@Observable")
+			i = end + 1
+			migrated[name] = true
+			changed = true
+		}
+		if changed {
+			lines = ensureObservationImport(lines)
+			w.set(path, lines)
+		}
+	}
+	if len(migrated) == 0 {
+		if len(notes) == 0 {
+			notes = append(notes, "no ObservableObject class found in the correlated files")
+		}
+		return notes
+	}
+
+	for _, path := range w.swiftFiles() {
+		lines, err := w.lines(path)
+		if err != nil {
+			continue
+		}
+		if out, fileNotes, ok := migrateObservers(path, lines, migrated); ok {
+			w.set(path, out)
+			notes = append(notes, fileNotes...)
+		}
+	}
+	return notes
+}
+
+// observedTypes returns the types a file observes through @ObservedObject,
+// @StateObject or @EnvironmentObject
+func observedTypes(lines []string) map[string]bool {
+	types := map[string]bool{}
+	for _, l := range lines {
+		if m := observedObject.FindStringSubmatch(l); m != nil {
+			types[m[4]] = true
+		} else if m := stateObject.FindStringSubmatch(l); m != nil {
+			if m[4] != "" {
+				types[m[4]] = true
+			} else if m[5] != "" {
+				types[m[5]] = true
+			}
+		} else if m := environmentObject.FindStringSubmatch(l); m != nil {
+			types[m[4]] = true
+		}
+	}
+	return types
+}
+
+func declaresClass(lines []string, names map[string]bool) bool {
+	for _, l := range lines {
+		if m := classDecl.FindStringSubmatch(l); m != nil && names[m[3]] {
+			return true
+		}
+	}
+	return false
+}
+
+func conformsTo(list, proto string) bool {
+	for _, c := range strings.Split(list, ",") {
+		if strings.TrimSpace(c) == proto {
+			return true
+		}
+	}
+	return false
+}
+
+// combineUse reports why a class body cannot drop Combine: it sends
+// objectWillChange itself or subscribes to its own $publishers.
+func combineUse(body []string) string {
+	names := map[string]bool{}
+	for _, l := range body {
+		if m := publishedVar.FindStringSubmatch(l); m != nil {
+			names[m[1]] = true
+		}
+	}
+	for _, l := range body {
+		if strings.Contains(l, "objectWillChange") {
+			return "objectWillChange"
+		}
+		for name := range names {
+			if regexp.MustCompile(`\$` + name + `\b`).MatchString(l) {
+				return "the $" + name + " publisher"
+			}
+		}
+	}
+	return ""
+}
+
+// rebuildClassDecl drops ObservableObject from a class declaration match
+func rebuildClassDecl(m []string) string {
+	var keep []string
+	for _, c := range strings.Split(m[4], ",") {
+		if c = strings.TrimSpace(c); c != "" && c != "ObservableObject" {
+			keep = append(keep, c)
+		}
+	}
+	decl := m[1] + m[2]
+	if len(keep) > 0 {
+		decl += ": " + strings.Join(keep, ", ")
+	}
+	return decl + " {" + m[5]
+}
+
+// ensureObservationImport adds `import Observation` unless SwiftUI or
+// Observation is already imported
+func ensureObservationImport(lines []string) []string {
+	last := -1
+	for i, l := range lines {
+		if m := importLine.FindStringSubmatch(l); m != nil {
+			if m[1] == "SwiftUI" || m[1] == "Observation" {
+				return lines
+			}
+			last = i
+		}
+	}
+	return insert(lines, last+1, "import Observation")
+}
+
+// migrateObservers rewrites the property wrappers observing migrated
+// classes in one file
+func migrateObservers(path string, lines []string, migrated map[string]bool) ([]string, []string, bool) {
+	var notes []string
+	vars := map[string]bool{}
+	changed := false
+	out := append([]string(nil), lines...)
+	for i, l := range out {
+		if m := observedObject.FindStringSubmatch(l); m != nil && migrated[m[4]] {
+			wrapper := ""
+			if usesBinding(enclosingType(lines, i), m[3]) {
+				wrapper = "@Bindable "
+			}
+			out[i] = m[1] + wrapper + m[2] + "var " + m[3] + ": " + m[4] + m[5]
+			vars[m[3]] = true
+			changed = true
+			continue
+		}
+		if m := stateObject.FindStringSubmatch(l); m != nil && (migrated[m[4]] || migrated[m[5]]) {
+			out[i] = m[1] + "@State " + m[2]
+			vars[m[3]] = true
+			changed = true
+			continue
+		}
+		if m := environmentObject.FindStringSubmatch(l); m != nil && migrated[m[4]] {
+			out[i] = m[1] + "@Environment(" + m[4] + ".self) " + m[2] + "var " + m[3] + ": " + m[4] + m[5]
+			if usesBinding(enclosingType(lines, i), m[3]) {
+				notes = append(notes, fmt.Sprintf("%s: $%s bindings need `@Bindable var %s = %s` in body", path, m[3], m[3], m[3]))
+			}
+			vars[m[3]] = true
+			changed = true
+		}
+	}
+	for i, l := range out {
+		out[i] = envModifier.ReplaceAllStringFunc(l, func(s string) string {
+			m := envModifier.FindStringSubmatch(s)
+			if (m[2] == "" && vars[m[1]]) || (m[2] != "" && migrated[m[1]]) {
+				changed = true
+				return ".environment(" + m[1] + m[2] + ")"
+			}
+			return s
+		})
+		for name := range vars {
+			if strings.Contains(out[i], "_"+name+" = StateObject(") {
+				out[i] = strings.Replace(out[i], "_"+name+" = StateObject(", "_"+name+" = State(", 1)
+			}
+		}
+	}
+	return out, notes, changed
+}
+
+// enclosingType returns the text of the type declaration holding line i,
+// or the whole file if there is none
+func enclosingType(lines []string, i int) string {
+	for start := i; start >= 0; start-- {
+		if !typeDecl.MatchString(lines[start]) {
+			continue
+		}
+		if end := blockEnd(lines, start); end >= i {
+			return strings.Join(lines[start:end+1], "\n")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// usesBinding reports whether a file projects bindings from a property
+func usesBinding(text, name string) bool {
+	return regexp.MustCompile(`\$` + name + `\.`).MatchString(text)
+}
+
+func insert(lines []string, at int, line string) []string {
+	lines = append(lines, "")
+	copy(lines[at+1:], lines[at:])
+	lines[at] = line
+	return lines
+}
@@ -0,0 +1,382 @@
+// Package patch turns mechanical fixes into unified diffs against the
+// project's own Swift sources: ObservableObject + @Published to
+// @Observable (with the views observing those classes), and a
+// Timer.publish + onReceive clock to TimelineView. Fixes that need
+// judgement are left to the generic code samples in suggestions.
+package patch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrNoMechanicalFix is returned when none of the fixes can be generated
+var ErrNoMechanicalFix = errors.New("no mechanical fix for this issue")
+
+// ErrStale is returned by Apply when a file changed since the patch was made
+var ErrStale = errors.New("file changed since the patch was generated")
+
+// FilePatch is the change to one source file
+type FilePatch struct {
+	Path   string `json:"path"` // relative to the source root
+	Diff   string `json:"diff"` // unified diff
+	before string
+	after  string
+}
+
+// Patch is the source change of one mechanical fix
+type Patch struct {
+	FixID string      `json:"fix_id"`
+	Files []FilePatch `json:"files"`
+	Notes []string    `json:"notes,omitempty"` // what was skipped and why, manual follow-ups
+}
+
+// transform rewrites files of a workspace, returning notes for the user
+type transform func(w *workspace, files []string) []string
+
+// transforms maps the suggestions fix IDs that are mechanical to their
+// source rewrite
+var transforms = map[string]transform{
+	"observable-macro": migrateObservable,
+	"timeline-view":    timerToTimelineView,
+}
+
+// Mechanical reports whether a fix ID has a source rewrite
+func Mechanical(fixID string) bool {
+	_, ok := transforms[fixID]
+	return ok
+}
+
+// Generate builds the patch of the first mechanical fix in fixIDs that
+// changes anything, starting from the files correlated with the issue
+// (paths relative to root). If no fix changes anything, the patch of the
+// first mechanical one is returned with no files and notes saying why.
+func Generate(root string, fixIDs []string, files []string) (*Patch, error) {
+	var fallback *Patch
+	for _, id := range fixIDs {
+		t, ok := transforms[id]
+		if !ok {
+			continue
+		}
+		w := newWorkspace(root)
+		var candidates []string
+		for _, f := range files {
+			if rel, ok := w.rel(f); ok {
+				candidates = append(candidates, rel)
+			}
+		}
+		notes := t(w, dedupe(candidates))
+		p := &Patch{FixID: id, Files: w.changes(), Notes: notes}
+		if len(p.Files) > 0 {
+			return p, nil
+		}
+		if fallback == nil {
+			fallback = p
+		}
+	}
+	if fallback == nil {
+		return nil, ErrNoMechanicalFix
+	}
+	return fallback, nil
+}
+
+// Diff returns the unified diff of all files
+func (p *Patch) Diff() string {
+	var b strings.Builder
+	for _, f := range p.Files {
+		b.WriteString(f.Diff)
+	}
+	return b.String()
+}
+
+// Apply writes the patched files under root. Each original is first copied
+// to path+backupSuffix, or path+backupSuffix+".N" when an earlier patch
+// left that backup, so the first backup always holds the true original.
+// Nothing is written if any file changed since the patch was generated,
+// and the patched content replaces each file by rename, so a failure
+// leaves the sources as they were. It returns the backup paths.
+func (p *Patch) Apply(root, backupSuffix string) ([]string, error) {
+	if backupSuffix == "" {
+		return nil, fmt.Errorf("a backup suffix is required")
+	}
+	for _, f := range p.Files {
+		cur, err := os.ReadFile(filepath.Join(root, f.Path))
+		if err != nil {
+			return nil, err
+		}
+		if string(cur) != f.before {
+			return nil, fmt.Errorf("%s: %w", f.Path, ErrStale)
+		}
+	}
+
+	// Write every backup and patched temp file before touching a source
+	var backups, temps []string
+	perms := make([]fs.FileMode, len(p.Files))
+	cleanup := func() {
+		for _, name := range append(backups, temps...) {
+			os.Remove(name)
+		}
+	}
+	for i, f := range p.Files {
+		path := filepath.Join(root, f.Path)
+		info, err := os.Stat(path)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		perms[i] = info.Mode().Perm()
+		backup, err := writeBackup(path+backupSuffix, f.before, perms[i])
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		backups = append(backups, backup)
+		temp, err := writeTemp(path, f.after, perms[i])
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		temps = append(temps, temp)
+	}
+
+	for i, f := range p.Files {
+		path := filepath.Join(root, f.Path)
+		if err := rename(temps[i], path); err != nil {
+			for _, temp := range temps[i:] {
+				os.Remove(temp)
+			}
+			// Put back the files already replaced, with their modes; the
+			// backup of one that cannot be restored is kept
+			errs := []error{err}
+			for j, g := range p.Files[:i] {
+				if err := replace(filepath.Join(root, g.Path), g.before, perms[j]); err != nil {
+					errs = append(errs, fmt.Errorf("restoring %s, original kept in %s: %w", g.Path, backups[j], err))
+					backups[j] = ""
+				}
+			}
+			for _, backup := range backups {
+				if backup != "" {
+					os.Remove(backup)
+				}
+			}
+			return nil, errors.Join(errs...)
+		}
+	}
+	return backups, nil
+}
+
+// rename is os.Rename, replaced by tests to make a write fail
+var rename = os.Rename
+
+// replace writes content over path by rename, with the given mode
+func replace(path, content string, perm fs.FileMode) error {
+	temp, err := writeTemp(path, content, perm)
+	if err != nil {
+		return err
+	}
+	if err := rename(temp, path); err != nil {
+		os.Remove(temp)
+		return err
+	}
+	return nil
+}
+
+// writeBackup creates the first of name, name.1, name.2, ... that does not
+// exist yet
+func writeBackup(name, content string, perm fs.FileMode) (string, error) {
+	for n := 0; ; n++ {
+		path := name
+		if n > 0 {
+			path = fmt.Sprintf("%s.%d", name, n)
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = f.WriteString(content)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(path)
+			return "", err
+		}
+		return path, nil
+	}
+}
+
+// writeTemp writes content to a new file next to path, to be renamed over it
+func writeTemp(path, content string, perm fs.FileMode) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(content)
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// workspace holds the original and rewritten content of the files a
+// transform touches
+type workspace struct {
+	root     string
+	original map[string]string
+	current  map[string]string
+	all      []string // every Swift file under root, loaded lazily
+}
+
+func newWorkspace(root string) *workspace {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return &workspace{root: root, original: map[string]string{}, current: map[string]string{}}
+}
+
+// rel returns path relative to the root, rejecting paths outside it
+func (w *workspace) rel(path string) (string, bool) {
+	if filepath.IsAbs(path) {
+		r, err := filepath.Rel(w.root, path)
+		if err != nil {
+			return "", false
+		}
+		path = r
+	}
+	path = filepath.Clean(path)
+	if path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(path), true
+}
+
+// lines returns the current lines of a file
+func (w *workspace) lines(path string) ([]string, error) {
+	if s, ok := w.current[path]; ok {
+		return strings.Split(s, "\n"), nil
+	}
+	data, err := os.ReadFile(filepath.Join(w.root, filepath.FromSlash(path)))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return nil, fmt.Errorf("%s is not a text file", path)
+	}
+	w.original[path] = string(data)
+	w.current[path] = string(data)
+	return strings.Split(string(data), "\n"), nil
+}
+
+func (w *workspace) set(path string, lines []string) {
+	w.current[path] = strings.Join(lines, "\n")
+}
+
+// swiftFiles lists the Swift files under the root, skipping build output
+// and dependencies
+func (w *workspace) swiftFiles() []string {
+	if w.all != nil {
+		return w.all
+	}
+	w.all = []string{}
+	filepath.WalkDir(w.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != w.root && (strings.HasPrefix(name, ".") || name == "Pods" || name == "DerivedData" || name == "Carthage" || name == "build") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".swift") {
+			if rel, err := filepath.Rel(w.root, path); err == nil {
+				w.all = append(w.all, filepath.ToSlash(rel))
+			}
+		}
+		return nil
+	})
+	sort.Strings(w.all)
+	return w.all
+}
+
+// changes returns the diffs of changed files, ordered by path
+func (w *workspace) changes() []FilePatch {
+	paths := make([]string, 0, len(w.current))
+	for p := range w.current {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	files := []FilePatch{}
+	for _, p := range paths {
+		before, after := w.original[p], w.current[p]
+		if before == after {
+			continue
+		}
+		files = append(files, FilePatch{Path: p, Diff: Unified(p, before, after), before: before, after: after})
+	}
+	return files
+}
+
+func dedupe(strs []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range strs {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// blockEnd returns the index of the line closing the block opened on line
+// start, or -1. Braces in string literals and line comments are ignored.
+func blockEnd(lines []string, start int) int {
+	depth := 0
+	opened := false
+	for i := start; i < len(lines); i++ {
+		inString := false
+		line := lines[i]
+		for j := 0; j < len(line); j++ {
+			c := line[j]
+			switch {
+			case inString:
+				if c == '\\' {
+					j++
+				} else if c == '"' {
+					inString = false
+				}
+			case c == '"':
+				inString = true
+			case c == '/' && j+1 < len(line) && line[j+1] == '/':
+				j = len(line)
+			case c == '{':
+				depth++
+				opened = true
+			case c == '}':
+				depth--
+				if opened && depth == 0 {
+					return i
+				}
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,327 @@
+package patch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+const viewModelSwift = `import Foundation
+import Combine
+
+final class CartModel: ObservableObject, Identifiable {
+    @Published var items: [String] = []
+    @Published private(set) var total = 0
+    let id = UUID()
+}
+`
+
+const cartViewSwift = `import SwiftUI
+
+struct CartView: View {
+    @ObservedObject var model: CartModel
+
+    var body: some View {
+        List(model.items, id: \.self) { Text($0) }
+    }
+}
+
+struct CartEditor: View {
+    @ObservedObject var model: CartModel
+
+    var body: some View {
+        TextField("Total", value: $model.total, format: .number)
+    }
+}
+`
+
+const appSwift = `import SwiftUI
+
+@main
+struct ShopApp: App {
+    @StateObject private var cart = CartModel()
+
+    var body: some Scene {
+        WindowGroup {
+            RootView()
+                .environmentObject(cart)
+        }
+    }
+}
+
+struct RootView: View {
+    @EnvironmentObject var cart: CartModel
+
+    var body: some View {
+        CartView(model: cart)
+    }
+}
+`
+
+func TestGenerate_Observable(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"Shop/Models/CartModel.swift": viewModelSwift,
+		"Shop/Views/CartView.swift":   cartViewSwift,
+		"Shop/ShopApp.swift":          appSwift,
+	})
+
+	// The issue correlates with the view; the class is found through it
+	p, err := Generate(root, []string{"equatable-view", "observable-macro"}, []string{"Shop/Views/CartView.swift"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.FixID != "observable-macro" {
+		t.Errorf("expected the observable fix, got %s", p.FixID)
+	}
+	var paths []string
+	for _, f := range p.Files {
+		paths = append(paths, f.Path)
+	}
+	if got := strings.Join(paths, ","); got != "Shop/Models/CartModel.swift,Shop/ShopApp.swift,Shop/Views/CartView.swift" {
+		t.Fatalf("unexpected files %s", got)
+	}
+
+	diff := p.Diff()
+	for _, want := range []string{
+		"--- a/Shop/Models/CartModel.swift\n+++ b/Shop/Models/CartModel.swift\n",
+		"+import Observation\n",
+		"-final class CartModel: ObservableObject, Identifiable {\n",
+		"+@Observable\n+final class CartModel: Identifiable {\n",
+		"-    @Published var items: [String] = []\n",
+		"+    var items: [String] = []\n",
+		"+    private(set) var total = 0\n",
+		"-    @ObservedObject var model: CartModel\n+    var model: CartModel\n",
+		"-    @ObservedObject var model: CartModel\n+    @Bindable var model: CartModel\n",
+		"+    @State private var cart = CartModel()\n",
+		"+                .environment(cart)\n",
+		"+    @Environment(CartModel.self) var cart: CartModel\n",
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff missing %q:\n%s", want, diff)
+		}
+	}
+
+	backups, err := p.Apply(root, ".orig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 3 {
+		t.Errorf("expected 3 backups, got %v", backups)
+	}
+	orig, _ := os.ReadFile(filepath.Join(root, "Shop/Models/CartModel.swift.orig"))
+	if string(orig) != viewModelSwift {
+		t.Error("backup does not hold the original")
+	}
+	patched, _ := os.ReadFile(filepath.Join(root, "Shop/Models/CartModel.swift"))
+	if strings.Contains(string(patched), "@Published") || !strings.Contains(string(patched), "@Observable\nfinal class") {
+		t.Errorf("unexpected patched file:\n%s", patched)
+	}
+
+	// The same patch cannot be applied twice
+	if _, err := p.Apply(root, ".orig"); !errors.Is(err, ErrStale) {
+		t.Errorf("expected ErrStale, got %v", err)
+	}
+}
+
+func TestApply_KeepsFirstBackup(t *testing.T) {
+	root := writeFiles(t, map[string]string{"A.swift": "one\n", "B.swift": "b\n"})
+	read := func(name string) string {
+		data, _ := os.ReadFile(filepath.Join(root, name))
+		return string(data)
+	}
+
+	first := &Patch{Files: []FilePatch{{Path: "A.swift", before: "one\n", after: "two\n"}}}
+	if _, err := first.Apply(root, ".orig"); err != nil {
+		t.Fatal(err)
+	}
+	second := &Patch{Files: []FilePatch{{Path: "A.swift", before: "two\n", after: "three\n"}}}
+	backups, err := second.Apply(root, ".orig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 || backups[0] != filepath.Join(root, "A.swift.orig.1") {
+		t.Errorf("expected a numbered backup, got %v", backups)
+	}
+	if read("A.swift.orig") != "one\n" || read("A.swift.orig.1") != "two\n" || read("A.swift") != "three\n" {
+		t.Errorf("unexpected files: orig %q, orig.1 %q, patched %q", read("A.swift.orig"), read("A.swift.orig.1"), read("A.swift"))
+	}
+
+	// A backup that cannot be written leaves every source untouched
+	both := &Patch{Files: []FilePatch{
+		{Path: "B.swift", before: "b\n", after: "B\n"},
+		{Path: "A.swift", before: "three\n", after: "four\n"},
+	}}
+	if _, err := both.Apply(root, "/missing"); err == nil {
+		t.Fatal("expected the backup to fail")
+	}
+	if read("B.swift") != "b\n" || read("A.swift") != "three\n" {
+		t.Errorf("a failed apply changed the sources: %q %q", read("B.swift"), read("A.swift"))
+	}
+	entries, _ := os.ReadDir(root)
+	if len(entries) != 4 {
+		t.Errorf("a failed apply left files behind: %v", entries)
+	}
+}
+
+func TestApply_RollsBack(t *testing.T) {
+	defer func(orig func(string, string) error) { rename = orig }(rename)
+
+	// renames after the first fail: the second file is not replaced and,
+	// with restore set, the first is put back
+	setup := func(restore bool) (string, *Patch) {
+		root := writeFiles(t, map[string]string{"A.swift": "a\n", "B.swift": "b\n"})
+		if err := os.Chmod(filepath.Join(root, "A.swift"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		calls := 0
+		rename = func(from, to string) error {
+			calls++
+			if calls == 1 || (restore && calls == 3) {
+				return os.Rename(from, to)
+			}
+			return errors.New("disk full")
+		}
+		return root, &Patch{Files: []FilePatch{
+			{Path: "A.swift", before: "a\n", after: "A\n"},
+			{Path: "B.swift", before: "b\n", after: "B\n"},
+		}}
+	}
+
+	root, p := setup(true)
+	if _, err := p.Apply(root, ".orig"); err == nil || strings.Contains(err.Error(), "restoring") {
+		t.Fatalf("expected only the rename error, got %v", err)
+	}
+	info, err := os.Stat(filepath.Join(root, "A.swift"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "A.swift")); string(data) != "a\n" || info.Mode().Perm() != 0o600 {
+		t.Errorf("A.swift not restored: %q, mode %v", data, info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(root); len(entries) != 2 {
+		t.Errorf("a rolled back apply left files behind: %v", entries)
+	}
+
+	root, p = setup(false)
+	_, err = p.Apply(root, ".orig")
+	if err == nil || !strings.Contains(err.Error(), "restoring A.swift") {
+		t.Fatalf("expected the failed restore to be reported, got %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "A.swift.orig")); string(data) != "a\n" {
+		t.Errorf("the backup of an unrestored file should be kept, got %q", data)
+	}
+}
+
+func TestGenerate_ObservableCombine(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"Feed.swift": `import SwiftUI
+
+class FeedModel: ObservableObject {
+    @Published var query = ""
+    init() {
+        $query.sink { print($0) }
+    }
+}
+`,
+	})
+	p, err := Generate(root, []string{"observable-macro"}, []string{"Feed.swift"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Files) != 0 || len(p.Notes) != 1 || !strings.Contains(p.Notes[0], "$query publisher") {
+		t.Errorf("expected the class to be skipped with a note, got %+v", p)
+	}
+}
+
+const clockSwift = `import SwiftUI
+
+struct ClockView: View {
+    @State private var now = Date()
+    private let ticker = Timer.publish(every: 0.5, on: .main, in: .common).autoconnect()
+
+    var body: some View {
+        VStack {
+            Text(now, style: .time)
+            Text("Updated \(now.formatted())")
+        }
+        .onReceive(ticker) { now = $0 }
+    }
+}
+`
+
+func TestGenerate_TimelineView(t *testing.T) {
+	root := writeFiles(t, map[string]string{"Clock.swift": clockSwift})
+	p, err := Generate(root, []string{"timeline-view", "limit-timer-scope"}, []string{filepath.Join(root, "Clock.swift")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Files) != 1 {
+		t.Fatalf("expected one file, notes: %v", p.Notes)
+	}
+	want := `import SwiftUI
+
+struct ClockView: View {
+
+    var body: some View {
+        TimelineView(.periodic(from: .now, by: 0.5)) { context in
+            VStack {
+                Text(context.date, style: .time)
+                Text("Updated \(context.date.formatted())")
+            }
+        }
+    }
+}
+`
+	if p.Files[0].after != want {
+		t.Errorf("got:\n%s\nwant:\n%s", p.Files[0].after, want)
+	}
+}
+
+func TestGenerate_TimelineViewNotMechanical(t *testing.T) {
+	src := strings.Replace(clockSwift, "{ now = $0 }", "{ now = $0; refresh() }", 1)
+	root := writeFiles(t, map[string]string{"Clock.swift": src})
+	p, err := Generate(root, []string{"timeline-view"}, []string{"Clock.swift"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Files) != 0 || len(p.Notes) == 0 || !strings.Contains(p.Notes[0], "rewrite by hand") {
+		t.Errorf("expected a note and no change, got %+v", p)
+	}
+}
+
+func TestGenerate_NoMechanicalFix(t *testing.T) {
+	if _, err := Generate(t.TempDir(), []string{"debounce", "throttle"}, nil); !errors.Is(err, ErrNoMechanicalFix) {
+		t.Errorf("expected ErrNoMechanicalFix, got %v", err)
+	}
+	if Mechanical("debounce") || !Mechanical("timeline-view") {
+		t.Error("unexpected Mechanical result")
+	}
+}
+
+func TestGenerate_OutsideRoot(t *testing.T) {
+	root := writeFiles(t, map[string]string{"Clock.swift": clockSwift})
+	p, err := Generate(filepath.Join(root, "sub"), []string{"timeline-view"}, []string{"../Clock.swift"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Files) != 0 {
+		t.Error("files outside the root must not be patched")
+	}
+}
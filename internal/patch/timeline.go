@@ -0,0 +1,129 @@
+package patch
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	timerDecl = regexp.MustCompile(`^\s*(?:(?:private|fileprivate)\s+)?(?:let|var)\s+(\w+)\s*=\s*Timer\.publish\(\s*every:\s*([\d.]+)[^)]*\)\.autoconnect\(\)\s*$`)
+	viewDecl  = regexp.MustCompile(`^\s*(?:(?:public|internal|private|fileprivate)\s+)?struct\s+(\w+)\s*:[^{]*\bView\b[^{]*\{`)
+	bodyDecl  = regexp.MustCompile(`^(\s*)var\s+body\s*:\s*some\s+View\s*\{\s*$`)
+)
+
+// timerToTimelineView replaces a view's clock, a Timer.publish publisher
+// whose ticks are stored into a Date @State by onReceive, with a
+// TimelineView that redraws on the same schedule. Only the simple form is
+// rewritten: the timer drives nothing else and the date is only read in
+// body.
+func timerToTimelineView(w *workspace, files []string) []string {
+	var notes []string
+	found := false
+	for _, path := range files {
+		lines, err := w.lines(path)
+		if err != nil {
+			notes = append(notes, err.Error())
+			continue
+		}
+		for i := 0; i < len(lines); i++ {
+			m := timerDecl.FindStringSubmatch(lines[i])
+			if m == nil {
+				continue
+			}
+			found = true
+			out, note := rewriteTimer(lines, i, m[1], m[2])
+			if note != "" {
+				notes = append(notes, fmt.Sprintf("%s:%d: %s", path, i+1, note))
+				continue
+			}
+			lines = out
+			w.set(path, lines)
+			i = -1 // line numbers moved; look for another timer from the top
+		}
+	}
+	if !found && len(notes) == 0 {
+		notes = append(notes, "no Timer.publish clock found in the correlated files")
+	}
+	return dedupe(notes) // a timer left alone is seen again after each rewrite
+}
+
+// rewriteTimer rewrites the view holding the timer declared on line decl,
+// or explains why it cannot
+func rewriteTimer(lines []string, decl int, timer, interval string) ([]string, string) {
+	start := -1
+	for i := decl; i >= 0; i-- {
+		if viewDecl.MatchString(lines[i]) {
+			start = i
+			break
+		}
+	}
+	end := -1
+	if start >= 0 {
+		end = blockEnd(lines, start)
+	}
+	if start < 0 || end < decl {
+		return nil, "timer is not a property of a View struct"
+	}
+
+	timerRef := regexp.MustCompile(`\b` + regexp.QuoteMeta(timer) + `\b`)
+	onReceive := regexp.MustCompile(`^\s*\.onReceive\(\s*` + regexp.QuoteMeta(timer) + `\s*\)\s*\{\s*(?:(\w+)\s+in\s+)?(\w+)\s*=\s*(\$0|\w+)\s*\}\s*$`)
+	receive, state := -1, ""
+	for i := start; i <= end; i++ {
+		if i == decl || !timerRef.MatchString(lines[i]) {
+			continue
+		}
+		m := onReceive.FindStringSubmatch(lines[i])
+		if m == nil || receive >= 0 || (m[1] == "" && m[3] != "$0") || (m[1] != "" && m[3] != m[1]) {
+			return nil, fmt.Sprintf("%s is used for more than storing the date; rewrite by hand", timer)
+		}
+		receive, state = i, m[2]
+	}
+	if receive < 0 {
+		return nil, fmt.Sprintf("no `.onReceive(%s) { date = $0 }` found", timer)
+	}
+
+	stateDecl := regexp.MustCompile(`^\s*@State\s+(?:(?:private|fileprivate)\s+)?var\s+` + regexp.QuoteMeta(state) + `\s*(?::\s*Date\s*)?=\s*(?:Date\(\)|Date\.now|\.now)\s*$`)
+	stateRef := regexp.MustCompile(`\b` + regexp.QuoteMeta(state) + `\b`)
+	body, bodyEnd, stateLine := -1, -1, -1
+	for i := start; i <= end; i++ {
+		if stateDecl.MatchString(lines[i]) {
+			stateLine = i
+		}
+		if body < 0 && bodyDecl.MatchString(lines[i]) {
+			body, bodyEnd = i, blockEnd(lines, i)
+		}
+	}
+	if stateLine < 0 {
+		return nil, fmt.Sprintf("%s is not a `@State var %s = Date()`", state, state)
+	}
+	if body < 0 || bodyEnd < 0 || receive < body || receive > bodyEnd {
+		return nil, "onReceive is not in body"
+	}
+	for i := start; i <= end; i++ {
+		if (i < body || i > bodyEnd) && i != stateLine && stateRef.MatchString(lines[i]) {
+			return nil, fmt.Sprintf("%s is used outside body; rewrite by hand", state)
+		}
+	}
+
+	indent := bodyDecl.FindStringSubmatch(lines[body])[1] + "    "
+	out := make([]string, 0, len(lines)+2)
+	for i, l := range lines {
+		switch {
+		case i == decl || i == stateLine || i == receive:
+			continue
+		case i == body:
+			out = append(out, l, indent+fmt.Sprintf("TimelineView(.periodic(from: .now, by: %s)) { context in", interval))
+		case i == bodyEnd:
+			out = append(out, indent+"}", l)
+		case i > body && i < bodyEnd:
+			if strings.TrimSpace(l) != "" {
+				l = "    " + stateRef.ReplaceAllString(l, "context.date")
+			}
+			out = append(out, l)
+		default:
+			out = append(out, l)
+		}
+	}
+	return out, ""
+}
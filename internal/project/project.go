@@ -0,0 +1,170 @@
+// Package project reads the deployment target and Swift version of a
+// Swift project from its Package.swift and Xcode project files, so fixes
+// needing a newer OS or compiler can be ranked accordingly.
+package project
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Target is the oldest platform and the Swift version a project supports
+type Target struct {
+	IOS     string   `json:"ios,omitempty"`     // minimum iOS version, e.g. "15.0"
+	Swift   string   `json:"swift,omitempty"`   // Swift tools version, e.g. "5.9"
+	Sources []string `json:"sources,omitempty"` // files (relative to the source root) or "flag"
+}
+
+// Known reports whether anything was detected or given
+func (t Target) Known() bool {
+	return t.IOS != "" || t.Swift != ""
+}
+
+func (t Target) String() string {
+	var parts []string
+	if t.IOS != "" {
+		parts = append(parts, "iOS "+t.IOS)
+	}
+	if t.Swift != "" {
+		parts = append(parts, "Swift "+t.Swift)
+	}
+	if len(parts) == 0 {
+		return "unknown"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Supports reports whether a fix needing minIOS and swift (either may be
+// empty, swift may end in "+") can be used, and if not, why. Requirements
+// on a version the target does not know are assumed to be met.
+func (t Target) Supports(minIOS, swift string) (bool, string) {
+	if minIOS != "" && t.IOS != "" && Compare(t.IOS, minIOS) < 0 {
+		return false, fmt.Sprintf("needs iOS %s, deployment target is iOS %s", minIOS, t.IOS)
+	}
+	if swift != "" && t.Swift != "" && Compare(t.Swift, swift) < 0 {
+		return false, fmt.Sprintf("needs Swift %s, project uses Swift %s", strings.TrimSuffix(swift, "+"), t.Swift)
+	}
+	return true, ""
+}
+
+// Compare compares dotted versions numerically ("15" == "15.0" < "15.4");
+// a trailing "+" is ignored
+func Compare(a, b string) int {
+	pa := strings.Split(strings.TrimSuffix(a, "+"), ".")
+	pb := strings.Split(strings.TrimSuffix(b, "+"), ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			y, _ = strconv.Atoi(pb[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+var (
+	reToolsVersion = regexp.MustCompile(`^//\s*swift-tools-version\s*:\s*([\d.]+)`)
+	reIOSPlatform  = regexp.MustCompile(`\.iOS\(\s*(?:\.v(\d+)(?:_(\d+))?|"([\d.]+)")\s*\)`)
+	reIOSSetting   = regexp.MustCompile(`\bIPHONEOS_DEPLOYMENT_TARGET\s*=\s*"?([\d.]+)"?\s*;`)
+)
+
+// Detect scans root for Package.swift and *.xcodeproj/project.pbxproj
+// files (skipping dependencies and build output). The lowest iOS
+// deployment target and Swift tools version found win, since the project
+// has to build for all of them.
+func Detect(root string) (Target, error) {
+	var t Target
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || name == "Pods" || name == "Carthage" || name == "DerivedData" || name == "build") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		var ios, swift string
+		switch {
+		case name == "Package.swift":
+			ios, swift = readPackage(path)
+		case name == "project.pbxproj" && strings.HasSuffix(filepath.Dir(path), ".xcodeproj"):
+			ios = readPBXProj(path)
+		default:
+			return nil
+		}
+		if ios == "" && swift == "" {
+			return nil
+		}
+		if ios != "" && (t.IOS == "" || Compare(ios, t.IOS) < 0) {
+			t.IOS = ios
+		}
+		if swift != "" && (t.Swift == "" || Compare(swift, t.Swift) < 0) {
+			t.Swift = swift
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = path
+		}
+		t.Sources = append(t.Sources, filepath.ToSlash(rel))
+		return nil
+	})
+	return t, err
+}
+
+// readPackage returns the iOS platform and tools version of a manifest
+func readPackage(path string) (ios, swift string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", ""
+	}
+	text := string(data)
+	if first, _, _ := strings.Cut(text, "\n"); first != "" {
+		if m := reToolsVersion.FindStringSubmatch(strings.TrimSpace(first)); m != nil {
+			swift = m[1]
+		}
+	}
+	if m := reIOSPlatform.FindStringSubmatch(text); m != nil {
+		switch {
+		case m[3] != "":
+			ios = m[3]
+		case m[2] != "":
+			ios = m[1] + "." + m[2]
+		default:
+			ios = m[1] + ".0"
+		}
+	}
+	return ios, swift
+}
+
+// readPBXProj returns the lowest IPHONEOS_DEPLOYMENT_TARGET of a project's
+// build configurations. SWIFT_VERSION is the language mode, not the
+// compiler version that decides whether macros are available, so it is not
+// read.
+func readPBXProj(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var lowest string
+	for _, m := range reIOSSetting.FindAllStringSubmatch(string(data), -1) {
+		if lowest == "" || Compare(m[1], lowest) < 0 {
+			lowest = m[1]
+		}
+	}
+	return lowest
+}
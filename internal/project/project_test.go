@@ -0,0 +1,88 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	root := t.TempDir()
+	write(t, filepath.Join(root, "Package.swift"), `// swift-tools-version:5.9
+import PackageDescription
+
+let package = Package(
+    name: "Shop",
+    platforms: [.macOS(.v13), .iOS(.v16)],
+    targets: [.target(name: "Shop")]
+)
+`)
+	write(t, filepath.Join(root, "App", "Shop.xcodeproj", "project.pbxproj"), `
+		buildSettings = {
+			IPHONEOS_DEPLOYMENT_TARGET = 17.0;
+			SWIFT_VERSION = 5.0;
+		};
+		buildSettings = {
+			IPHONEOS_DEPLOYMENT_TARGET = 15.4;
+		};
+`)
+	// Dependencies are not the project's target
+	write(t, filepath.Join(root, "Pods", "Old.xcodeproj", "project.pbxproj"), "IPHONEOS_DEPLOYMENT_TARGET = 9.0;")
+	write(t, filepath.Join(root, ".build", "checkouts", "dep", "Package.swift"), "// swift-tools-version:5.3\n.iOS(.v12)")
+
+	got, err := Detect(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Target{IOS: "15.4", Swift: "5.9", Sources: []string{"App/Shop.xcodeproj/project.pbxproj", "Package.swift"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestReadPackageForms(t *testing.T) {
+	for src, want := range map[string]string{
+		`platforms: [.iOS(.v15)]`:        "15.0",
+		`platforms: [.iOS(.v15_4)]`:      "15.4",
+		`platforms: [.iOS("16.1")]`:      "16.1",
+		`platforms: [.macOS(.v14)]`:      "",
+		`// no platforms, defaults only`: "",
+	} {
+		path := filepath.Join(t.TempDir(), "Package.swift")
+		write(t, path, "// swift-tools-version: 5.10\n"+src)
+		ios, swift := readPackage(path)
+		if ios != want || swift != "5.10" {
+			t.Errorf("%s: got iOS %q Swift %q", src, ios, swift)
+		}
+	}
+}
+
+func TestSupports(t *testing.T) {
+	target := Target{IOS: "15.0", Swift: "5.9"}
+	if ok, reason := target.Supports("17.0", "5.9+"); ok || reason != "needs iOS 17.0, deployment target is iOS 15.0" {
+		t.Errorf("got %v %q", ok, reason)
+	}
+	if ok, _ := target.Supports("15", "5.5+"); !ok {
+		t.Error("15 should satisfy 15.0")
+	}
+	if ok, reason := (Target{Swift: "5.7"}).Supports("17.0", "5.9+"); ok || reason != "needs Swift 5.9, project uses Swift 5.7" {
+		t.Errorf("got %v %q", ok, reason)
+	}
+	if ok, _ := (Target{}).Supports("17.0", "6.0"); !ok {
+		t.Error("an unknown target supports everything")
+	}
+	if Compare("5.10", "5.9") != 1 || Compare("17", "17.0.0") != 0 || Compare("15.4", "16") != -1 {
+		t.Error("Compare is not numeric")
+	}
+}
@@ -0,0 +1,408 @@
+// Package query answers reachability questions over cause-effect graphs.
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/analyze"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+// Direction selects which way a traversal follows edges
+type Direction string
+
+const (
+	Downstream Direction = "down" // cause → state → view
+	Upstream   Direction = "up"   // view → state → cause
+)
+
+// Query describes a traversal or path search
+type Query struct {
+	// Traversal: start from nodes matching Node and walk in Direction
+	Node      string
+	Direction Direction
+
+	// Path search: used instead of a traversal when both are set
+	From     string
+	To       string
+	AllPaths bool // all simple paths instead of only the shortest
+
+	// Filters
+	Types    []graph.NodeType // only report these node types (empty = all)
+	MinCount int              // only report nodes with at least this count
+	MaxDepth int              // limit traversal/path length in edges (0 = unlimited)
+}
+
+// NodeRef is a node as it appears in query output
+type NodeRef struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Type  string `json:"type"`
+	Count int    `json:"count,omitempty"`
+}
+
+// TreeNode is one entry of a traversal tree
+type TreeNode struct {
+	NodeRef
+	Repeat   bool        `json:"repeat,omitempty"` // already expanded elsewhere in the tree
+	Children []*TreeNode `json:"children,omitempty"`
+}
+
+// Result holds the answer to a query
+type Result struct {
+	Mode      string       `json:"mode"` // upstream, downstream, shortest_path, all_paths
+	Trees     []*TreeNode  `json:"trees,omitempty"`
+	Paths     [][]NodeRef  `json:"paths,omitempty"`
+	Subgraph  *graph.Graph `json:"-"`
+	nodeOrder []string
+}
+
+// Match returns the nodes matching a pattern. An exact ID match wins;
+// otherwise the pattern is a case-insensitive regular expression on labels.
+// IDs and raw labels of collapsed instances resolve to the collapsed node.
+func Match(g *graph.Graph, pattern string) ([]*graph.Node, error) {
+	if n, ok := g.Nodes[pattern]; ok {
+		return []*graph.Node{n}, nil
+	}
+	for _, n := range g.SortedNodes() {
+		for _, inst := range n.Instances {
+			if inst.ID == pattern {
+				return []*graph.Node{n}, nil
+			}
+		}
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	var out []*graph.Node
+	for _, n := range g.SortedNodes() {
+		if re.MatchString(n.Label) || matchInstance(re, n) {
+			out = append(out, n)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no node matches %q", pattern)
+	}
+	return out, nil
+}
+
+func matchInstance(re *regexp.Regexp, n *graph.Node) bool {
+	for _, inst := range n.Instances {
+		if re.MatchString(inst.Label) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run executes a query against a graph
+func Run(g *graph.Graph, q Query) (*Result, error) {
+	if q.From != "" || q.To != "" {
+		if q.From == "" || q.To == "" {
+			return nil, fmt.Errorf("path queries need both a from and a to node")
+		}
+		return runPaths(g, q)
+	}
+	if q.Node == "" {
+		return nil, fmt.Errorf("a node pattern is required")
+	}
+	return runTraversal(g, q)
+}
+
+func (q Query) keep(n *graph.Node) bool {
+	if n == nil {
+		return false
+	}
+	if q.MinCount > 0 && n.Count < q.MinCount {
+		return false
+	}
+	if len(q.Types) == 0 {
+		return true
+	}
+	for _, t := range q.Types {
+		if n.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+func runTraversal(g *graph.Graph, q Query) (*Result, error) {
+	roots, err := Match(g, q.Node)
+	if err != nil {
+		return nil, err
+	}
+	dir := q.Direction
+	if dir == "" {
+		dir = Downstream
+	}
+	next := g.Successors
+	mode := "downstream"
+	if dir == Upstream {
+		next = g.Predecessors
+		mode = "upstream"
+	} else if dir != Downstream {
+		return nil, fmt.Errorf("unknown direction %q (want up or down)", dir)
+	}
+
+	res := &Result{Mode: mode}
+	expanded := map[string]bool{}
+	for _, root := range roots {
+		tree := &TreeNode{NodeRef: ref(g, root.ID)}
+		res.addNode(root.ID)
+		expanded[root.ID] = true
+		res.grow(g, q, tree, root.ID, next, expanded, map[string]bool{root.ID: true}, 0)
+		res.Trees = append(res.Trees, tree)
+	}
+	res.Subgraph = treeGraph(g, res, dir)
+	return res, nil
+}
+
+// treeGraph builds the subgraph for a traversal from its tree links, so
+// relationships that pass through filtered-out nodes stay visible.
+func treeGraph(g *graph.Graph, res *Result, dir Direction) *graph.Graph {
+	sub := g.Subgraph(res.nodeOrder)
+	direct := map[[2]string]string{}
+	for _, e := range g.Edges {
+		direct[[2]string{e.From, e.To}] = e.Label
+	}
+	sub.Edges = sub.Edges[:0]
+	seen := map[[2]string]bool{}
+	var link func(t *TreeNode)
+	link = func(t *TreeNode) {
+		for _, c := range t.Children {
+			from, to := t.ID, c.ID
+			if dir == Upstream {
+				from, to = c.ID, t.ID
+			}
+			key := [2]string{from, to}
+			if !seen[key] {
+				seen[key] = true
+				label, ok := direct[key]
+				if !ok {
+					label = "via hidden nodes"
+				}
+				sub.AddEdge(graph.Edge{From: from, To: to, Label: label})
+			}
+			link(c)
+		}
+	}
+	for _, t := range res.Trees {
+		link(t)
+	}
+	return sub
+}
+
+// grow attaches the reported descendants of id to parent. Nodes rejected by
+// the filters are walked through but not shown, so their reported
+// descendants attach to the nearest reported ancestor.
+func (r *Result) grow(g *graph.Graph, q Query, parent *TreeNode, id string, next func(string) []string, expanded, onPath map[string]bool, depth int) {
+	if q.MaxDepth > 0 && depth >= q.MaxDepth {
+		return
+	}
+	for _, n := range next(id) {
+		if onPath[n] {
+			continue
+		}
+		node := g.Nodes[n]
+		if !q.keep(node) {
+			// Hidden nodes are walked once; their descendants are already
+			// reported under the first ancestor that reached them.
+			if expanded[n] {
+				continue
+			}
+			expanded[n] = true
+			onPath[n] = true
+			r.grow(g, q, parent, n, next, expanded, onPath, depth+1)
+			onPath[n] = false
+			continue
+		}
+		child := &TreeNode{NodeRef: ref(g, n)}
+		parent.Children = append(parent.Children, child)
+		r.addNode(n)
+		if expanded[n] {
+			child.Repeat = len(next(n)) > 0
+			continue
+		}
+		expanded[n] = true
+		onPath[n] = true
+		r.grow(g, q, child, n, next, expanded, onPath, depth+1)
+		onPath[n] = false
+	}
+}
+
+func runPaths(g *graph.Graph, q Query) (*Result, error) {
+	froms, err := Match(g, q.From)
+	if err != nil {
+		return nil, err
+	}
+	tos, err := Match(g, q.To)
+	if err != nil {
+		return nil, err
+	}
+
+	allow := func(n *graph.Node) bool { return q.keep(n) }
+	if len(q.Types) == 0 && q.MinCount == 0 {
+		allow = nil
+	}
+
+	res := &Result{Mode: "shortest_path"}
+	var found [][]string
+	if q.AllPaths {
+		res.Mode = "all_paths"
+		for _, f := range froms {
+			for _, t := range tos {
+				found = append(found, g.AllPaths(f.ID, t.ID, q.MaxDepth, allow)...)
+			}
+		}
+	} else {
+		var best []string
+		for _, f := range froms {
+			for _, t := range tos {
+				p := g.ShortestPath(f.ID, t.ID, allow)
+				if p != nil && (best == nil || len(p) < len(best)) {
+					best = p
+				}
+			}
+		}
+		if best != nil && (q.MaxDepth == 0 || len(best)-1 <= q.MaxDepth) {
+			found = append(found, best)
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return len(found[i]) < len(found[j]) })
+	for _, p := range found {
+		refs := make([]NodeRef, len(p))
+		for i, id := range p {
+			refs[i] = ref(g, id)
+			res.addNode(id)
+		}
+		res.Paths = append(res.Paths, refs)
+	}
+	res.Subgraph = g.Subgraph(res.nodeOrder)
+	// Keep only the edges that lie on a reported path
+	onPath := map[[2]string]bool{}
+	for _, p := range found {
+		for i := 0; i+1 < len(p); i++ {
+			onPath[[2]string{p[i], p[i+1]}] = true
+		}
+	}
+	edges := res.Subgraph.Edges[:0]
+	for _, e := range res.Subgraph.Edges {
+		if onPath[[2]string{e.From, e.To}] {
+			edges = append(edges, e)
+		}
+	}
+	res.Subgraph.Edges = edges
+	return res, nil
+}
+
+func (r *Result) addNode(id string) {
+	for _, existing := range r.nodeOrder {
+		if existing == id {
+			return
+		}
+	}
+	r.nodeOrder = append(r.nodeOrder, id)
+}
+
+func ref(g *graph.Graph, id string) NodeRef {
+	n, ok := g.Nodes[id]
+	if !ok {
+		return NodeRef{ID: id, Label: id, Type: string(graph.NodeOther)}
+	}
+	return NodeRef{ID: n.ID, Label: n.Label, Type: string(n.Type), Count: n.Count}
+}
+
+// ParseTypes parses a comma-separated list of node types
+func ParseTypes(s string) ([]graph.NodeType, error) {
+	var out []graph.NodeType
+	for _, part := range strings.Split(s, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		t := graph.NodeType(part)
+		switch t {
+		case graph.NodeCause, graph.NodeState, graph.NodeView, graph.NodeOther:
+			out = append(out, t)
+		default:
+			return nil, fmt.Errorf("unknown node type %q (want cause, state, view or other)", part)
+		}
+	}
+	return out, nil
+}
+
+// Text renders the result as an indented tree or a list of paths
+func (r *Result) Text() string {
+	var b strings.Builder
+	if len(r.Trees) == 0 && len(r.Paths) == 0 {
+		b.WriteString("No results.\n")
+		return b.String()
+	}
+	for i, tree := range r.Trees {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s%s of %s\n", strings.ToUpper(r.Mode[:1]), r.Mode[1:], formatRef(tree.NodeRef))
+		writeTree(&b, tree.Children, "")
+	}
+	for i, p := range r.Paths {
+		labels := make([]string, len(p))
+		for j, n := range p {
+			labels[j] = formatRef(n)
+		}
+		fmt.Fprintf(&b, "%d. %s\n", i+1, strings.Join(labels, " → "))
+	}
+	return b.String()
+}
+
+func writeTree(b *strings.Builder, children []*TreeNode, prefix string) {
+	for i, c := range children {
+		branch, indent := "├── ", "│   "
+		if i == len(children)-1 {
+			branch, indent = "└── ", "    "
+		}
+		line := formatRef(c.NodeRef)
+		if c.Repeat {
+			line += " ↺"
+		}
+		b.WriteString(prefix + branch + line + "\n")
+		writeTree(b, c.Children, prefix+indent)
+	}
+}
+
+func formatRef(n NodeRef) string {
+	s := fmt.Sprintf("%s [%s]", n.Label, n.Type)
+	if n.Count > 0 {
+		s += fmt.Sprintf(" count=%d", n.Count)
+	}
+	if n.ID != n.Label {
+		s += fmt.Sprintf(" (%s)", n.ID)
+	}
+	return s
+}
+
+// JSON renders the result as indented JSON
+func (r *Result) JSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// DOT renders the nodes and edges touched by the result as a Graphviz graph
+func (r *Result) DOT() string {
+	return analyze.RenderDOT(r.Subgraph)
+}
+
+// Mermaid renders the nodes and edges touched by the result as a Mermaid flowchart
+func (r *Result) Mermaid() string {
+	return analyze.RenderMermaid(r.Subgraph, analyze.MermaidOptions{})
+}
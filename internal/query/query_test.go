@@ -0,0 +1,167 @@
+package query
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+func testGraph() *graph.Graph {
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "c1", Label: "Button tap", Type: graph.NodeCause, Count: 4})
+	g.UpsertNode(&graph.Node{ID: "c2", Label: "Timer fired", Type: graph.NodeCause, Count: 60})
+	g.UpsertNode(&graph.Node{ID: "s1", Label: "@State items", Type: graph.NodeState})
+	g.UpsertNode(&graph.Node{ID: "s2", Label: "@Published now", Type: graph.NodeState})
+	g.UpsertNode(&graph.Node{ID: "v1", Label: "RowView", Type: graph.NodeView, Count: 40})
+	g.UpsertNode(&graph.Node{ID: "v2", Label: "ClockView", Type: graph.NodeView, Count: 60})
+	g.AddEdge(graph.Edge{From: "c1", To: "s1", Label: "causes"})
+	g.AddEdge(graph.Edge{From: "c2", To: "s2", Label: "causes"})
+	g.AddEdge(graph.Edge{From: "s1", To: "v1", Label: "updates"})
+	g.AddEdge(graph.Edge{From: "s2", To: "v1", Label: "updates"})
+	g.AddEdge(graph.Edge{From: "s2", To: "v2", Label: "updates"})
+	return g
+}
+
+func TestMatch(t *testing.T) {
+	g := testGraph()
+	if nodes, err := Match(g, "v1"); err != nil || len(nodes) != 1 || nodes[0].ID != "v1" {
+		t.Errorf("exact ID match failed: %v %v", nodes, err)
+	}
+	if nodes, err := Match(g, "view$"); err != nil || len(nodes) != 2 {
+		t.Errorf("expected 2 regex matches, got %v %v", nodes, err)
+	}
+	if _, err := Match(g, "nothing-here"); err == nil {
+		t.Error("expected error for no match")
+	}
+	if _, err := Match(g, "("); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
+
+func TestMatch_Instances(t *testing.T) {
+	g := testGraph()
+	g.Nodes["v1"].Instances = []graph.Instance{
+		{ID: "v1", Label: "RowView: 0x6000aaaa", Count: 30},
+		{ID: "v7", Label: "RowView: 0x6000bbbb", Count: 10},
+	}
+	if nodes, err := Match(g, "v7"); err != nil || len(nodes) != 1 || nodes[0].ID != "v1" {
+		t.Errorf("instance ID should resolve to the collapsed node: %v %v", nodes, err)
+	}
+	if nodes, err := Match(g, "0x6000bbbb"); err != nil || len(nodes) != 1 || nodes[0].ID != "v1" {
+		t.Errorf("raw instance label should match: %v %v", nodes, err)
+	}
+}
+
+func TestRun_Upstream(t *testing.T) {
+	res, err := Run(testGraph(), Query{Node: "RowView", Direction: Upstream})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Trees) != 1 || len(res.Trees[0].Children) != 2 {
+		t.Fatalf("expected RowView with 2 upstream states, got %+v", res.Trees)
+	}
+	text := res.Text()
+	for _, want := range []string{"Upstream of RowView", "@State items", "Button tap", "Timer fired"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text output missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestRun_TypeFilterCollapsesHiddenNodes(t *testing.T) {
+	res, err := Run(testGraph(), Query{Node: "RowView", Direction: Upstream, Types: []graph.NodeType{graph.NodeCause}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	children := res.Trees[0].Children
+	if len(children) != 2 {
+		t.Fatalf("expected both causes attached directly to RowView, got %+v", children)
+	}
+	for _, c := range children {
+		if c.Type != string(graph.NodeCause) {
+			t.Errorf("unexpected node type %s in filtered tree", c.Type)
+		}
+	}
+	// DOT subgraph still links causes to the view
+	if len(res.Subgraph.Edges) != 2 || !strings.Contains(res.DOT(), "via hidden nodes") {
+		t.Errorf("expected synthetic edges in subgraph, got %+v", res.Subgraph.Edges)
+	}
+}
+
+func TestRun_MinCount(t *testing.T) {
+	res, err := Run(testGraph(), Query{Node: "Timer", Direction: Downstream, MinCount: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	children := res.Trees[0].Children
+	if len(children) != 1 || children[0].ID != "v2" {
+		t.Errorf("expected only ClockView (count 60), got %+v", children)
+	}
+}
+
+func TestRun_Paths(t *testing.T) {
+	g := testGraph()
+	res, err := Run(g, Query{From: "Timer", To: "RowView"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Mode != "shortest_path" || len(res.Paths) != 1 || len(res.Paths[0]) != 3 {
+		t.Fatalf("unexpected shortest path result: %+v", res.Paths)
+	}
+
+	res, err = Run(g, Query{From: "tap|timer", To: "RowView", AllPaths: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Paths) != 2 {
+		t.Errorf("expected 2 paths, got %d", len(res.Paths))
+	}
+	if len(res.Subgraph.Edges) != 4 {
+		t.Errorf("expected only on-path edges, got %+v", res.Subgraph.Edges)
+	}
+
+	if _, err := Run(g, Query{From: "Timer"}); err == nil {
+		t.Error("expected error when only one path end is given")
+	}
+}
+
+func TestResultJSON(t *testing.T) {
+	res, err := Run(testGraph(), Query{Node: "Timer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := res.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded["mode"] != "downstream" {
+		t.Errorf("unexpected mode: %v", decoded["mode"])
+	}
+}
+
+func TestParseTypes(t *testing.T) {
+	types, err := ParseTypes("cause, View")
+	if err != nil || len(types) != 2 || types[1] != graph.NodeView {
+		t.Errorf("ParseTypes returned %v %v", types, err)
+	}
+	if _, err := ParseTypes("widget"); err == nil {
+		t.Error("expected error for unknown type")
+	}
+}
+
+func TestResult_Mermaid(t *testing.T) {
+	res, err := Run(testGraph(), Query{From: "Button", To: "RowView"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Mermaid()
+	if !strings.HasPrefix(out, "flowchart LR") || !strings.Contains(out, "RowView") || strings.Contains(out, "ClockView") {
+		t.Errorf("unexpected Mermaid output:\n%s", out)
+	}
+}
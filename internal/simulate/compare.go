@@ -0,0 +1,162 @@
+package simulate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+)
+
+// Outcome compares a graph with a copy that has edits applied
+type Outcome struct {
+	Edits      []Edit         `json:"edits"`
+	Before     Metrics        `json:"before"`
+	After      Metrics        `json:"after"`
+	Reduction  float64        `json:"reduction"`            // share of view updates saved, 0-1
+	Resolved   []issues.Issue `json:"resolved,omitempty"`   // detected before the edits only
+	Introduced []issues.Issue `json:"introduced,omitempty"` // detected after the edits only
+	Nodes      []NodeChange   `json:"nodes,omitempty"`      // largest change first
+
+	Graph *graph.Graph `json:"-"` // the edited graph
+}
+
+// NodeChange is a node whose count the edits changed, or that they added or
+// removed
+type NodeChange struct {
+	ID     string         `json:"id"`
+	Label  string         `json:"label"`
+	Type   graph.NodeType `json:"type"`
+	Before int            `json:"before"`
+	After  int            `json:"after"`
+	Status string         `json:"status,omitempty"` // added or removed
+}
+
+// Compare applies edits to a copy of g, re-runs detection on it and compares
+// the two. detected are the issues d found in g.
+func Compare(g *graph.Graph, d *issues.Detector, detected []issues.Issue, edits []Edit) (*Outcome, error) {
+	after, err := Apply(g, edits)
+	if err != nil {
+		return nil, err
+	}
+	afterIssues := d.Detect(after)
+	o := &Outcome{
+		Edits:  edits,
+		Before: Measure(g, detected),
+		After:  Measure(after, afterIssues),
+		Graph:  after,
+	}
+	o.Reduction = reduction(o.Before.ViewUpdates, o.After.ViewUpdates)
+	for _, is := range detected {
+		if !containsIssue(afterIssues, is) {
+			o.Resolved = append(o.Resolved, is)
+		}
+	}
+	for _, is := range afterIssues {
+		if !containsIssue(detected, is) {
+			o.Introduced = append(o.Introduced, is)
+		}
+	}
+	o.Nodes = nodeChanges(g, after)
+	return o, nil
+}
+
+func nodeChanges(before, after *graph.Graph) []NodeChange {
+	var out []NodeChange
+	for id, n := range before.Nodes {
+		c := NodeChange{ID: id, Label: n.Label, Type: n.Type, Before: n.Count}
+		if m, ok := after.Nodes[id]; ok {
+			c.After = m.Count
+		} else {
+			c.Status = "removed"
+		}
+		if c.Before != c.After || c.Status != "" {
+			out = append(out, c)
+		}
+	}
+	for id, n := range after.Nodes {
+		if _, ok := before.Nodes[id]; !ok {
+			out = append(out, NodeChange{ID: id, Label: n.Label, Type: n.Type, After: n.Count, Status: "added"})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		di, dj := abs(out[i].After-out[i].Before), abs(out[j].After-out[j].Before)
+		if di != dj {
+			return di > dj
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// containsIssue reports whether an issue of the same type is raised on the
+// same primary node. Issue IDs are positional and cannot be compared.
+func containsIssue(detected []issues.Issue, issue issues.Issue) bool {
+	if len(issue.AffectedNodes) == 0 {
+		return false
+	}
+	for _, other := range detected {
+		if other.Type == issue.Type && len(other.AffectedNodes) > 0 && other.AffectedNodes[0] == issue.AffectedNodes[0] {
+			return true
+		}
+	}
+	return false
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Text renders the comparison: the edits, the metrics side by side, the
+// issues resolved and introduced and the largest count changes
+func (o *Outcome) Text() string {
+	var b strings.Builder
+	b.WriteString("Edits:\n")
+	for _, e := range o.Edits {
+		fmt.Fprintf(&b, "  %s\n", e)
+	}
+	b.WriteString("\n")
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "\tbefore\tafter\tchange\t")
+	fmt.Fprintf(tw, "view updates\t%d\t%d\t%+d (%+.1f%%)\t\n", o.Before.ViewUpdates, o.After.ViewUpdates,
+		o.After.ViewUpdates-o.Before.ViewUpdates, -o.Reduction*100)
+	fmt.Fprintf(tw, "issues\t%d\t%d\t%+d\t\n", o.Before.Issues, o.After.Issues, o.After.Issues-o.Before.Issues)
+	fmt.Fprintf(tw, "score\t%d\t%d\t%+d\t\n", o.Before.Score, o.After.Score, o.After.Score-o.Before.Score)
+	tw.Flush()
+
+	writeIssues := func(title string, list []issues.Issue) {
+		if len(list) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s (%d):\n", title, len(list))
+		for _, is := range list {
+			fmt.Fprintf(&b, "  [%s] %s\n", is.Severity, is.Title)
+		}
+	}
+	writeIssues("Resolved", o.Resolved)
+	writeIssues("Introduced", o.Introduced)
+
+	if len(o.Nodes) > 0 {
+		b.WriteString("\nLargest count changes:\n")
+		for i, c := range o.Nodes {
+			if i == maxTextNodes {
+				fmt.Fprintf(&b, "  … %d more\n", len(o.Nodes)-i)
+				break
+			}
+			change := fmt.Sprintf("%d → %d", c.Before, c.After)
+			if c.Status != "" {
+				change = fmt.Sprintf("%s (%d)", c.Status, c.Before+c.After)
+			}
+			fmt.Fprintf(&b, "  %s [%s] (%s): %s\n", c.Label, c.Type, c.ID, change)
+		}
+	}
+	return b.String()
+}
+
+// maxTextNodes caps the count changes Text lists
+const maxTextNodes = 10
@@ -0,0 +1,56 @@
+package simulate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+)
+
+func TestCompare(t *testing.T) {
+	g := chain()
+	d := issues.NewDetector()
+	detected := d.Detect(g)
+
+	o, err := Compare(g, d, detected, []Edit{{Op: OpSplitState, Node: "model"}, {Op: OpDropEdge, From: "timer", To: "list"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Before.ViewUpdates != 160 || o.After.ViewUpdates != 39 || o.Reduction != 0.756 {
+		t.Errorf("got %+v → %+v, reduction %v", o.Before, o.After, o.Reduction)
+	}
+	if o.Before.Issues != len(detected) || o.Before.Score != issues.Score(detected) {
+		t.Errorf("before metrics do not match the detected issues: %+v", o.Before)
+	}
+	resolved := map[issues.IssueType]bool{}
+	for _, is := range o.Resolved {
+		resolved[is.Type] = true
+	}
+	if len(o.Resolved) != 2 || !resolved[issues.IssueCascadingUpdate] || !resolved[issues.IssueWholeObjectPassing] {
+		t.Errorf("expected the cascade and whole-object observation resolved, got %+v", o.Resolved)
+	}
+	if len(o.Introduced) != 0 {
+		t.Errorf("nothing should be introduced, got %+v", o.Introduced)
+	}
+	if o.Nodes[0].ID != "list" || o.Nodes[0].After != 13 {
+		t.Errorf("largest change first: got %+v", o.Nodes[0])
+	}
+	if _, ok := o.Graph.Nodes["model#1"]; !ok {
+		t.Error("expected the edited graph")
+	}
+
+	text := o.Text()
+	for _, want := range []string{"split-state model", "drop-edge timer -> list", "view updates", "-121 (-75.6%)", "Resolved (", "Model (ListView) [state] (model#1): added (13)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestCompareError(t *testing.T) {
+	g := chain()
+	d := issues.NewDetector()
+	if _, err := Compare(g, d, d.Detect(g), []Edit{{Op: OpBypass, Node: "nope"}}); err == nil {
+		t.Error("expected the edit error")
+	}
+}
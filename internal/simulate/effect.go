@@ -0,0 +1,164 @@
+package simulate
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+)
+
+// Kind names what a fix does to the graph
+type Kind string
+
+const (
+	// KindSplitState splits the states the issue names, or those feeding its
+	// views, so each view observes only its own part
+	KindSplitState Kind = "split-state"
+	// KindBypass removes the states the issue names that relay updates
+	KindBypass Kind = "bypass"
+	// KindScaleViews makes the issue's views update Factor times as often
+	KindScaleViews Kind = "scale-views"
+	// KindScaleCauses makes the issue's causes, or those upstream of it,
+	// fire Factor times as often
+	KindScaleCauses Kind = "scale-causes"
+	// KindIsolateCause confines each of the issue's causes to the view it
+	// updates most; the other views stop receiving its updates
+	KindIsolateCause Kind = "isolate-cause"
+)
+
+// Kinds lists the effect kinds, in documentation order
+var Kinds = []Kind{KindSplitState, KindBypass, KindScaleViews, KindScaleCauses, KindIsolateCause}
+
+// Scales reports whether the kind takes a factor
+func (k Kind) Scales() bool {
+	return k == KindScaleViews || k == KindScaleCauses
+}
+
+// Effect is how a fix changes the graph, as declared by the fix catalog
+type Effect struct {
+	Kind       Kind    `json:"kind"`
+	Factor     float64 `json:"factor,omitempty"`     // share of updates kept, for scale kinds
+	Assumption string  `json:"assumption,omitempty"` // what the estimate takes for granted
+}
+
+// Validate checks the kind is known and the factor fits it
+func (e Effect) Validate() error {
+	known := false
+	for _, k := range Kinds {
+		known = known || k == e.Kind
+	}
+	switch {
+	case !known:
+		return fmt.Errorf("unknown effect kind %q", e.Kind)
+	case e.Kind.Scales() && (e.Factor <= 0 || e.Factor >= 1):
+		return fmt.Errorf("%s needs a factor between 0 and 1, got %g", e.Kind, e.Factor)
+	case !e.Kind.Scales() && e.Factor != 0:
+		return fmt.Errorf("%s takes no factor", e.Kind)
+	}
+	return nil
+}
+
+// Edits returns the graph edits that model the effect on one issue, or
+// nil when the issue has no nodes of the kind the effect changes
+func (e Effect) Edits(g *graph.Graph, issue issues.Issue) []Edit {
+	ids := issues.AffectedNodeIDs(g, []issues.Issue{issue})
+	var edits []Edit
+	switch e.Kind {
+	case KindSplitState:
+		states := ofType(g, ids, graph.NodeState)
+		if len(states) == 0 {
+			for _, v := range ofType(g, ids, graph.NodeView) {
+				states = append(states, ofType(g, g.Predecessors(v), graph.NodeState)...)
+			}
+		}
+		for _, id := range dedupe(states) {
+			edits = append(edits, Edit{Op: OpSplitState, Node: id})
+		}
+	case KindBypass:
+		for _, id := range ids {
+			n := g.Nodes[id]
+			if n.Type != graph.NodeCause && n.Type != graph.NodeView && len(g.Predecessors(id)) > 0 && len(g.Successors(id)) > 0 {
+				edits = append(edits, Edit{Op: OpBypass, Node: id})
+			}
+		}
+	case KindScaleViews:
+		for _, id := range ofType(g, ids, graph.NodeView) {
+			edits = append(edits, Edit{Op: OpScale, Node: id, Factor: e.Factor})
+		}
+	case KindScaleCauses:
+		for _, id := range causes(g, ids) {
+			edits = append(edits, Edit{Op: OpScale, Node: id, Factor: e.Factor})
+		}
+	case KindIsolateCause:
+		for _, id := range causes(g, ids) {
+			edits = append(edits, isolate(g, id)...)
+		}
+	}
+	return edits
+}
+
+// causes returns the causes among ids, or else the causes upstream of them
+func causes(g *graph.Graph, ids []string) []string {
+	if out := ofType(g, ids, graph.NodeCause); len(out) > 0 {
+		return out
+	}
+	var up []string
+	for _, id := range ids {
+		up = append(up, ofType(g, g.Upstream(id), graph.NodeCause)...)
+	}
+	return dedupe(up)
+}
+
+// isolate drops the edges carrying a cause's updates into every view it
+// reaches except the one updated most
+func isolate(g *graph.Graph, cause string) []Edit {
+	reach := g.Downstream(cause)
+	views := ofType(g, reach, graph.NodeView)
+	if len(views) < 2 {
+		return nil
+	}
+	keep := views[0]
+	for _, v := range views[1:] {
+		if g.Nodes[v].Count > g.Nodes[keep].Count || (g.Nodes[v].Count == g.Nodes[keep].Count && v < keep) {
+			keep = v
+		}
+	}
+	carrier := map[string]bool{cause: true}
+	for _, id := range reach {
+		carrier[id] = true
+	}
+	seen := map[[2]string]bool{}
+	var edits []Edit
+	for _, e := range g.SortedEdges() {
+		if e.To == keep || !carrier[e.From] || g.Nodes[e.To] == nil || g.Nodes[e.To].Type != graph.NodeView {
+			continue
+		}
+		if key := [2]string{e.From, e.To}; !seen[key] {
+			seen[key] = true
+			edits = append(edits, Edit{Op: OpDropEdge, From: e.From, To: e.To})
+		}
+	}
+	return edits
+}
+
+func ofType(g *graph.Graph, ids []string, t graph.NodeType) []string {
+	var out []string
+	for _, id := range ids {
+		if n, ok := g.Nodes[id]; ok && n.Type == t {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func dedupe(ids []string) []string {
+	sort.Strings(ids)
+	out := ids[:0]
+	for i, id := range ids {
+		if i == 0 || id != ids[i-1] {
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,201 @@
+package simulate
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+)
+
+// Metrics are the totals compared before and after a simulation
+type Metrics struct {
+	ViewUpdates int `json:"view_updates"`
+	Issues      int `json:"issues"`
+	Score       int `json:"score"`
+}
+
+// Measure totals a graph's view updates and scores the issues detected in it
+func Measure(g *graph.Graph, detected []issues.Issue) Metrics {
+	m := Metrics{Issues: len(detected), Score: issues.Score(detected)}
+	for _, n := range g.Nodes {
+		if n.Type == graph.NodeView {
+			m.ViewUpdates += n.Count
+		}
+	}
+	return m
+}
+
+// Impact is the projected effect of a fix on the traced session
+type Impact struct {
+	Before           Metrics `json:"before"`
+	After            Metrics `json:"after"`
+	ViewUpdatesSaved int     `json:"view_updates_saved"`
+	Reduction        float64 `json:"reduction"` // share of view updates saved, 0-1
+	ScoreGain        int     `json:"score_gain"`
+	ResolvesIssue    bool    `json:"resolves_issue"`
+	Edits            []Edit  `json:"edits"`
+	Assumption       string  `json:"assumption,omitempty"`
+}
+
+// Estimate simulates effect on the nodes of one issue and compares the
+// result with g, whose detected issues are given. It returns nil when the
+// effect changes nothing the issue names.
+func Estimate(g *graph.Graph, d *issues.Detector, detected []issues.Issue, issue issues.Issue, effect Effect) *Impact {
+	return NewEstimator(g, d, detected).Estimate(issue, effect)
+}
+
+// Estimator estimates effects on one graph. Unlike Compare, it only re-runs
+// detection on the nodes an edit touched, so estimating every fix of many
+// issues stays cheap on a large graph.
+type Estimator struct {
+	g        *graph.Graph
+	d        *issues.Detector
+	detected []issues.Issue
+	before   Metrics
+	out, in  map[string][]string
+}
+
+// NewEstimator prepares estimates on g, whose detected issues are given
+func NewEstimator(g *graph.Graph, d *issues.Detector, detected []issues.Issue) *Estimator {
+	e := &Estimator{g: g, d: d, detected: detected, before: Measure(g, detected)}
+	e.out, e.in = adjacency(g)
+	return e
+}
+
+// Estimate simulates effect on the nodes of one issue, as the package-level
+// Estimate does
+func (e *Estimator) Estimate(issue issues.Issue, effect Effect) *Impact {
+	edits := effect.Edits(e.g, issue)
+	if len(edits) == 0 {
+		return nil
+	}
+	after, err := Apply(e.g, edits)
+	if err != nil {
+		return nil
+	}
+	dirty := e.touched(after)
+	var afterIssues []issues.Issue
+	for _, is := range e.detected {
+		if len(is.AffectedNodes) == 0 || !dirty[is.AffectedNodes[0]] {
+			afterIssues = append(afterIssues, is)
+		}
+	}
+	ids := make([]string, 0, len(dirty))
+	for id := range dirty {
+		ids = append(ids, id)
+	}
+	afterIssues = append(afterIssues, e.d.DetectAt(after, ids)...)
+
+	before, afterM := e.before, Measure(after, afterIssues)
+	return &Impact{
+		Before:           before,
+		After:            afterM,
+		ViewUpdatesSaved: before.ViewUpdates - afterM.ViewUpdates,
+		Reduction:        reduction(before.ViewUpdates, afterM.ViewUpdates),
+		ScoreGain:        afterM.Score - before.Score,
+		ResolvesIssue:    containsIssue(e.detected, issue) && !containsIssue(afterIssues, issue),
+		Edits:            edits,
+		Assumption:       effect.Assumption,
+	}
+}
+
+// touched returns the nodes whose issues may differ in the edited graph.
+// Issues are anchored at a node and depend on its count, its outgoing
+// edges or, for chains and timers, on everything downstream of it: so the
+// nodes added, removed or recounted, those whose outgoing edges changed,
+// and everything upstream of the latter in either graph.
+func (e *Estimator) touched(after *graph.Graph) map[string]bool {
+	outAfter, inAfter := adjacency(after)
+	dirty := map[string]bool{}
+	var rewired []string
+	for id, n := range e.g.Nodes {
+		m, ok := after.Nodes[id]
+		switch {
+		case !ok || !sameTargets(e.out[id], outAfter[id]):
+			rewired = append(rewired, id)
+		case m.Count != n.Count:
+			dirty[id] = true
+		}
+	}
+	for id := range after.Nodes {
+		if _, ok := e.g.Nodes[id]; !ok {
+			rewired = append(rewired, id)
+		}
+	}
+	for _, preds := range []map[string][]string{e.in, inAfter} {
+		stack := append([]string(nil), rewired...)
+		seen := map[string]bool{}
+		for len(stack) > 0 {
+			id := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			dirty[id] = true
+			stack = append(stack, preds[id]...)
+		}
+	}
+	return dirty
+}
+
+// adjacency indexes a graph's edges by source and by target
+func adjacency(g *graph.Graph) (out, in map[string][]string) {
+	out, in = make(map[string][]string, len(g.Nodes)), make(map[string][]string, len(g.Nodes))
+	for _, e := range g.Edges {
+		out[e.From] = append(out[e.From], e.To)
+		in[e.To] = append(in[e.To], e.From)
+	}
+	return out, in
+}
+
+// sameTargets reports whether two edge target lists hold the same targets
+// as often, in any order
+func sameTargets(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if slices.Equal(a, b) {
+		return true
+	}
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+// reduction is the share of view updates saved, to three decimals
+func reduction(before, after int) float64 {
+	if before <= 0 {
+		return 0
+	}
+	return math.Round(float64(before-after)/float64(before)*1000) / 1000
+}
+
+// String summarizes the savings, e.g. "81 fewer view updates (50.6%),
+// score +10, resolves the issue"
+func (im *Impact) String() string {
+	s := fmt.Sprintf("%d fewer view updates (%.1f%%), score %+d", im.ViewUpdatesSaved, im.Reduction*100, im.ScoreGain)
+	if im.ResolvesIssue {
+		s += ", resolves the issue"
+	}
+	return s
+}
+
+// Payoff orders two impacts by score gained, then view updates saved; a
+// nil impact ranks below any estimate
+func Payoff(a, b *Impact) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	case a.ScoreGain != b.ScoreGain:
+		return a.ScoreGain - b.ScoreGain
+	}
+	return a.ViewUpdatesSaved - b.ViewUpdatesSaved
+}
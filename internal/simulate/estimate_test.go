@@ -0,0 +1,163 @@
+package simulate
+
+import (
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+)
+
+func findIssue(t *testing.T, detected []issues.Issue, typ issues.IssueType) issues.Issue {
+	t.Helper()
+	for _, is := range detected {
+		if is.Type == typ {
+			return is
+		}
+	}
+	t.Fatalf("no %s issue in %+v", typ, detected)
+	return issues.Issue{}
+}
+
+func TestEstimateSplitResolvesCascade(t *testing.T) {
+	g := chain()
+	d := issues.NewDetector()
+	detected := d.Detect(g)
+	cascade := findIssue(t, detected, issues.IssueCascadingUpdate)
+
+	im := Estimate(g, d, detected, cascade, Effect{Kind: KindSplitState, Assumption: "equal shares"})
+	if im == nil {
+		t.Fatal("expected an estimate")
+	}
+	if !im.ResolvesIssue {
+		t.Error("splitting the model should resolve the cascade")
+	}
+	if im.Before.ViewUpdates != 160 || im.After.ViewUpdates != 79 || im.ViewUpdatesSaved != 81 {
+		t.Errorf("view updates: got %+v → %+v, saved %d", im.Before, im.After, im.ViewUpdatesSaved)
+	}
+	if im.Reduction != 0.506 {
+		t.Errorf("reduction: got %v", im.Reduction)
+	}
+	if im.ScoreGain <= 0 || im.After.Score != im.Before.Score+im.ScoreGain {
+		t.Errorf("score: got %d → %d (gain %d)", im.Before.Score, im.After.Score, im.ScoreGain)
+	}
+	if len(im.Edits) != 1 || im.Edits[0].String() != "split-state model" || im.Assumption != "equal shares" {
+		t.Errorf("edits: got %+v, assumption %q", im.Edits, im.Assumption)
+	}
+}
+
+func TestEstimateIsolateTimer(t *testing.T) {
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "timer", Label: "Timer.publish", Type: graph.NodeCause, Count: 60})
+	g.UpsertNode(&graph.Node{ID: "now", Label: "@State now", Type: graph.NodeState, Count: 60})
+	g.UpsertNode(&graph.Node{ID: "clock", Label: "ClockView", Type: graph.NodeView, Count: 60})
+	g.UpsertNode(&graph.Node{ID: "list", Label: "ListView", Type: graph.NodeView, Count: 60})
+	g.AddEdge(graph.Edge{From: "timer", To: "now"})
+	g.AddEdge(graph.Edge{From: "now", To: "clock"})
+	g.AddEdge(graph.Edge{From: "now", To: "list"})
+	d := issues.NewDetector()
+	detected := d.Detect(g)
+	timer := findIssue(t, detected, issues.IssueTimerCascade)
+
+	im := Estimate(g, d, detected, timer, Effect{Kind: KindIsolateCause})
+	if im == nil {
+		t.Fatal("expected an estimate")
+	}
+	if len(im.Edits) != 1 || im.Edits[0].String() != "drop-edge now -> list" {
+		t.Errorf("edits: got %+v", im.Edits)
+	}
+	if im.ViewUpdatesSaved != 60 || !im.ResolvesIssue {
+		t.Errorf("got saved %d, resolves %v", im.ViewUpdatesSaved, im.ResolvesIssue)
+	}
+}
+
+func TestEstimateScaleUpstreamCauses(t *testing.T) {
+	g := chain()
+	d := issues.NewDetector()
+	detected := d.Detect(g)
+	var rerender issues.Issue
+	for _, is := range detected {
+		if is.Type == issues.IssueExcessiveRerender && is.AffectedNodes[0] == "list" {
+			rerender = is
+		}
+	}
+
+	edits := Effect{Kind: KindScaleCauses, Factor: 0.5}.Edits(g, rerender)
+	if len(edits) != 2 || edits[0].Node != "tap" || edits[1].Node != "timer" {
+		t.Errorf("a view's issue should scale the causes upstream of it, got %+v", edits)
+	}
+}
+
+func TestEstimateNothingToChange(t *testing.T) {
+	g := chain()
+	d := issues.NewDetector()
+	detected := d.Detect(g)
+	rerender := findIssue(t, detected, issues.IssueExcessiveRerender)
+
+	if im := Estimate(g, d, detected, rerender, Effect{Kind: KindBypass}); im != nil {
+		t.Errorf("a view issue has no relaying state to bypass, got %+v", im)
+	}
+}
+
+// TestEstimateMatchesCompare checks that re-detecting only the touched
+// nodes gives the same metrics as re-detecting the whole edited graph
+func TestEstimateMatchesCompare(t *testing.T) {
+	g := chain()
+	for i, id := range []string{"row", "cell", "badge"} {
+		g.UpsertNode(&graph.Node{ID: id, Label: id + "View", Type: graph.NodeView, Count: 30 - i*10})
+	}
+	g.AddEdge(graph.Edge{From: "list", To: "row"})
+	g.AddEdge(graph.Edge{From: "row", To: "cell"})
+	g.AddEdge(graph.Edge{From: "cell", To: "badge"})
+	d := issues.NewDetector()
+	detected := d.Detect(g)
+
+	for _, is := range detected {
+		for _, kind := range Kinds {
+			effect := Effect{Kind: kind}
+			if kind.Scales() {
+				effect.Factor = 0.25
+			}
+			im := Estimate(g, d, detected, is, effect)
+			if im == nil {
+				continue
+			}
+			o, err := Compare(g, d, detected, im.Edits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if im.After != o.After || im.ResolvesIssue != containsIssue(o.Resolved, is) {
+				t.Errorf("%s on %s: estimated %+v (resolves %v), compared %+v (resolved %v)",
+					kind, is.Title, im.After, im.ResolvesIssue, o.After, containsIssue(o.Resolved, is))
+			}
+		}
+	}
+}
+
+func TestEffectValidate(t *testing.T) {
+	for _, tc := range []struct {
+		effect Effect
+		ok     bool
+	}{
+		{Effect{Kind: KindSplitState}, true},
+		{Effect{Kind: KindScaleViews, Factor: 0.5}, true},
+		{Effect{Kind: KindScaleViews}, false},
+		{Effect{Kind: KindScaleCauses, Factor: 1}, false},
+		{Effect{Kind: KindBypass, Factor: 0.5}, false},
+		{Effect{Kind: "rewrite"}, false},
+	} {
+		if err := tc.effect.Validate(); (err == nil) != tc.ok {
+			t.Errorf("%+v: got %v", tc.effect, err)
+		}
+	}
+}
+
+func TestPayoff(t *testing.T) {
+	big := &Impact{ScoreGain: 10, ViewUpdatesSaved: 5}
+	wide := &Impact{ScoreGain: 3, ViewUpdatesSaved: 500}
+	if Payoff(big, wide) <= 0 || Payoff(wide, big) >= 0 {
+		t.Error("score gain should rank before view updates saved")
+	}
+	if Payoff(&Impact{}, nil) <= 0 || Payoff(nil, nil) != 0 {
+		t.Error("an estimate should rank above none")
+	}
+}
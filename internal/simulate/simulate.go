@@ -0,0 +1,388 @@
+// Package simulate applies edits to a copy of a cause-effect graph and
+// re-propagates its update counts, so the effect of a fix can be measured by
+// re-running issue detection and scoring on the result.
+//
+// Each edge is taken to account for a share of its target's traced updates,
+// in proportion to how often its source fired. An edit changes how often a
+// node fires or which edges remain; every node downstream then updates in
+// proportion to what is left of its inputs.
+package simulate
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+// Op names a graph edit
+type Op string
+
+const (
+	OpDropEdge   Op = "drop-edge"   // remove the edges From → To
+	OpSplitState Op = "split-state" // split Node into Parts, or one part per observer
+	OpBypass     Op = "bypass"      // remove Node, linking its inputs to its outputs
+	OpScale      Op = "scale"       // Node fires Factor times as often
+)
+
+// Ops lists the edit operations
+var Ops = []Op{OpDropEdge, OpSplitState, OpBypass, OpScale}
+
+// Edit is one change to the graph
+type Edit struct {
+	Op     Op      `json:"op"`
+	Node   string  `json:"node,omitempty"`
+	From   string  `json:"from,omitempty"`
+	To     string  `json:"to,omitempty"`
+	Factor float64 `json:"factor,omitempty"`
+	Parts  []Part  `json:"parts,omitempty"`
+}
+
+// Part is one piece of a split state and the views that observe it. Views
+// of the state assigned to no part keep observing all of them.
+type Part struct {
+	Name  string   `json:"name"`
+	Views []string `json:"views"`
+}
+
+func (e Edit) String() string {
+	switch e.Op {
+	case OpDropEdge:
+		return fmt.Sprintf("%s %s -> %s", e.Op, e.From, e.To)
+	case OpScale:
+		return fmt.Sprintf("%s %s x%g", e.Op, e.Node, e.Factor)
+	case OpSplitState:
+		if len(e.Parts) > 0 {
+			parts := make([]string, len(e.Parts))
+			for i, p := range e.Parts {
+				parts[i] = p.Name + ":" + strings.Join(p.Views, ",")
+			}
+			return fmt.Sprintf("%s %s into %s", e.Op, e.Node, strings.Join(parts, " "))
+		}
+	}
+	return fmt.Sprintf("%s %s", e.Op, e.Node)
+}
+
+type simNode struct {
+	node  graph.Node
+	root  bool    // had no inputs in the traced graph
+	scale float64 // how often it fires relative to the trace, before its inputs
+}
+
+type simEdge struct {
+	graph.Edge
+	share float64 // part of the target's traced updates this edge accounts for
+}
+
+type sim struct {
+	nodes map[string]*simNode
+	edges []simEdge
+}
+
+// Apply returns a copy of g with the edits applied in order and every
+// node's count re-propagated. Per-source counts and instances are not
+// carried over, since they no longer add up.
+func Apply(g *graph.Graph, edits []Edit) (*graph.Graph, error) {
+	s := newSim(g)
+	for _, e := range edits {
+		if err := s.apply(e); err != nil {
+			return nil, err
+		}
+	}
+	return s.graph(g.Containment), nil
+}
+
+func newSim(g *graph.Graph) *sim {
+	s := &sim{nodes: make(map[string]*simNode, len(g.Nodes))}
+	for id, n := range g.Nodes {
+		s.nodes[id] = &simNode{node: graph.Node{ID: n.ID, Label: n.Label, Type: n.Type, Count: n.Count}, root: true, scale: 1}
+	}
+
+	inputs := make(map[string][]int, len(g.Nodes))
+	for i, e := range g.Edges {
+		inputs[e.To] = append(inputs[e.To], i)
+		if n, ok := s.nodes[e.To]; ok {
+			n.root = false
+		}
+	}
+	s.edges = make([]simEdge, len(g.Edges))
+	for _, idx := range inputs {
+		// Weight inputs by how often they fired; when none has a count,
+		// weight them equally
+		weights := make([]float64, len(idx))
+		var total float64
+		for i, ei := range idx {
+			if n, ok := g.Nodes[g.Edges[ei].From]; ok && n.Count > 0 {
+				weights[i] = float64(n.Count)
+				total += weights[i]
+			}
+		}
+		if total == 0 {
+			for i := range weights {
+				weights[i] = 1
+			}
+			total = float64(len(weights))
+		}
+		for i, ei := range idx {
+			s.edges[ei] = simEdge{Edge: g.Edges[ei], share: weights[i] / total}
+		}
+	}
+	return s
+}
+
+func (s *sim) node(op Op, id string) (*simNode, error) {
+	n, ok := s.nodes[id]
+	if !ok {
+		return nil, fmt.Errorf("%s: no node %q", op, id)
+	}
+	return n, nil
+}
+
+func (s *sim) apply(e Edit) error {
+	switch e.Op {
+	case OpDropEdge:
+		return s.dropEdge(e.From, e.To)
+	case OpSplitState:
+		if len(e.Parts) > 0 {
+			return s.splitParts(e.Node, e.Parts)
+		}
+		return s.split(e.Node)
+	case OpBypass:
+		return s.bypass(e.Node)
+	case OpScale:
+		n, err := s.node(e.Op, e.Node)
+		if err != nil {
+			return err
+		}
+		if e.Factor < 0 {
+			return fmt.Errorf("%s: factor %g is negative", e.Op, e.Factor)
+		}
+		n.scale *= e.Factor
+		return nil
+	}
+	return fmt.Errorf("unknown edit %q", e.Op)
+}
+
+func (s *sim) dropEdge(from, to string) error {
+	kept := s.edges[:0]
+	dropped := false
+	for _, e := range s.edges {
+		if e.From == from && e.To == to {
+			dropped = true
+			continue
+		}
+		kept = append(kept, e)
+	}
+	s.edges = kept
+	if !dropped {
+		return fmt.Errorf("%s: no edge %s -> %s", OpDropEdge, from, to)
+	}
+	return nil
+}
+
+// split replaces a node with one part per outgoing edge. Each part keeps the
+// node's inputs but changes 1/n as often, n being the number of observers:
+// an observer only updates for the changes to the part it reads.
+func (s *sim) split(id string) error {
+	n, err := s.node(OpSplitState, id)
+	if err != nil {
+		return err
+	}
+	var in, out []simEdge
+	for _, e := range s.edges {
+		switch {
+		case e.To == id:
+			in = append(in, e)
+		case e.From == id:
+			out = append(out, e)
+		}
+	}
+	if len(out) < 2 {
+		return nil
+	}
+
+	edges := make([]simEdge, 0, len(s.edges)+len(in)*len(out))
+	for _, e := range s.edges {
+		if e.To != id && e.From != id {
+			edges = append(edges, e)
+		}
+	}
+	delete(s.nodes, id)
+	for i, o := range out {
+		part := *n
+		part.node.ID = fmt.Sprintf("%s#%d", id, i+1)
+		part.node.Label = n.node.Label
+		if to, ok := s.nodes[o.To]; ok {
+			part.node.Label = fmt.Sprintf("%s (%s)", n.node.Label, to.node.Label)
+		}
+		part.scale = n.scale / float64(len(out))
+		s.nodes[part.node.ID] = &part
+		for _, e := range in {
+			e.To = part.node.ID
+			edges = append(edges, e)
+		}
+		o.From = part.node.ID
+		edges = append(edges, o)
+	}
+	s.edges = edges
+	return nil
+}
+
+// splitParts replaces a node with named parts. Each part keeps the node's
+// inputs and changes 1/n as often for n parts; a view observes the parts it
+// is assigned, or all of them when it is assigned none.
+func (s *sim) splitParts(id string, parts []Part) error {
+	n, err := s.node(OpSplitState, id)
+	if err != nil {
+		return err
+	}
+	var in, out []simEdge
+	observers := map[string]bool{}
+	for _, e := range s.edges {
+		switch {
+		case e.To == id:
+			in = append(in, e)
+		case e.From == id:
+			out = append(out, e)
+			observers[e.To] = true
+		}
+	}
+	names := map[string]bool{}
+	assigned := map[string][]string{} // view → part IDs
+	for _, p := range parts {
+		if p.Name == "" || names[p.Name] {
+			return fmt.Errorf("%s %s: part names must be unique and not empty", OpSplitState, id)
+		}
+		names[p.Name] = true
+		for _, v := range p.Views {
+			if !observers[v] {
+				return fmt.Errorf("%s %s: %s does not observe it", OpSplitState, id, v)
+			}
+			assigned[v] = append(assigned[v], id+"#"+p.Name)
+		}
+	}
+
+	edges := make([]simEdge, 0, len(s.edges)+len(in)*len(parts)+len(out)*len(parts))
+	for _, e := range s.edges {
+		if e.To != id && e.From != id {
+			edges = append(edges, e)
+		}
+	}
+	delete(s.nodes, id)
+	var all []string
+	for _, p := range parts {
+		part := *n
+		part.node.ID = id + "#" + p.Name
+		part.node.Label = n.node.Label + "." + p.Name
+		part.scale = n.scale / float64(len(parts))
+		s.nodes[part.node.ID] = &part
+		all = append(all, part.node.ID)
+		for _, e := range in {
+			e.To = part.node.ID
+			edges = append(edges, e)
+		}
+	}
+	for _, o := range out {
+		from := assigned[o.To]
+		if len(from) == 0 {
+			from = all
+		}
+		for _, pid := range from {
+			e := o
+			e.From = pid
+			edges = append(edges, e)
+		}
+	}
+	s.edges = edges
+	return nil
+}
+
+// bypass removes a node that relays updates, connecting each of its inputs
+// to each of its outputs with the combined share
+func (s *sim) bypass(id string) error {
+	n, err := s.node(OpBypass, id)
+	if err != nil {
+		return err
+	}
+	var in, out []simEdge
+	var inShare float64
+	for _, e := range s.edges {
+		switch {
+		case e.To == id:
+			in = append(in, e)
+			inShare += e.share
+		case e.From == id:
+			out = append(out, e)
+		}
+	}
+	if len(in) == 0 || len(out) == 0 {
+		return fmt.Errorf("%s: %s does not relay updates (it needs inputs and outputs)", OpBypass, id)
+	}
+
+	edges := make([]simEdge, 0, len(s.edges)+len(in)*len(out))
+	for _, e := range s.edges {
+		if e.To != id && e.From != id {
+			edges = append(edges, e)
+		}
+	}
+	for _, o := range out {
+		for _, i := range in {
+			share := o.share * n.scale
+			if inShare > 0 {
+				share *= i.share / inShare
+			}
+			edges = append(edges, simEdge{Edge: graph.Edge{From: i.From, To: o.To, Label: o.Label}, share: share})
+		}
+	}
+	delete(s.nodes, id)
+	s.edges = edges
+	return nil
+}
+
+// ratio is how often a node fires relative to the trace: its own scale
+// times what is left of its inputs, indexed by target. Edges closing a
+// cycle count as unchanged.
+func (s *sim) ratio(id string, inputs map[string][]simEdge, memo map[string]float64, visiting map[string]bool) float64 {
+	if r, ok := memo[id]; ok {
+		return r
+	}
+	n, ok := s.nodes[id]
+	if !ok || visiting[id] {
+		return 1
+	}
+	visiting[id] = true
+	r := 1.0
+	if !n.root {
+		r = 0
+		for _, e := range inputs[id] {
+			r += e.share * s.ratio(e.From, inputs, memo, visiting)
+		}
+	}
+	visiting[id] = false
+	r *= n.scale
+	memo[id] = r
+	return r
+}
+
+func (s *sim) graph(containment []graph.Edge) *graph.Graph {
+	out := &graph.Graph{Nodes: make(map[string]*graph.Node, len(s.nodes)), Edges: make([]graph.Edge, 0, len(s.edges))}
+	inputs := make(map[string][]simEdge, len(s.nodes))
+	for _, e := range s.edges {
+		inputs[e.To] = append(inputs[e.To], e)
+	}
+	memo := make(map[string]float64, len(s.nodes))
+	visiting := map[string]bool{}
+	for id, n := range s.nodes {
+		node := n.node
+		node.Count = int(math.Round(float64(n.node.Count) * s.ratio(id, inputs, memo, visiting)))
+		out.Nodes[id] = &node
+	}
+	for _, e := range s.edges {
+		out.AddEdge(e.Edge)
+	}
+	for _, e := range containment {
+		out.AddContainment(e.From, e.To)
+	}
+	return out
+}
@@ -0,0 +1,212 @@
+package simulate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+// chain builds tap → model → {list, header, footer}, with the list also fed
+// by a timer firing as often as the tap
+func chain() *graph.Graph {
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "tap", Label: "Tap", Type: graph.NodeCause, Count: 40})
+	g.UpsertNode(&graph.Node{ID: "timer", Label: "Timer", Type: graph.NodeCause, Count: 40})
+	g.UpsertNode(&graph.Node{ID: "model", Label: "Model", Type: graph.NodeState, Count: 40})
+	g.UpsertNode(&graph.Node{ID: "list", Label: "ListView", Type: graph.NodeView, Count: 80})
+	g.UpsertNode(&graph.Node{ID: "header", Label: "HeaderView", Type: graph.NodeView, Count: 40})
+	g.UpsertNode(&graph.Node{ID: "footer", Label: "FooterView", Type: graph.NodeView, Count: 40})
+	g.AddEdge(graph.Edge{From: "tap", To: "model"})
+	g.AddEdge(graph.Edge{From: "model", To: "list"})
+	g.AddEdge(graph.Edge{From: "timer", To: "list"})
+	g.AddEdge(graph.Edge{From: "model", To: "header"})
+	g.AddEdge(graph.Edge{From: "model", To: "footer"})
+	return g
+}
+
+func counts(g *graph.Graph) map[string]int {
+	out := map[string]int{}
+	for id, n := range g.Nodes {
+		out[id] = n.Count
+	}
+	return out
+}
+
+func TestApplyNoEditsKeepsCounts(t *testing.T) {
+	g := chain()
+	out, err := Apply(g, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, c := range counts(out) {
+		if c != g.Nodes[id].Count {
+			t.Errorf("%s: got %d, want %d", id, c, g.Nodes[id].Count)
+		}
+	}
+	if len(out.Edges) != len(g.Edges) {
+		t.Errorf("edges: got %d, want %d", len(out.Edges), len(g.Edges))
+	}
+}
+
+func TestApplyDropEdge(t *testing.T) {
+	out, err := Apply(chain(), []Edit{{Op: OpDropEdge, From: "timer", To: "list"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The timer and the model fired equally often, so each accounted for
+	// half of the list's updates
+	if c := out.Nodes["list"].Count; c != 40 {
+		t.Errorf("list: got %d, want 40", c)
+	}
+	if c := out.Nodes["header"].Count; c != 40 {
+		t.Errorf("header: got %d, want 40", c)
+	}
+}
+
+func TestApplyScalePropagates(t *testing.T) {
+	out, err := Apply(chain(), []Edit{{Op: OpScale, Node: "tap", Factor: 0.25}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"tap": 10, "timer": 40, "model": 10, "list": 50, "header": 10, "footer": 10}
+	for id, c := range counts(out) {
+		if c != want[id] {
+			t.Errorf("%s: got %d, want %d", id, c, want[id])
+		}
+	}
+}
+
+func TestApplySplitState(t *testing.T) {
+	out, err := Apply(chain(), []Edit{{Op: OpSplitState, Node: "model"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := out.Nodes["model"]; ok {
+		t.Error("split node should be replaced by its parts")
+	}
+	part, ok := out.Nodes["model#2"]
+	if !ok || part.Label != "Model (HeaderView)" || len(out.Successors("model#2")) != 1 {
+		t.Fatalf("part: got %+v, successors %v", part, out.Successors("model#2"))
+	}
+	if len(out.Predecessors("model#2")) != 1 {
+		t.Errorf("part should keep the model's input, got %v", out.Predecessors("model#2"))
+	}
+	// Three observers: each part changes a third as often
+	want := map[string]int{"list": 53, "header": 13, "footer": 13}
+	for id, c := range want {
+		if got := out.Nodes[id].Count; got != c {
+			t.Errorf("%s: got %d, want %d", id, got, c)
+		}
+	}
+}
+
+func TestApplyBypass(t *testing.T) {
+	out, err := Apply(chain(), []Edit{{Op: OpBypass, Node: "model"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := out.Nodes["model"]; ok {
+		t.Error("bypassed node should be removed")
+	}
+	succ := out.Successors("tap")
+	if len(succ) != 3 {
+		t.Errorf("tap should feed the model's views directly, got %v", succ)
+	}
+	for id, c := range counts(out) {
+		if c != chain().Nodes[id].Count {
+			t.Errorf("%s: bypass should not change counts, got %d", id, c)
+		}
+	}
+}
+
+func TestApplyUnweightedInputs(t *testing.T) {
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "a", Label: "A", Type: graph.NodeState})
+	g.UpsertNode(&graph.Node{ID: "b", Label: "B", Type: graph.NodeState})
+	g.UpsertNode(&graph.Node{ID: "v", Label: "V", Type: graph.NodeView, Count: 30})
+	g.AddEdge(graph.Edge{From: "a", To: "v"})
+	g.AddEdge(graph.Edge{From: "b", To: "v"})
+
+	out, err := Apply(g, []Edit{{Op: OpDropEdge, From: "a", To: "v"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := out.Nodes["v"].Count; c != 15 {
+		t.Errorf("inputs without counts share equally: got %d, want 15", c)
+	}
+}
+
+func TestApplyCycle(t *testing.T) {
+	g := chain()
+	g.AddEdge(graph.Edge{From: "list", To: "model"})
+	out, err := Apply(g, []Edit{{Op: OpScale, Node: "timer", Factor: 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := out.Nodes["list"].Count; c >= 80 || c == 0 {
+		t.Errorf("list: got %d, want a partial reduction", c)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	for _, tc := range []struct {
+		edit Edit
+		want string
+	}{
+		{Edit{Op: OpDropEdge, From: "tap", To: "list"}, "no edge"},
+		{Edit{Op: OpScale, Node: "nope", Factor: 0.5}, "no node"},
+		{Edit{Op: OpScale, Node: "tap", Factor: -1}, "negative"},
+		{Edit{Op: OpBypass, Node: "tap"}, "does not relay"},
+		{Edit{Op: "rename", Node: "tap"}, "unknown edit"},
+	} {
+		_, err := Apply(chain(), []Edit{tc.edit})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%v: got %v, want %q", tc.edit, err, tc.want)
+		}
+	}
+}
+
+func TestApplyLeavesInputAlone(t *testing.T) {
+	g := chain()
+	if _, err := Apply(g, []Edit{{Op: OpSplitState, Node: "model"}, {Op: OpScale, Node: "tap", Factor: 0.5}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.Nodes["model"]; !ok || len(g.Edges) != 5 || g.Nodes["tap"].Count != 40 {
+		t.Error("Apply modified its input graph")
+	}
+}
+
+func TestApplySplitParts(t *testing.T) {
+	out, err := Apply(chain(), []Edit{{Op: OpSplitState, Node: "model", Parts: []Part{
+		{Name: "items", Views: []string{"list"}},
+		{Name: "title", Views: []string{"header"}},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, ok := out.Nodes["model#items"]
+	if !ok || items.Label != "Model.items" || items.Count != 20 {
+		t.Fatalf("items part: got %+v", items)
+	}
+	if succ := out.Successors("model#title"); len(succ) != 2 || succ[0] != "header" || succ[1] != "footer" {
+		t.Errorf("title part should feed its view and the unassigned footer, got %v", succ)
+	}
+	// The list gets half of the model's changes; the footer, reading both
+	// parts, all of them
+	want := map[string]int{"list": 60, "header": 20, "footer": 40}
+	for id, c := range want {
+		if got := out.Nodes[id].Count; got != c {
+			t.Errorf("%s: got %d, want %d", id, got, c)
+		}
+	}
+
+	for _, parts := range [][]Part{
+		{{Name: "a", Views: []string{"list"}}, {Name: "a", Views: []string{"header"}}},
+		{{Name: "a", Views: []string{"tap"}}},
+	} {
+		if _, err := Apply(chain(), []Edit{{Op: OpSplitState, Node: "model", Parts: parts}}); err == nil {
+			t.Errorf("%+v: expected an error", parts)
+		}
+	}
+}
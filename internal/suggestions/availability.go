@@ -0,0 +1,84 @@
+package suggestions
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/project"
+)
+
+// Availability records whether a fix or recommendation can be used with the
+// project's deployment target. Without a known target it is assumed usable
+// and the reason says the requirement is unchecked.
+type Availability struct {
+	Requires   string `json:"requires"` // e.g. "iOS 17.0, Swift 5.9+"
+	Compatible bool   `json:"compatible"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// unknownTarget is the reason given for requirements no target was
+// checked against
+const unknownTarget = "deployment target unknown"
+
+func availability(minIOS, swift string, t project.Target) *Availability {
+	if minIOS == "" && swift == "" {
+		return nil
+	}
+	var req []string
+	if minIOS != "" {
+		req = append(req, "iOS "+minIOS)
+	}
+	if swift != "" {
+		req = append(req, "Swift "+swift)
+	}
+	if !t.Known() {
+		return &Availability{Requires: strings.Join(req, ", "), Compatible: true, Reason: unknownTarget}
+	}
+	ok, reason := t.Supports(minIOS, swift)
+	return &Availability{Requires: strings.Join(req, ", "), Compatible: ok, Reason: reason}
+}
+
+// Unchecked reports whether the requirements were not checked against a
+// deployment target
+func (a *Availability) Unchecked() bool {
+	return a != nil && a.Compatible && a.Reason == unknownTarget
+}
+
+// ApplyTarget marks each fix's availability for the target and moves the
+// incompatible ones after the rest, or drops them. Without a known target
+// fixes keep their order and only their requirements are marked.
+func ApplyTarget(fixes []Fix, t project.Target, drop bool) []Fix {
+	out := make([]Fix, 0, len(fixes))
+	var later []Fix
+	for _, f := range fixes {
+		f.Availability = availability(f.MinIOS, f.SwiftVersion, t)
+		switch {
+		case f.Availability == nil || f.Availability.Compatible:
+			out = append(out, f)
+		case !drop:
+			later = append(later, f)
+		}
+	}
+	return append(out, later...)
+}
+
+// ApplyTargetRecommendations does the same for recommendations, moving
+// incompatible ones after the rest, each group by priority
+func ApplyTargetRecommendations(recs []Recommendation, t project.Target, drop bool) []Recommendation {
+	out := make([]Recommendation, 0, len(recs))
+	for _, r := range recs {
+		r.Availability = availability(r.MinIOS, "", t)
+		if drop && r.Availability != nil && !r.Availability.Compatible {
+			continue
+		}
+		out = append(out, r)
+	}
+	usable := func(r Recommendation) bool { return r.Availability == nil || r.Availability.Compatible }
+	sort.SliceStable(out, func(i, j int) bool {
+		if usable(out[i]) != usable(out[j]) {
+			return usable(out[i])
+		}
+		return out[i].Priority < out[j].Priority
+	})
+	return out
+}
@@ -0,0 +1,66 @@
+package suggestions
+
+import (
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/project"
+)
+
+func TestApplyTarget(t *testing.T) {
+	fixes := GenerateFixes(issues.Issue{Type: issues.IssueExcessiveRerender})
+	ios15 := project.Target{IOS: "15.0"}
+
+	ranked := ApplyTarget(fixes, ios15, false)
+	if len(ranked) != len(fixes) {
+		t.Fatalf("expected %d fixes, got %d", len(fixes), len(ranked))
+	}
+	last := ranked[len(ranked)-1]
+	if last.ID != "observable-macro" || last.Availability == nil || last.Availability.Compatible ||
+		last.Availability.Requires != "iOS 17.0, Swift 5.9+" || last.Availability.Reason != "needs iOS 17.0, deployment target is iOS 15.0" {
+		t.Errorf("expected @Observable ranked last as incompatible, got %+v", last)
+	}
+	if ranked[0].ID != "equatable-view" || ranked[0].Availability != nil {
+		t.Errorf("fixes without requirements keep their order and get no availability: %+v", ranked[0])
+	}
+
+	for _, f := range ApplyTarget(fixes, ios15, true) {
+		if f.ID == "observable-macro" {
+			t.Error("expected the incompatible fix to be dropped")
+		}
+	}
+	ok := ApplyTarget(fixes, project.Target{IOS: "17.2"}, true)
+	for _, f := range ok {
+		if f.ID == "observable-macro" && (f.Availability == nil || !f.Availability.Compatible) {
+			t.Errorf("expected a compatible availability on iOS 17.2, got %+v", f.Availability)
+		}
+	}
+	unknown := ApplyTarget(fixes, project.Target{}, true)
+	if len(unknown) != len(fixes) || unknown[2].ID != "observable-macro" {
+		t.Fatal("an unknown target must keep every fix in order")
+	}
+	if a := unknown[2].Availability; !a.Unchecked() || a.Requires != "iOS 17.0, Swift 5.9+" {
+		t.Errorf("expected the requirement marked unchecked, got %+v", a)
+	}
+}
+
+func TestApplyTargetRecommendations(t *testing.T) {
+	recs := GenerateRecommendations([]issues.Issue{
+		{Type: issues.IssueExcessiveRerender},
+		{Type: issues.IssueWholeObjectPassing},
+	})
+	ranked := ApplyTargetRecommendations(recs, project.Target{IOS: "16.0"}, false)
+	if ranked[0].Title != "Pass only required data to child views" {
+		t.Errorf("expected the compatible recommendation first, got %q", ranked[0].Title)
+	}
+	last := ranked[len(ranked)-1]
+	if last.MinIOS != "17.0" || last.Availability == nil || last.Availability.Compatible {
+		t.Errorf("expected the @Observable recommendation last, got %+v", last)
+	}
+	if last.Priority != recs[0].Priority {
+		t.Errorf("ranking changed the priority from %d to %d", recs[0].Priority, last.Priority)
+	}
+	if n := len(ApplyTargetRecommendations(recs, project.Target{IOS: "16.0"}, true)); n != len(recs)-1 {
+		t.Errorf("expected one recommendation dropped, got %d of %d", n, len(recs))
+	}
+}
@@ -0,0 +1,413 @@
+package suggestions
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/simulate"
+)
+
+//go:embed catalog/*.json
+var builtinFS embed.FS
+
+// BuiltinSource is the Source of entries shipped with swiftuice
+const BuiltinSource = "builtin"
+
+// FixesPathEnv lists extra catalog directories (os.PathListSeparator
+// separated), searched after the user config directory
+const FixesPathEnv = "SWIFTUICE_FIXES"
+
+// Entry is a catalog fix with the rules deciding when it is suggested. A
+// fix applies to the issue types in ApplicableTo; When narrows that down.
+type Entry struct {
+	Fix
+	When *When `json:"when,omitempty"`
+
+	// Placeholder identifiers in the code samples and the role they play
+	// (view, model, property, state), substituted with the project's names
+	Symbols map[string]string `json:"symbols,omitempty"`
+
+	// How the fix changes the cause-effect graph, for estimating its payoff
+	Effect *simulate.Effect `json:"effect,omitempty"`
+
+	Source string `json:"-"` // BuiltinSource or the file the entry came from
+}
+
+// When holds the conditions a fix needs besides the issue type. Conditions
+// on the source (imports, model type) never match without correlation.
+type When struct {
+	MinSeverity     issues.Severity `json:"min_severity,omitempty"`
+	MinUpdateCount  int             `json:"min_update_count,omitempty"`
+	MinCascadeDepth int             `json:"min_cascade_depth,omitempty"`
+	Imports         []string        `json:"imports,omitempty"`    // the view's file imports any of these modules
+	ModelType       string          `json:"model_type,omitempty"` // regex on the view's observed model type
+}
+
+// Problem is a catalog lint finding
+type Problem struct {
+	Path    string `json:"path"`
+	FixID   string `json:"fix_id,omitempty"`
+	Message string `json:"message"`
+	Warning bool   `json:"warning,omitempty"`
+}
+
+func (p Problem) String() string {
+	level := "error"
+	if p.Warning {
+		level = "warning"
+	}
+	if p.FixID == "" {
+		return fmt.Sprintf("%s: %s: %s", p.Path, level, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s: %s", p.Path, p.FixID, level, p.Message)
+}
+
+// Catalog is an ordered set of fixes
+type Catalog struct {
+	entries []Entry
+}
+
+type catalogFile struct {
+	Fixes []Entry `json:"fixes"`
+}
+
+var (
+	builtinOnce sync.Once
+	builtin     *Catalog
+)
+
+// Builtin returns the catalog shipped with swiftuice
+func Builtin() *Catalog {
+	builtinOnce.Do(func() {
+		entries, problems := readBuiltin()
+		for _, p := range problems {
+			if !p.Warning {
+				panic("suggestions: invalid built-in catalog: " + p.String())
+			}
+		}
+		builtin = &Catalog{entries: entries}
+	})
+	return builtin
+}
+
+// UserDirs returns the catalog directories that exist: swiftuice/fixes in
+// the user config directory, then those listed in $SWIFTUICE_FIXES
+func UserDirs() []string {
+	var dirs []string
+	if cfg, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(cfg, "swiftuice", "fixes"))
+	}
+	dirs = append(dirs, filepath.SplitList(os.Getenv(FixesPathEnv))...)
+	var existing []string
+	for _, d := range dirs {
+		if info, err := os.Stat(d); err == nil && info.IsDir() {
+			existing = append(existing, d)
+		}
+	}
+	return existing
+}
+
+// LoadCatalog returns the built-in catalog extended with the *.json files
+// of dirs. A fix with the ID of an earlier one replaces it in place; new
+// fixes are appended. Any lint error fails the load.
+func LoadCatalog(dirs []string) (*Catalog, error) {
+	entries, problems := lint(dirs)
+	for _, p := range problems {
+		if !p.Warning {
+			return nil, fmt.Errorf("invalid fix catalog: %s", p)
+		}
+	}
+	return &Catalog{entries: entries}, nil
+}
+
+// Lint checks the built-in catalog and the *.json files of dirs
+func Lint(dirs []string) []Problem {
+	_, problems := lint(dirs)
+	return problems
+}
+
+func lint(dirs []string) ([]Entry, []Problem) {
+	entries, problems := readBuiltin()
+	index := map[string]int{}
+	for i, e := range entries {
+		index[e.ID] = i
+	}
+	for _, dir := range dirs {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil || len(paths) == 0 {
+			problems = append(problems, Problem{Path: dir, Message: "no *.json catalog files", Warning: true})
+			continue
+		}
+		sort.Strings(paths)
+		for _, p := range paths {
+			data, err := os.ReadFile(p)
+			if err != nil {
+				problems = append(problems, Problem{Path: p, Message: err.Error()})
+				continue
+			}
+			fileEntries, fileProblems := parseCatalog(p, data)
+			problems = append(problems, fileProblems...)
+			for _, e := range fileEntries {
+				i, ok := index[e.ID]
+				switch {
+				case !ok:
+					index[e.ID] = len(entries)
+					entries = append(entries, e)
+				case entries[i].Source == BuiltinSource:
+					problems = append(problems, Problem{Path: p, FixID: e.ID, Message: "overrides the built-in fix", Warning: true})
+					entries[i] = e
+				default:
+					problems = append(problems, Problem{Path: p, FixID: e.ID, Message: "duplicate ID, also defined in " + entries[i].Source})
+				}
+			}
+		}
+	}
+	return entries, problems
+}
+
+func readBuiltin() ([]Entry, []Problem) {
+	paths, _ := fs.Glob(builtinFS, "catalog/*.json")
+	sort.Strings(paths)
+	var entries []Entry
+	var problems []Problem
+	seen := map[string]string{}
+	for _, p := range paths {
+		data, _ := builtinFS.ReadFile(p)
+		fileEntries, fileProblems := parseCatalog(p, data)
+		problems = append(problems, fileProblems...)
+		for _, e := range fileEntries {
+			if prev, ok := seen[e.ID]; ok {
+				problems = append(problems, Problem{Path: p, FixID: e.ID, Message: "duplicate ID, also defined in " + prev})
+				continue
+			}
+			seen[e.ID] = p
+			e.Source = BuiltinSource
+			entries = append(entries, e)
+		}
+	}
+	return entries, problems
+}
+
+var (
+	fixIDPattern   = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+	versionPattern = regexp.MustCompile(`^\d+(?:\.\d+)*\+?$`)
+	levels         = map[string]bool{"low": true, "medium": true, "high": true}
+	roles          = map[string]bool{RoleView: true, RoleModel: true, RoleProperty: true, RoleState: true}
+)
+
+// parseCatalog decodes one catalog file and validates its entries; only
+// valid entries are returned
+func parseCatalog(name string, data []byte) ([]Entry, []Problem) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var file catalogFile
+	if err := dec.Decode(&file); err != nil {
+		return nil, []Problem{{Path: name, Message: err.Error()}}
+	}
+	if len(file.Fixes) == 0 {
+		return nil, []Problem{{Path: name, Message: "no fixes", Warning: true}}
+	}
+	var entries []Entry
+	var problems []Problem
+	for i, e := range file.Fixes {
+		id := e.ID
+		if id == "" {
+			id = fmt.Sprintf("fixes[%d]", i)
+		}
+		entryProblems := validate(e)
+		for _, msg := range entryProblems {
+			problems = append(problems, Problem{Path: name, FixID: id, Message: msg.message, Warning: msg.warning})
+		}
+		if !hasError(entryProblems) {
+			e.Source = name
+			entries = append(entries, e)
+		}
+	}
+	return entries, problems
+}
+
+type finding struct {
+	message string
+	warning bool
+}
+
+func hasError(fs []finding) bool {
+	for _, f := range fs {
+		if !f.warning {
+			return true
+		}
+	}
+	return false
+}
+
+func validate(e Entry) []finding {
+	var out []finding
+	errorf := func(format string, args ...any) {
+		out = append(out, finding{message: fmt.Sprintf(format, args...)})
+	}
+	warnf := func(format string, args ...any) {
+		out = append(out, finding{message: fmt.Sprintf(format, args...), warning: true})
+	}
+
+	if !fixIDPattern.MatchString(e.ID) {
+		errorf("id %q must be lower-case words joined by hyphens", e.ID)
+	}
+	if e.Approach == "" {
+		errorf("approach is required")
+	}
+	if e.Description == "" {
+		errorf("description is required")
+	}
+	if len(e.Steps) == 0 {
+		errorf("at least one step is required")
+	}
+	if !levels[e.Effort] {
+		errorf("effort must be low, medium or high, got %q", e.Effort)
+	}
+	if !levels[e.Impact] {
+		errorf("impact must be low, medium or high, got %q", e.Impact)
+	}
+	if len(e.ApplicableTo) == 0 {
+		errorf("applicable_to needs at least one issue type")
+	}
+	for _, t := range e.ApplicableTo {
+		if !knownType(t) {
+			errorf("unknown issue type %q in applicable_to", t)
+		}
+	}
+	if e.MinIOS != "" && !versionPattern.MatchString(e.MinIOS) {
+		errorf("min_ios %q is not a version such as 17.0", e.MinIOS)
+	}
+	if e.SwiftVersion != "" && !versionPattern.MatchString(e.SwiftVersion) {
+		errorf("swift_version %q is not a version such as 5.9+", e.SwiftVersion)
+	}
+	if e.Tailored {
+		errorf("tailored is set by swiftuice, not by the catalog")
+	}
+	if e.Expected != nil {
+		errorf("expected is set by swiftuice, not by the catalog")
+	}
+	if e.Effect != nil {
+		if err := e.Effect.Validate(); err != nil {
+			errorf("effect: %v", err)
+		}
+	}
+	if w := e.When; w != nil {
+		if w.MinSeverity != "" {
+			if _, err := issues.ParseSeverity(string(w.MinSeverity)); err != nil {
+				errorf("when.min_severity: %v", err)
+			}
+		}
+		if w.MinUpdateCount < 0 || w.MinCascadeDepth < 0 {
+			errorf("when minimums cannot be negative")
+		}
+		if w.ModelType != "" {
+			if _, err := regexp.Compile(w.ModelType); err != nil {
+				errorf("when.model_type: %v", err)
+			}
+		}
+	}
+	idents := make([]string, 0, len(e.Symbols))
+	for ident := range e.Symbols {
+		idents = append(idents, ident)
+	}
+	sort.Strings(idents)
+	for _, ident := range idents {
+		if !roles[e.Symbols[ident]] {
+			errorf("symbol %s has unknown role %q (view, model, property or state)", ident, e.Symbols[ident])
+			continue
+		}
+		re := regexp.MustCompile(`\b` + regexp.QuoteMeta(ident) + `\b`)
+		if !re.MatchString(e.CodeBefore) && !re.MatchString(e.CodeAfter) {
+			warnf("symbol %s does not occur in the code samples", ident)
+		}
+	}
+	return out
+}
+
+func knownType(t string) bool {
+	for _, known := range issues.DetectorTypes {
+		if string(known) == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Entries returns the catalog's entries in order
+func (c *Catalog) Entries() []Entry {
+	return c.entries
+}
+
+// Lookup returns the entry with the given fix ID
+func (c *Catalog) Lookup(id string) (Entry, bool) {
+	for _, e := range c.entries {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Fixes returns the fixes for an issue in catalog order: those whose
+// primary issue type (the first of ApplicableTo) it is. Code samples and
+// steps use the context's names where the entry declares symbols for them.
+func (c *Catalog) Fixes(issue issues.Issue, ctx Context) []Fix {
+	var fixes []Fix
+	for _, e := range c.entries {
+		if len(e.ApplicableTo) == 0 || e.ApplicableTo[0] != string(issue.Type) || !e.When.matches(issue, ctx) {
+			continue
+		}
+		fix := e.Fix
+		fix.Steps = append([]string(nil), e.Steps...)
+		fix.ApplicableTo = append([]string(nil), e.ApplicableTo...)
+		fix.References = append([]string(nil), e.References...)
+		tailor(&fix, e.Symbols, ctx)
+		fixes = append(fixes, fix)
+	}
+	return fixes
+}
+
+func (w *When) matches(issue issues.Issue, ctx Context) bool {
+	if w == nil {
+		return true
+	}
+	if w.MinSeverity != "" && !issue.Severity.AtLeast(w.MinSeverity) {
+		return false
+	}
+	if issue.UpdateCount < w.MinUpdateCount || issue.CascadeDepth < w.MinCascadeDepth {
+		return false
+	}
+	if len(w.Imports) > 0 && !anyImported(w.Imports, ctx.Imports) {
+		return false
+	}
+	if w.ModelType != "" {
+		if ctx.ModelType == "" {
+			return false
+		}
+		if ok, _ := regexp.MatchString(w.ModelType, ctx.ModelType); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func anyImported(want, imported []string) bool {
+	for _, w := range want {
+		for _, i := range imported {
+			if w == i {
+				return true
+			}
+		}
+	}
+	return false
+}
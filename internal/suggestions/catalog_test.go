@@ -0,0 +1,170 @@
+package suggestions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+)
+
+func TestBuiltinCatalogLintsClean(t *testing.T) {
+	for _, p := range Lint(nil) {
+		t.Errorf("built-in catalog: %s", p)
+	}
+	for _, typ := range issues.DetectorTypes {
+		if len(GenerateFixes(issues.Issue{Type: typ})) == 0 {
+			t.Errorf("no built-in fixes for %s", typ)
+		}
+	}
+	for _, e := range Builtin().Entries() {
+		if e.Effect == nil || e.Effect.Assumption == "" {
+			t.Errorf("built-in fix %s has no effect to estimate with", e.ID)
+		}
+	}
+}
+
+func TestCatalogFixesPerType(t *testing.T) {
+	// Each issue type gets the fixes filed under it, as before the catalog
+	// moved to data files; a fix also listing other types is not repeated
+	// for them
+	want := map[issues.IssueType]string{
+		issues.IssueExcessiveRerender:   "equatable-view,extract-subview,observable-macro",
+		issues.IssueCascadingUpdate:     "derived-state,split-state",
+		issues.IssueFrequentTrigger:     "debounce,throttle",
+		issues.IssueDeepDependencyChain: "flatten-hierarchy,direct-observation",
+		issues.IssueTimerCascade:        "timeline-view,limit-timer-scope",
+		issues.IssueWholeObjectPassing:  "pass-primitives,focused-protocol",
+	}
+	for typ, ids := range want {
+		var got []string
+		for _, f := range GenerateFixes(issues.Issue{Type: typ}) {
+			got = append(got, f.ID)
+		}
+		if strings.Join(got, ",") != ids {
+			t.Errorf("%s: got %s, want %s", typ, strings.Join(got, ","), ids)
+		}
+	}
+}
+
+const houseFixes = `{
+  "fixes": [
+    {
+      "id": "house-store-selector",
+      "approach": "Select a slice of the store",
+      "description": "Read only the state the view needs through a HouseStore selector.",
+      "applicable_to": ["excessive_rerender", "whole_object_passing"],
+      "when": {"imports": ["HouseStore"], "min_severity": "medium"},
+      "effort": "low",
+      "impact": "high",
+      "steps": ["Replace @ObservedObject var store with @Selected(\\.cart) in CartRow"],
+      "code_after": "struct CartRow: View {\n    @Selected(\\.cart) var cart\n}",
+      "symbols": {"CartRow": "view"}
+    },
+    {
+      "id": "equatable-view",
+      "approach": "EquatableView via our macro",
+      "description": "Use @HouseEquatable instead of hand-written ==.",
+      "applicable_to": ["excessive_rerender"],
+      "effort": "low",
+      "impact": "high",
+      "steps": ["Annotate the view with @HouseEquatable"]
+    }
+  ]
+}
+`
+
+func TestLoadCatalog_UserDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "house.json"), []byte(houseFixes), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadCatalog([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Entries()) != len(Builtin().Entries())+1 {
+		t.Errorf("expected one new fix, got %d entries", len(c.Entries()))
+	}
+	e, ok := c.Lookup("equatable-view")
+	if !ok || e.Approach != "EquatableView via our macro" || e.Source != filepath.Join(dir, "house.json") {
+		t.Errorf("expected the user fix to override the built-in one, got %+v", e)
+	}
+
+	issue := issues.Issue{Type: issues.IssueExcessiveRerender, Severity: issues.SeverityHigh}
+	has := func(fixes []Fix, id string) *Fix {
+		for i := range fixes {
+			if fixes[i].ID == id {
+				return &fixes[i]
+			}
+		}
+		return nil
+	}
+	// The import condition needs correlation
+	if has(c.Fixes(issue, Context{}), "house-store-selector") != nil {
+		t.Error("house fix suggested without source context")
+	}
+	fix := has(c.Fixes(issue, Context{ViewType: "BasketRow", Imports: []string{"SwiftUI", "HouseStore"}}), "house-store-selector")
+	if fix == nil {
+		t.Fatal("house fix not suggested for a view importing HouseStore")
+	}
+	if !strings.Contains(fix.CodeAfter, "struct BasketRow: View") || !strings.Contains(fix.Steps[0], "in BasketRow") {
+		t.Errorf("house fix not tailored: %+v", fix)
+	}
+	low := issues.Issue{Type: issues.IssueExcessiveRerender, Severity: issues.SeverityLow}
+	if has(c.Fixes(low, Context{Imports: []string{"HouseStore"}}), "house-store-selector") != nil {
+		t.Error("min_severity not applied")
+	}
+
+	// Loading a catalog leaves the built-in one alone
+	if f := has(GenerateFixes(issue), "equatable-view"); f == nil || f.Approach == "EquatableView via our macro" {
+		t.Error("GenerateFixes does not use the built-in catalog")
+	}
+}
+
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	bad := `{"fixes": [
+  {"id": "Bad_ID", "approach": "x", "description": "y", "applicable_to": ["slow_views"],
+   "effort": "tiny", "impact": "high", "steps": ["z"], "min_ios": "seventeen",
+   "when": {"min_severity": "urgent", "model_type": "("}, "effect": {"kind": "scale-views"},
+   "symbols": {"Missing": "view", "Thing": "widget"}},
+  {"id": "ok-fix", "approach": "x", "description": "y", "applicable_to": ["timer_cascade"],
+   "effort": "low", "impact": "low", "steps": ["z"]},
+  {"id": "ok-fix", "approach": "x", "description": "y", "applicable_to": ["timer_cascade"],
+   "effort": "low", "impact": "low", "steps": ["z"]}
+]}`
+	if err := os.WriteFile(filepath.Join(dir, "bad.json"), []byte(bad), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "typo.json"), []byte(`{"fixes": [{"identifier": "x"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, p := range Lint([]string{dir}) {
+		got = append(got, p.String())
+	}
+	text := strings.Join(got, "\n")
+	for _, want := range []string{
+		"Bad_ID: error: id \"Bad_ID\" must be lower-case",
+		"Bad_ID: error: unknown issue type \"slow_views\"",
+		"Bad_ID: error: effort must be low, medium or high",
+		"Bad_ID: error: min_ios \"seventeen\"",
+		"Bad_ID: error: when.min_severity",
+		"Bad_ID: error: when.model_type",
+		"Bad_ID: error: effect: scale-views needs a factor",
+		"Bad_ID: warning: symbol Missing does not occur",
+		"Bad_ID: error: symbol Thing has unknown role \"widget\"",
+		"ok-fix: error: duplicate ID",
+		"typo.json: error: json: unknown field \"identifier\"",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("lint output missing %q:\n%s", want, text)
+		}
+	}
+	if _, err := LoadCatalog([]string{dir}); err == nil {
+		t.Error("expected LoadCatalog to fail on lint errors")
+	}
+}
@@ -0,0 +1,93 @@
+package suggestions
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+)
+
+// Context is what source correlation found about an issue: the names to
+// use in code samples instead of the catalog's placeholders.
+type Context struct {
+	ViewType   string   `json:"view_type,omitempty"`  // View struct of the first correlated view node
+	Property   string   `json:"property,omitempty"`   // the view's observed model property
+	ModelType  string   `json:"model_type,omitempty"` // that property's type
+	StateName  string   `json:"state_name,omitempty"` // the view's first @State property
+	Imports    []string `json:"imports,omitempty"`    // modules the view's file imports
+	Snippet    string   `json:"snippet,omitempty"`
+	SourceFile string   `json:"source_file,omitempty"`
+	LineNumber int      `json:"line_number,omitempty"`
+}
+
+// Empty reports whether the context has no names to substitute
+func (c Context) Empty() bool {
+	return c.ViewType == "" && c.Property == "" && c.ModelType == "" && c.StateName == ""
+}
+
+// Symbol roles in catalog samples
+const (
+	RoleView     = "view"
+	RoleModel    = "model"
+	RoleProperty = "property"
+	RoleState    = "state"
+)
+
+func (c Context) value(role string) string {
+	switch role {
+	case RoleView:
+		return c.ViewType
+	case RoleModel:
+		return c.ModelType
+	case RoleProperty:
+		return c.Property
+	case RoleState:
+		return c.StateName
+	}
+	return ""
+}
+
+// GenerateFixesWithContext returns the fixes for an issue with their code
+// samples and steps rewritten to use the project's own names. Roles the
+// context does not know keep the catalog's placeholder, so an empty
+// context yields the generic samples. Fixes whose catalog conditions need
+// source (imports, model type) are only suggested with a context.
+func GenerateFixesWithContext(issue issues.Issue, ctx Context) []Fix {
+	return Builtin().Fixes(issue, ctx)
+}
+
+// tailor substitutes the context's names for a fix's sample symbols
+func tailor(fix *Fix, symbols map[string]string, ctx Context) {
+	names := map[string]string{}
+	for ident, role := range symbols {
+		if v := ctx.value(role); v != "" && v != ident {
+			names[ident] = v
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	idents := make([]string, 0, len(names))
+	for ident := range names {
+		idents = append(idents, regexp.QuoteMeta(ident))
+	}
+	sort.Strings(idents)
+	re := regexp.MustCompile(`\b(` + strings.Join(idents, "|") + `)\b`)
+	replace := func(s string) string {
+		return re.ReplaceAllStringFunc(s, func(m string) string { return names[m] })
+	}
+
+	fix.CodeBefore = replace(fix.CodeBefore)
+	fix.CodeAfter = replace(fix.CodeAfter)
+	steps := make([]string, 0, len(fix.Steps)+1)
+	if ctx.SourceFile != "" && ctx.ViewType != "" {
+		steps = append(steps, fmt.Sprintf("Open %s at %s:%d", ctx.ViewType, ctx.SourceFile, ctx.LineNumber))
+	}
+	for _, s := range fix.Steps {
+		steps = append(steps, replace(s))
+	}
+	fix.Steps = steps
+	fix.Tailored = true
+}
@@ -0,0 +1,80 @@
+package suggestions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+)
+
+func TestGenerateFixesWithContext(t *testing.T) {
+	issue := issues.Issue{Type: issues.IssueExcessiveRerender, Severity: issues.SeverityHigh}
+	ctx := Context{
+		ViewType:   "CartView",
+		Property:   "cart",
+		ModelType:  "CartModel",
+		StateName:  "quantity",
+		SourceFile: "Views/CartView.swift",
+		LineNumber: 12,
+	}
+
+	fixes := GenerateFixesWithContext(issue, ctx)
+	byID := map[string]Fix{}
+	for _, f := range fixes {
+		byID[f.ID] = f
+	}
+
+	obs := byID["observable-macro"]
+	if !obs.Tailored {
+		t.Error("expected observable-macro to be tailored")
+	}
+	for _, want := range []string{"class CartModel {", "struct CartView: View {", "var cart: CartModel"} {
+		if !strings.Contains(obs.CodeAfter, want) {
+			t.Errorf("CodeAfter missing %q:\n%s", want, obs.CodeAfter)
+		}
+	}
+	if strings.Contains(obs.CodeBefore, "UserViewModel") || strings.Contains(obs.CodeBefore, "ProfileView") {
+		t.Errorf("placeholders left in CodeBefore:\n%s", obs.CodeBefore)
+	}
+	if obs.Steps[0] != "Open CartView at Views/CartView.swift:12" {
+		t.Errorf("unexpected first step %q", obs.Steps[0])
+	}
+
+	eq := byID["equatable-view"]
+	if !strings.Contains(eq.CodeAfter, "static func == (lhs: CartView, rhs: CartView)") {
+		t.Errorf("equatable sample not tailored:\n%s", eq.CodeAfter)
+	}
+	if !strings.Contains(eq.Steps[len(eq.Steps)-1], "EquatableView(content: CartView(item: item))") {
+		t.Errorf("equatable step not tailored: %q", eq.Steps[len(eq.Steps)-1])
+	}
+
+	sub := byID["extract-subview"]
+	if !strings.Contains(sub.CodeBefore, "(quantity)") || strings.Contains(sub.CodeBefore, "ContentView") {
+		t.Errorf("extract-subview sample not tailored:\n%s", sub.CodeBefore)
+	}
+}
+
+func TestGenerateFixesWithContext_Fallback(t *testing.T) {
+	issue := issues.Issue{Type: issues.IssueTimerCascade, Severity: issues.SeverityHigh}
+	generic := GenerateFixes(issue)
+
+	fixes := GenerateFixesWithContext(issue, Context{})
+	for i := range fixes {
+		if fixes[i].Tailored || fixes[i].CodeAfter != generic[i].CodeAfter {
+			t.Errorf("%s: expected the generic sample with an empty context", fixes[i].ID)
+		}
+	}
+
+	// Only the roles the context knows are substituted
+	fixes = GenerateFixesWithContext(issue, Context{ModelType: "Clock"})
+	for _, f := range fixes {
+		if f.Tailored {
+			t.Errorf("%s has no model placeholder and should stay generic", f.ID)
+		}
+	}
+	fixes = GenerateFixesWithContext(issue, Context{ViewType: "DashboardView"})
+	if !strings.Contains(fixes[0].CodeAfter, "struct DashboardView: View") ||
+		!strings.Contains(fixes[0].CodeAfter, "context.date") {
+		t.Errorf("unexpected timeline sample:\n%s", fixes[0].CodeAfter)
+	}
+}
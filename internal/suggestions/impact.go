@@ -0,0 +1,37 @@
+package suggestions
+
+import (
+	"sort"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/simulate"
+)
+
+// Estimate simulates each fix whose entry in the catalog declares an
+// effect on the issue's nodes and returns the expected impacts by fix ID
+func (c *Catalog) Estimate(fixes []Fix, est *simulate.Estimator, issue issues.Issue) map[string]*simulate.Impact {
+	out := map[string]*simulate.Impact{}
+	for _, f := range fixes {
+		if e, ok := c.Lookup(f.ID); ok && e.Effect != nil {
+			if im := est.Estimate(issue, *e.Effect); im != nil {
+				out[f.ID] = im
+			}
+		}
+	}
+	return out
+}
+
+// RankByPayoff sets each fix's expected impact from the estimates by fix
+// ID, then orders the fixes by payoff: score gained, then view updates
+// saved. Fixes without an estimate keep their order after those with one.
+func RankByPayoff(fixes []Fix, expected map[string]*simulate.Impact) []Fix {
+	out := make([]Fix, len(fixes))
+	for i, f := range fixes {
+		f.Expected = expected[f.ID]
+		out[i] = f
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return simulate.Payoff(out[i].Expected, out[j].Expected) > 0
+	})
+	return out
+}
@@ -0,0 +1,56 @@
+package suggestions
+
+import (
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/simulate"
+)
+
+func TestEstimateRanksByPayoff(t *testing.T) {
+	g := graph.New()
+	g.UpsertNode(&graph.Node{ID: "tap", Label: "Tap", Type: graph.NodeCause, Count: 30})
+	g.UpsertNode(&graph.Node{ID: "store", Label: "CartStore", Type: graph.NodeState, Count: 30})
+	for _, v := range []string{"CartView", "BadgeView", "TotalView"} {
+		g.UpsertNode(&graph.Node{ID: v, Label: v, Type: graph.NodeView, Count: 30})
+		g.AddEdge(graph.Edge{From: "store", To: v})
+	}
+	g.AddEdge(graph.Edge{From: "tap", To: "store"})
+
+	d := issues.NewDetector()
+	detected := d.Detect(g)
+	var cascade issues.Issue
+	for _, is := range detected {
+		if is.Type == issues.IssueCascadingUpdate {
+			cascade = is
+		}
+	}
+	fixes := GenerateFixes(cascade)
+	if fixes[0].ID != "derived-state" {
+		t.Fatalf("catalog order changed: first fix is %s", fixes[0].ID)
+	}
+
+	ranked := RankByPayoff(fixes, Builtin().Estimate(fixes, simulate.NewEstimator(g, d, detected), cascade))
+	if len(ranked) != len(fixes) {
+		t.Fatalf("got %d fixes, want %d", len(ranked), len(fixes))
+	}
+	for _, f := range ranked {
+		if f.Expected == nil && f.ID != "derived-state" {
+			t.Errorf("%s: no estimate", f.ID)
+		}
+	}
+	// Splitting the store resolves the cascade and saves updates; the store
+	// has one input, so bypassing it saves nothing
+	best := ranked[0].Expected
+	if best == nil || !best.ResolvesIssue || best.ViewUpdatesSaved != 60 || best.ScoreGain <= 0 {
+		t.Errorf("best fix %s: got %+v", ranked[0].ID, best)
+	}
+	last := ranked[len(ranked)-1]
+	if last.ID != "derived-state" || last.Expected == nil || last.Expected.ViewUpdatesSaved != 0 {
+		t.Errorf("derived-state should rank last with no savings, got %s %+v", last.ID, last.Expected)
+	}
+	if fixes[0].Expected != nil {
+		t.Error("RankByPayoff modified its input")
+	}
+}
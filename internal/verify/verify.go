@@ -0,0 +1,369 @@
+// Package verify checks the issues a fix plan targeted against a report of
+// a fresh recording: each must be resolved or improved by the margin its
+// fix was expected to achieve, without regressions elsewhere.
+package verify
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/compare"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/issues"
+)
+
+// DefaultTolerance is the share of a fix's expected savings a recording may
+// miss, and the growth an untouched view may show, before either counts
+// against the fix. Two recordings of the same interaction rarely match.
+const DefaultTolerance = 0.25
+
+// DefaultFailOn is the lowest severity of a new or worsened issue that
+// fails verification unless Options says otherwise
+const DefaultFailOn = issues.SeverityMedium
+
+// minRegression is the fewest extra updates that make a busier untouched
+// view a regression, so views updating a handful of times are not flagged
+// for noise
+const minRegression = 5
+
+// Status is what became of a targeted issue
+type Status string
+
+const (
+	StatusResolved  Status = "resolved"  // no longer detected
+	StatusImproved  Status = "improved"  // still detected, counts dropped enough
+	StatusUnchanged Status = "unchanged" // counts dropped too little, or not at all
+	StatusWorse     Status = "worse"     // counts grew beyond the tolerance
+)
+
+// Options selects the issues to check and how strictly
+type Options struct {
+	Issues    []string        // issue IDs in the base report; all planned issues when empty
+	Tasks     []string        // plan task IDs whose issues are checked
+	Tolerance *float64        // nil means DefaultTolerance; 0 allows no slack
+	FailOn    issues.Severity // lowest severity of a new issue that fails; empty means DefaultFailOn
+}
+
+// Result is the outcome of a verification
+type Result struct {
+	Pass        bool          `json:"pass"`
+	Verdict     string        `json:"verdict"`
+	BaseScore   int           `json:"base_score"`
+	HeadScore   int           `json:"head_score"`
+	Issues      []IssueResult `json:"issues"`
+	Regressions []Regression  `json:"regressions,omitempty"`
+}
+
+// IssueResult is the check of one targeted issue
+type IssueResult struct {
+	IssueID string       `json:"issue_id"`
+	Title   string       `json:"title"`
+	Task    string       `json:"task,omitempty"`
+	Fix     string       `json:"fix,omitempty"`
+	Status  Status       `json:"status"`
+	Pass    bool         `json:"pass"`
+	Before  int          `json:"before"`
+	After   int          `json:"after"` // 0 when resolved
+	Views   []ViewResult `json:"views,omitempty"`
+	Note    string       `json:"note,omitempty"`
+}
+
+// ViewResult compares a view the issue's task should have quietened with
+// the count its fix was expected to reach
+type ViewResult struct {
+	Label    string `json:"label"`
+	Before   int    `json:"before"`
+	Expected int    `json:"expected"`
+	Limit    int    `json:"limit"` // highest count that passes, given the tolerance
+	After    int    `json:"after"`
+	Pass     bool   `json:"pass"`
+}
+
+// Regression is something that got worse outside the targeted issues
+type Regression struct {
+	Kind     string          `json:"kind"` // new-issue, worse-issue, view or score
+	Title    string          `json:"title"`
+	Severity issues.Severity `json:"severity,omitempty"`
+	Before   int             `json:"before,omitempty"`
+	After    int             `json:"after,omitempty"`
+	Fails    bool            `json:"fails"`
+}
+
+// Check verifies the base report's targeted issues against head, a report
+// of the same interaction recorded after the fixes. Issues are matched as
+// compare matches them, by type and affected node labels; views by label.
+func Check(base, head *aioutput.Report, opts Options) (*Result, error) {
+	tol := DefaultTolerance
+	if opts.Tolerance != nil {
+		tol = *opts.Tolerance
+	}
+	if tol < 0 || tol >= 1 {
+		return nil, fmt.Errorf("tolerance must be between 0 and 1, got %g", tol)
+	}
+	failOn := opts.FailOn
+	if failOn == "" {
+		failOn = DefaultFailOn
+	}
+	plan := base.Plan
+	if plan == nil || base.Budget != nil {
+		plan = aioutput.BuildPlan(base)
+	}
+	targets, err := targeted(base, plan, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	headIssues := map[string]issues.Issue{}
+	for _, is := range head.Issues {
+		headIssues[compare.IssueKey(head, is.Issue)] = is.Issue
+	}
+	headViews := viewCounts(head)
+	baseViews := viewCounts(base)
+
+	res := &Result{
+		Pass:      true,
+		BaseScore: base.Summary.PerformanceScore,
+		HeadScore: head.Summary.PerformanceScore,
+		Issues:    []IssueResult{},
+	}
+	touched := map[string]bool{}
+	for _, t := range targets {
+		ir := IssueResult{IssueID: t.issue.ID, Title: t.issue.Title, Before: t.issue.UpdateCount}
+		if t.task != nil {
+			ir.Task, ir.Fix = t.task.ID, t.task.Fix
+			for _, v := range t.task.Verify.Views {
+				touched[v.Label] = true
+			}
+		}
+		for _, label := range affectedLabels(base, t.issue) {
+			touched[label] = true
+		}
+
+		after, persists := headIssues[compare.IssueKey(base, t.issue)]
+		if persists {
+			ir.After = after.UpdateCount
+		}
+		if t.task != nil {
+			for _, v := range t.task.Verify.Views {
+				if v.Expected == nil {
+					continue
+				}
+				limit := v.Before - int(math.Floor((1-tol)*float64(v.Before-*v.Expected)))
+				got := headViews[v.Label]
+				ir.Views = append(ir.Views, ViewResult{Label: v.Label, Before: v.Before, Expected: *v.Expected, Limit: limit, After: got, Pass: got <= limit})
+			}
+		}
+
+		switch {
+		case !persists:
+			ir.Status = StatusResolved
+		case len(ir.Views) > 0:
+			ir.Status = StatusImproved
+			for _, v := range ir.Views {
+				if !v.Pass {
+					ir.Status = StatusUnchanged
+				}
+			}
+			// Views may quieten through another task's fix; the issue's
+			// own count has to fall as well
+			if ir.Before > 0 && ir.After >= ir.Before {
+				ir.Status = StatusUnchanged
+				ir.Note = "the issue's own update count did not drop"
+			}
+		default:
+			ir.Note = "no estimate to check against; any drop counts"
+			ir.Status = StatusUnchanged
+			if ir.After < ir.Before {
+				ir.Status = StatusImproved
+			}
+		}
+		if persists && ir.Before > 0 && float64(ir.After) > float64(ir.Before)*(1+tol) {
+			ir.Status = StatusWorse
+		}
+		ir.Pass = ir.Status == StatusResolved || ir.Status == StatusImproved
+		res.Pass = res.Pass && ir.Pass
+		res.Issues = append(res.Issues, ir)
+	}
+
+	diff := compare.Reports(base, head, 0)
+	for _, ref := range diff.New {
+		fails := ref.Severity.AtLeast(failOn)
+		res.Regressions = append(res.Regressions, Regression{Kind: "new-issue", Title: ref.Title, Severity: ref.Severity, After: ref.UpdateCount, Fails: fails})
+		res.Pass = res.Pass && !fails
+	}
+	// A view the base report does not have is new, unless the base graph
+	// was trimmed to a token budget
+	trimmed := false
+	for _, e := range base.Elisions {
+		trimmed = trimmed || e.Section == "graph.nodes"
+	}
+	for _, label := range sortedLabels(headViews) {
+		before, seen := baseViews[label]
+		after := headViews[label]
+		if touched[label] || (!seen && trimmed) || after-before < minRegression || float64(after) <= float64(before)*(1+tol) {
+			continue
+		}
+		res.Regressions = append(res.Regressions, Regression{Kind: "view", Title: label, Before: before, After: after, Fails: true})
+		res.Pass = false
+	}
+	targetedIDs := map[string]bool{}
+	for _, t := range targets {
+		targetedIDs[t.issue.ID] = true
+	}
+	for _, c := range diff.Unchanged {
+		if targetedIDs[c.Base.ID] || c.Head.Severity == c.Base.Severity || !c.Head.Severity.AtLeast(c.Base.Severity) {
+			continue
+		}
+		fails := c.Head.Severity.AtLeast(failOn)
+		res.Regressions = append(res.Regressions, Regression{Kind: "worse-issue", Title: c.Head.Title, Severity: c.Head.Severity, Before: c.Base.UpdateCount, After: c.Head.UpdateCount, Fails: fails})
+		res.Pass = res.Pass && !fails
+	}
+	// A lower score follows from the issues above, which decide the
+	// verdict; it is reported for context
+	if res.HeadScore < res.BaseScore {
+		res.Regressions = append(res.Regressions, Regression{Kind: "score", Title: "performance score dropped", Before: res.BaseScore, After: res.HeadScore})
+	}
+
+	res.Verdict = "fail"
+	if res.Pass {
+		res.Verdict = "pass"
+	}
+	return res, nil
+}
+
+type target struct {
+	issue issues.Issue
+	task  *aioutput.Task
+}
+
+// targeted returns the issues to check with the plan task covering each:
+// those selected by ID or task, or else every planned issue
+func targeted(base *aioutput.Report, plan *aioutput.Plan, opts Options) ([]target, error) {
+	taskOf := map[string]*aioutput.Task{}
+	for i := range plan.Tasks {
+		for _, id := range plan.Tasks[i].IssueIDs {
+			taskOf[id] = &plan.Tasks[i]
+		}
+	}
+	want := map[string]bool{}
+	for _, id := range opts.Issues {
+		want[id] = true
+	}
+	for _, id := range opts.Tasks {
+		found := false
+		for _, t := range plan.Tasks {
+			if t.ID == id {
+				found = true
+				for _, is := range t.IssueIDs {
+					want[is] = true
+				}
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("task %s not found (%d tasks in the plan)", id, len(plan.Tasks))
+		}
+	}
+	selected := len(want) > 0
+
+	var out []target
+	for _, is := range base.Issues {
+		if (selected && want[is.ID]) || (!selected && taskOf[is.ID] != nil) {
+			out = append(out, target{issue: is.Issue, task: taskOf[is.ID]})
+			delete(want, is.ID)
+		}
+	}
+	for _, id := range opts.Issues {
+		if want[id] {
+			return nil, fmt.Errorf("issue %s not found (%d issues in the report)", id, len(base.Issues))
+		}
+	}
+	return out, nil
+}
+
+// viewCounts totals view update counts by label, since node IDs differ
+// between recordings
+func viewCounts(r *aioutput.Report) map[string]int {
+	out := map[string]int{}
+	for _, n := range r.Graph.Nodes {
+		if n.Type == string(graph.NodeView) {
+			out[n.Label] += n.UpdateCount
+		}
+	}
+	return out
+}
+
+func affectedLabels(r *aioutput.Report, is issues.Issue) []string {
+	labels := map[string]string{}
+	for _, n := range r.Graph.Nodes {
+		labels[n.ID] = n.Label
+	}
+	var out []string
+	for _, ref := range is.AffectedNodes {
+		if label, ok := labels[ref]; ok {
+			ref = label
+		}
+		out = append(out, ref)
+	}
+	return out
+}
+
+func sortedLabels(m map[string]int) []string {
+	out := make([]string, 0, len(m))
+	for label := range m {
+		out = append(out, label)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Text renders the result for a terminal
+func (r *Result) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Verdict: %s (score %d → %d)\n", strings.ToUpper(r.Verdict), r.BaseScore, r.HeadScore)
+	if len(r.Issues) == 0 {
+		b.WriteString("\nNo issues were targeted.\n")
+	} else {
+		b.WriteString("\nIssues:\n")
+	}
+	for _, ir := range r.Issues {
+		line := fmt.Sprintf("  %s  %-9s %s  %s", passLabel(ir.Pass), ir.Status, ir.IssueID, ir.Title)
+		if ir.Before > 0 && ir.Status != StatusResolved {
+			line += fmt.Sprintf(" (%d → %d)", ir.Before, ir.After)
+		}
+		b.WriteString(line + "\n")
+		for _, v := range ir.Views {
+			fmt.Fprintf(&b, "          %s: %d → %d, expected about %d (at most %d)\n", v.Label, v.Before, v.After, v.Expected, v.Limit)
+		}
+		if ir.Note != "" {
+			fmt.Fprintf(&b, "          %s\n", ir.Note)
+		}
+	}
+	if len(r.Regressions) > 0 {
+		b.WriteString("\nRegressions:\n")
+	}
+	for _, reg := range r.Regressions {
+		mark := "WARN"
+		if reg.Fails {
+			mark = "FAIL"
+		}
+		switch reg.Kind {
+		case "new-issue":
+			fmt.Fprintf(&b, "  %s  new issue [%s] %s\n", mark, reg.Severity, reg.Title)
+		case "worse-issue":
+			fmt.Fprintf(&b, "  %s  now [%s] %s\n", mark, reg.Severity, reg.Title)
+		default:
+			fmt.Fprintf(&b, "  %s  %s: %d → %d\n", mark, reg.Title, reg.Before, reg.After)
+		}
+	}
+	return b.String()
+}
+
+func passLabel(ok bool) string {
+	if ok {
+		return "PASS"
+	}
+	return "FAIL"
+}
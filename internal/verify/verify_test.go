@@ -0,0 +1,207 @@
+package verify
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/aioutput"
+	"github.com/greenstevester/swiftui-cause-effect-cli/internal/graph"
+)
+
+// clockGraph has a timer refreshing a clock and, through the same state, a
+// list; badge counts the updates of a view outside the cascade
+func clockGraph(list, badge int) *graph.Graph {
+	gr := graph.New()
+	gr.UpsertNode(&graph.Node{ID: "timer", Label: "Timer.publish", Type: graph.NodeCause, Count: 60})
+	gr.UpsertNode(&graph.Node{ID: "now", Label: "@State now", Type: graph.NodeState, Count: 60})
+	gr.UpsertNode(&graph.Node{ID: "clock", Label: "ClockView", Type: graph.NodeView, Count: 60})
+	gr.UpsertNode(&graph.Node{ID: "badge", Label: "BadgeView", Type: graph.NodeView, Count: badge})
+	gr.AddEdge(graph.Edge{From: "timer", To: "now"})
+	gr.AddEdge(graph.Edge{From: "now", To: "clock"})
+	if list > 0 {
+		gr.UpsertNode(&graph.Node{ID: "list", Label: "ListView", Type: graph.NodeView, Count: list})
+		gr.AddEdge(graph.Edge{From: "now", To: "list"})
+	}
+	return gr
+}
+
+func report(g *graph.Graph) *aioutput.Report {
+	gen, _ := aioutput.NewGenerator("")
+	return gen.Generate(g, aioutput.GenerateOptions{})
+}
+
+// timelineTask is the plan task confining the timer to the clock
+func timelineTask(t *testing.T, r *aioutput.Report) aioutput.Task {
+	t.Helper()
+	for _, task := range r.Plan.Tasks {
+		if task.Fix == "timeline-view" {
+			return task
+		}
+	}
+	t.Fatalf("expected a timeline-view task, got %+v", r.Plan.Tasks)
+	return aioutput.Task{}
+}
+
+func TestCheckResolved(t *testing.T) {
+	base := report(clockGraph(60, 8))
+	task := timelineTask(t, base)
+
+	res, err := Check(base, report(clockGraph(0, 8)), Options{Tasks: []string{task.ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Pass || res.Verdict != "pass" || len(res.Issues) != len(task.IssueIDs) {
+		t.Fatalf("expected the task's issues to pass, got %+v", res)
+	}
+	if ir := res.Issues[0]; ir.Status != StatusResolved || ir.Task != task.ID || ir.Fix != "timeline-view" {
+		t.Errorf("unexpected result %+v", ir)
+	}
+	if v := res.Issues[0].Views; len(v) != 1 || v[0].Label != "ListView" || v[0].After != 0 || !v[0].Pass {
+		t.Errorf("expected the list's updates to be checked, got %+v", v)
+	}
+
+	// The timer still fires as often, so its debounce task has not been
+	// done even though the list it fed went quiet
+	res, err = Check(base, report(clockGraph(0, 8)), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	debounced := false
+	for _, ir := range res.Issues {
+		if ir.Fix != "debounce" {
+			continue
+		}
+		debounced = true
+		if ir.Pass || ir.Note == "" {
+			t.Errorf("expected the debounce task to fail on the timer's count, got %+v", ir)
+		}
+	}
+	if !debounced {
+		t.Error("expected a debounce task in the plan")
+	}
+}
+
+func TestCheckUnchanged(t *testing.T) {
+	base := report(clockGraph(60, 8))
+	res, err := Check(base, report(clockGraph(60, 8)), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Pass || res.Verdict != "fail" {
+		t.Fatalf("nothing changed, expected a failure, got %+v", res)
+	}
+	planned := 0
+	for _, task := range base.Plan.Tasks {
+		planned += len(task.IssueIDs)
+	}
+	if len(res.Issues) != planned {
+		t.Errorf("expected every planned issue checked, got %d of %d", len(res.Issues), planned)
+	}
+	for _, ir := range res.Issues {
+		if ir.Pass || ir.Status != StatusUnchanged {
+			t.Errorf("%s: got %s", ir.IssueID, ir.Status)
+		}
+	}
+	if len(res.Regressions) != 0 {
+		t.Errorf("expected no regressions, got %+v", res.Regressions)
+	}
+}
+
+func TestCheckImprovedWithinTolerance(t *testing.T) {
+	base := report(clockGraph(60, 8))
+	task := timelineTask(t, base)
+
+	// 60 → 12 saves 80% of the expected 60 updates
+	res, err := Check(base, report(clockGraph(12, 8)), Options{Tasks: []string{task.ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ir := range res.Issues {
+		if !ir.Pass {
+			t.Errorf("%s: %s should pass within the tolerance, views %+v", ir.IssueID, ir.Status, ir.Views)
+		}
+	}
+	for _, tol := range []float64{0.1, 0} {
+		res, err = Check(base, report(clockGraph(12, 8)), Options{Tasks: []string{task.ID}, Tolerance: &tol})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Pass {
+			t.Errorf("a tolerance of %g should fail a fix saving 80%% of what it promised, got %+v", tol, res.Issues)
+		}
+	}
+}
+
+func TestCheckRegressions(t *testing.T) {
+	base := report(clockGraph(60, 8))
+	task := timelineTask(t, base)
+
+	res, err := Check(base, report(clockGraph(0, 40)), Options{Tasks: []string{task.ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Pass {
+		t.Fatalf("a busier untouched view should fail verification, got %+v", res)
+	}
+	var kinds []string
+	for _, reg := range res.Regressions {
+		kinds = append(kinds, reg.Kind)
+		if reg.Kind == "view" && (reg.Title != "BadgeView" || reg.Before != 8 || reg.After != 40 || !reg.Fails) {
+			t.Errorf("unexpected view regression %+v", reg)
+		}
+	}
+	if !strings.Contains(strings.Join(kinds, ","), "view") {
+		t.Errorf("expected a view regression, got %v", kinds)
+	}
+	if text := res.Text(); !strings.Contains(text, "Verdict: FAIL") || !strings.Contains(text, "BadgeView: 8 → 40") {
+		t.Errorf("unexpected text:\n%s", text)
+	}
+}
+
+func TestCheckNewView(t *testing.T) {
+	base := report(clockGraph(60, 8))
+	task := timelineTask(t, base)
+	g := clockGraph(0, 8)
+	g.UpsertNode(&graph.Node{ID: "popup", Label: "PopupView", Type: graph.NodeView, Count: 30})
+	g.UpsertNode(&graph.Node{ID: "hint", Label: "HintView", Type: graph.NodeView, Count: 2})
+
+	res, err := Check(base, report(g), Options{Tasks: []string{task.ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Pass {
+		t.Fatalf("a new busy view should fail verification, got %+v", res)
+	}
+	var views []Regression
+	for _, reg := range res.Regressions {
+		if reg.Kind == "view" {
+			views = append(views, reg)
+		}
+	}
+	if len(views) != 1 || views[0].Title != "PopupView" || views[0].Before != 0 || views[0].After != 30 || !views[0].Fails {
+		t.Errorf("expected only the busy new view as a regression, got %+v", views)
+	}
+}
+
+func TestCheckSelection(t *testing.T) {
+	base := report(clockGraph(60, 8))
+	head := report(clockGraph(60, 8))
+	one, negative := 1.0, -0.1
+	for _, opts := range []Options{
+		{Issues: []string{"issue-99"}},
+		{Tasks: []string{"task-99"}},
+		{Tolerance: &one},
+		{Tolerance: &negative},
+	} {
+		if _, err := Check(base, head, opts); err == nil {
+			t.Errorf("%+v: expected an error", opts)
+		}
+	}
+	res, err := Check(base, head, Options{Issues: []string{base.Issues[0].ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Issues) != 1 || res.Issues[0].IssueID != base.Issues[0].ID {
+		t.Errorf("expected only the selected issue, got %+v", res.Issues)
+	}
+}